@@ -1,22 +1,278 @@
+-- Partitioned by month of created_at, partitions are created ahead and
+-- expired ones dropped by the application (see create_message_partitions
+-- and drop_message_partitions). The partition key has to be part of the
+-- primary key
 CREATE TABLE IF NOT EXISTS messages (
-    id BIGSERIAL PRIMARY KEY,
-    phone_number VARCHAR(20) NOT NULL,
+    id BIGSERIAL,
+    -- phone_number and content are encrypted by the application when it
+    -- has a keyring, phone numbers are then looked up by phone_number_hash
+    phone_number TEXT NOT NULL,
     content TEXT NOT NULL,
-    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'sent', 'failed')),
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'sent', 'failed', 'suppressed', 'delivered', 'cancelled')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
     sent_at TIMESTAMP WITH TIME ZONE NULL,
     message_id VARCHAR(255) NULL,
     error_message TEXT NULL,
-    updated_at TIMESTAMP WITH TIME ZONE NULL
-);
+    -- Class of the last provider error, retryable ones are sent again at
+    -- scheduled_at until attempts runs out
+    error_class VARCHAR(32) NULL CHECK (error_class IN ('transient', 'rate_limited', 'invalid_recipient', 'auth_failure', 'rejected')),
+    attempts SMALLINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    ordering_key VARCHAR(64) NULL,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    channel VARCHAR(20) NOT NULL DEFAULT 'sms',
+    country_code VARCHAR(2) NULL,
+    segments SMALLINT NOT NULL DEFAULT 1,
+    encoding VARCHAR(8) NOT NULL DEFAULT 'GSM-7' CHECK (encoding IN ('GSM-7', 'UCS-2')),
+    category VARCHAR(20) NOT NULL DEFAULT 'transactional' CHECK (category IN ('transactional', 'marketing')),
+    timezone VARCHAR(64) NULL,
+    scheduled_at TIMESTAMP WITH TIME ZONE NULL,
+    campaign_id BIGINT NULL,
+    callback_url TEXT NULL,
+    phone_number_hash CHAR(64) NULL,
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+-- Creates the partition of messages holding the month of month_start
+-- (UTC), returns its name or NULL when it already exists
+CREATE OR REPLACE FUNCTION create_message_partition(month_start DATE)
+RETURNS TEXT AS $$
+DECLARE
+    partition_name TEXT := format('messages_%s', to_char(month_start, 'YYYY_MM'));
+    range_start TIMESTAMP WITH TIME ZONE := date_trunc('month', month_start)::TIMESTAMP AT TIME ZONE 'UTC';
+BEGIN
+    IF to_regclass(partition_name) IS NOT NULL THEN
+        RETURN NULL;
+    END IF;
+
+    EXECUTE format('CREATE TABLE %I PARTITION OF messages FOR VALUES FROM (%L) TO (%L)',
+        partition_name, range_start, range_start + INTERVAL '1 month');
+    RETURN partition_name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Creates the partitions of the current month and of the months_ahead
+-- next ones that don't exist yet, returns their names. Replicas running it
+-- at the same time are serialized
+CREATE OR REPLACE FUNCTION create_message_partitions(months_ahead INTEGER DEFAULT 3)
+RETURNS SETOF TEXT AS $$
+DECLARE
+    current_month DATE := date_trunc('month', CURRENT_TIMESTAMP AT TIME ZONE 'UTC')::DATE;
+    partition_name TEXT;
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('messages_partitions'));
+
+    FOR i IN 0..months_ahead LOOP
+        partition_name := create_message_partition((current_month + make_interval(months => i))::DATE);
+        IF partition_name IS NOT NULL THEN
+            RETURN NEXT partition_name;
+        END IF;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Detaches and drops the partitions of the months ending more than
+-- keep_months months before the current one, returns their names. A
+-- partition still holding pending or processing messages is kept
+CREATE OR REPLACE FUNCTION drop_message_partitions(keep_months INTEGER)
+RETURNS SETOF TEXT AS $$
+DECLARE
+    cutoff DATE := (date_trunc('month', CURRENT_TIMESTAMP AT TIME ZONE 'UTC') - make_interval(months => keep_months))::DATE;
+    partition_name TEXT;
+    unsent BOOLEAN;
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('messages_partitions'));
+
+    FOR partition_name IN
+        SELECT c.relname
+        FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        WHERE i.inhparent = 'messages'::regclass
+          AND c.relname ~ '^messages_[0-9]{4}_[0-9]{2}$'
+          AND to_date(substr(c.relname, 10), 'YYYY_MM') < cutoff
+        ORDER BY c.relname
+    LOOP
+        EXECUTE format('SELECT EXISTS (SELECT 1 FROM %I WHERE status IN (''pending'', ''processing''))', partition_name)
+            INTO unsent;
+        IF unsent THEN
+            CONTINUE;
+        END IF;
+
+        EXECUTE format('ALTER TABLE messages DETACH PARTITION %I', partition_name);
+        EXECUTE format('DROP TABLE %I', partition_name);
+        RETURN NEXT partition_name;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT create_message_partitions(3);
+
+-- Messages moved out of messages by the retention job in archive mode. The
+-- job inserts the rows of messages as they are, a column added to messages
+-- has to be added here before archived_at
+CREATE TABLE IF NOT EXISTS messages_archive (LIKE messages);
+ALTER TABLE messages_archive ADD PRIMARY KEY (id);
+ALTER TABLE messages_archive ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_messages_archive_created ON messages_archive (created_at);
 
 -- Create indexes for better performance
 CREATE INDEX IF NOT EXISTS idx_messages_status_created ON messages (status, created_at);
 CREATE INDEX IF NOT EXISTS idx_messages_phone_number ON messages (phone_number);
+CREATE INDEX IF NOT EXISTS idx_messages_phone_number_hash ON messages (phone_number_hash) WHERE phone_number_hash IS NOT NULL;
 -- CREATE INDEX IF NOT EXISTS idx_messages_message_id ON messages (message_id);
 -- CREATE INDEX IF NOT EXISTS idx_messages_sent_at ON messages (sent_at);
 -- CREATE INDEX IF NOT EXISTS idx_messages_updated_at ON messages (updated_at);
+-- Keeps pending scans independent of the history accumulated in a partition
+CREATE INDEX IF NOT EXISTS idx_messages_pending ON messages (created_at) WHERE status = 'pending';
 CREATE INDEX IF NOT EXISTS idx_messages_processing_stuck ON messages (status, updated_at) WHERE status = 'processing';
+-- Sequence key lookups for ordered delivery (ordering key unless empty,
+-- phone number otherwise, by its hash when encrypted)
+CREATE INDEX IF NOT EXISTS idx_messages_sequence_key ON messages ((COALESCE(NULLIF(ordering_key, ''), phone_number_hash, phone_number)), created_at)
+    WHERE status IN ('pending', 'processing');
+-- Delivery reports look messages up by the id the provider returned
+CREATE INDEX IF NOT EXISTS idx_messages_message_id ON messages (message_id) WHERE message_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_messages_campaign_status ON messages (campaign_id, status) WHERE campaign_id IS NOT NULL;
+-- Statistics of sent messages are computed over a sent_at range
+CREATE INDEX IF NOT EXISTS idx_messages_sent_at_stats ON messages (sent_at) WHERE sent_at IS NOT NULL;
+-- Messages deferred by quiet hours
+CREATE INDEX IF NOT EXISTS idx_messages_scheduled_at ON messages (scheduled_at)
+    WHERE status = 'pending' AND scheduled_at IS NOT NULL;
+
+-- Opted-out recipients, nothing is sent to them on that channel for that tenant
+CREATE TABLE IF NOT EXISTS suppressions (
+    id BIGSERIAL PRIMARY KEY,
+    -- Encrypted like the phone numbers of messages
+    phone_number TEXT NOT NULL,
+    channel VARCHAR(20) NOT NULL DEFAULT 'sms',
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    reason TEXT NULL,
+    source VARCHAR(20) NOT NULL DEFAULT 'admin' CHECK (source IN ('admin', 'keyword')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    phone_number_hash CHAR(64) NULL
+);
+
+-- A recipient is suppressed once, by the hash of its phone number when encrypted
+CREATE UNIQUE INDEX IF NOT EXISTS idx_suppressions_recipient
+    ON suppressions ((COALESCE(phone_number_hash, phone_number)), channel, tenant_id);
+
+-- Daily windows, in the recipient's local time, during which messages of a
+-- category aren't sent for a tenant. start_time after end_time spans midnight
+CREATE TABLE IF NOT EXISTS quiet_hours (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    category VARCHAR(20) NOT NULL DEFAULT 'marketing' CHECK (category IN ('transactional', 'marketing')),
+    start_time VARCHAR(5) NOT NULL CHECK (start_time ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+    end_time VARCHAR(5) NOT NULL CHECK (end_time ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    UNIQUE (tenant_id, category)
+);
+
+-- Broadcast of one content to an uploaded list of recipients, which are
+-- expanded into messages in chunks while the campaign is running
+CREATE TABLE IF NOT EXISTS campaigns (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    name VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    category VARCHAR(20) NOT NULL DEFAULT 'marketing' CHECK (category IN ('transactional', 'marketing')),
+    segments SMALLINT NOT NULL DEFAULT 1,
+    encoding VARCHAR(8) NOT NULL DEFAULT 'GSM-7' CHECK (encoding IN ('GSM-7', 'UCS-2')),
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'running', 'paused', 'completed', 'cancelled')),
+    scheduled_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaigns_tenant_created ON campaigns (tenant_id, created_at);
+
+CREATE TABLE IF NOT EXISTS campaign_recipients (
+    id BIGSERIAL PRIMARY KEY,
+    campaign_id BIGINT NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    -- Encrypted like the phone numbers of messages
+    phone_number TEXT NOT NULL,
+    country_code VARCHAR(2) NULL,
+    timezone VARCHAR(64) NULL,
+    expanded_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    phone_number_hash CHAR(64) NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_campaign_recipients_recipient
+    ON campaign_recipients (campaign_id, (COALESCE(phone_number_hash, phone_number)));
+
+CREATE INDEX IF NOT EXISTS idx_campaign_recipients_unexpanded ON campaign_recipients (campaign_id, id)
+    WHERE expanded_at IS NULL;
+
+ALTER TABLE messages ADD CONSTRAINT fk_messages_campaign FOREIGN KEY (campaign_id) REFERENCES campaigns (id);
+
+-- Endpoints receiving the message lifecycle events of a tenant
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_tenant ON webhook_subscriptions (tenant_id);
+
+-- Durable delivery queue, also the delivery log of each subscription
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    -- JSON, encrypted like the message it holds
+    payload TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivering', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_status_code INTEGER NULL,
+    last_error TEXT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    delivered_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at)
+    WHERE status IN ('pending', 'delivering');
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, created_at);
+
+-- Administrative actions, who took them and from where. Records are
+-- immutable: they can only be inserted
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    actor VARCHAR(64) NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    parameters JSONB NOT NULL DEFAULT '{}',
+    source_ip VARCHAR(64) NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor ON audit_logs (actor, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action, created_at);
+
+CREATE OR REPLACE FUNCTION reject_audit_log_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only, % is not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_logs_immutable ON audit_logs;
+CREATE TRIGGER audit_logs_immutable
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_log_change();
+
+DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs;
+CREATE TRIGGER audit_logs_no_truncate
+    BEFORE TRUNCATE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_log_change();
 
 -- Insert sample data for testing
 INSERT INTO messages (phone_number, content, status) VALUES 
@@ -33,6 +289,7 @@ INSERT INTO messages (phone_number, content, status) VALUES
 CREATE VIEW sent_messages AS 
 SELECT 
     id,
+    tenant_id,
     phone_number,
     content,
     status,
@@ -41,32 +298,92 @@ SELECT
     message_id,
     EXTRACT(EPOCH FROM (sent_at - created_at)) as processing_time_seconds
 FROM messages 
-WHERE status = 'sent'
+WHERE status IN ('sent', 'delivered')
 ORDER BY sent_at DESC;
 
 -- Function for getting_unsent_messages atomicly  
 -- (For avoid the go application getting the same messages)
+-- Messages deferred by quiet hours wait until their scheduled_at, messages
+-- of a campaign are only claimed while it is running
 CREATE OR REPLACE FUNCTION get_unsent_messages(batch_size INTEGER DEFAULT 2)
-RETURNS TABLE (
-    id BIGINT,
-    phone_number VARCHAR(20),
-    content TEXT,
-    created_at TIMESTAMP WITH TIME ZONE
-) AS $$
+RETURNS SETOF messages AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE messages 
+    SET status = 'processing',
+        updated_at = CURRENT_TIMESTAMP
+    WHERE (messages.id, messages.created_at) IN (
+        SELECT m.id, m.created_at
+        FROM messages m
+        WHERE m.status = 'pending'
+          AND (m.scheduled_at IS NULL OR m.scheduled_at <= CURRENT_TIMESTAMP)
+          AND (m.campaign_id IS NULL OR EXISTS (
+              SELECT 1 FROM campaigns c WHERE c.id = m.campaign_id AND c.status = 'running'
+          ))
+        ORDER BY m.created_at ASC
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING messages.*;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Same as get_unsent_messages but keeps messages of one sequence key
+-- (ordering_key unless empty, phone_number otherwise) strictly
+-- sequential: only the oldest pending message of a key is claimed, and
+-- only when no other message of that key is still processing. A deferred
+-- message holds back the later ones of its key, so does one left in
+-- processing until the application resets it after its stuck timeout
+CREATE OR REPLACE FUNCTION get_unsent_messages_ordered(batch_size INTEGER DEFAULT 2)
+RETURNS SETOF messages AS $$
 BEGIN
     RETURN QUERY
     UPDATE messages 
     SET status = 'processing',
         updated_at = CURRENT_TIMESTAMP
-    WHERE messages.id IN (
-        SELECT m.id
+    WHERE (messages.id, messages.created_at) IN (
+        SELECT m.id, m.created_at
         FROM messages m
         WHERE m.status = 'pending'
+          AND (m.scheduled_at IS NULL OR m.scheduled_at <= CURRENT_TIMESTAMP)
+          AND (m.campaign_id IS NULL OR EXISTS (
+              SELECT 1 FROM campaigns c WHERE c.id = m.campaign_id AND c.status = 'running'
+          ))
+          AND NOT EXISTS (
+              SELECT 1
+              FROM messages p
+              WHERE COALESCE(NULLIF(p.ordering_key, ''), p.phone_number_hash, p.phone_number) = COALESCE(NULLIF(m.ordering_key, ''), m.phone_number_hash, m.phone_number)
+                AND (p.status = 'processing'
+                     OR (p.status = 'pending' AND (p.created_at, p.id) < (m.created_at, m.id)))
+          )
         ORDER BY m.created_at ASC
         LIMIT batch_size
         FOR UPDATE SKIP LOCKED
     )
-    RETURNING messages.id, messages.phone_number, messages.content, messages.created_at;
+    RETURNING messages.*;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Claims the webhook deliveries that are due, along with the ones claimed
+-- more than lease_seconds ago by an instance that never finished them
+CREATE OR REPLACE FUNCTION claim_webhook_deliveries(batch_size INTEGER DEFAULT 50, lease_seconds INTEGER DEFAULT 60)
+RETURNS SETOF webhook_deliveries AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE webhook_deliveries
+    SET status = 'delivering',
+        attempts = webhook_deliveries.attempts + 1,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE webhook_deliveries.id IN (
+        SELECT d.id
+        FROM webhook_deliveries d
+        WHERE (d.status = 'pending' AND d.next_attempt_at <= CURRENT_TIMESTAMP)
+           OR (d.status = 'delivering' AND d.updated_at < CURRENT_TIMESTAMP - make_interval(secs => lease_seconds))
+        ORDER BY d.next_attempt_at ASC
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING webhook_deliveries.*;
 END;
 $$ LANGUAGE plpgsql;
 
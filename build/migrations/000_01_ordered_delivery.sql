@@ -0,0 +1,68 @@
+-- Adds the ordering key of messages and the ordered claim function on a
+-- database created by the first init.sql. The build/migrations/000_*
+-- ones bring such a database, one feature at a time, to the schema
+-- 001_partition_messages.sql starts from: run them in order before it.
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/000_01_ordered_delivery.sql
+
+BEGIN;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS ordering_key VARCHAR(64) NULL;
+
+-- Sequence key lookups for ordered delivery (ordering key, phone number otherwise)
+CREATE INDEX IF NOT EXISTS idx_messages_sequence_key ON messages ((COALESCE(ordering_key, phone_number)), created_at)
+    WHERE status IN ('pending', 'processing');
+
+-- The claim functions return whole messages, the old one only had a few columns
+DROP FUNCTION IF EXISTS get_unsent_messages(INTEGER);
+
+CREATE OR REPLACE FUNCTION get_unsent_messages(batch_size INTEGER DEFAULT 2)
+RETURNS SETOF messages AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE messages 
+    SET status = 'processing',
+        updated_at = CURRENT_TIMESTAMP
+    WHERE messages.id IN (
+        SELECT m.id
+        FROM messages m
+        WHERE m.status = 'pending'
+        ORDER BY m.created_at ASC
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING messages.*;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Same as get_unsent_messages but keeps messages of one sequence key
+-- (ordering_key, phone_number otherwise) strictly sequential: only the
+-- oldest pending message of a key is claimed, and only when no other
+-- message of that key is still processing
+CREATE OR REPLACE FUNCTION get_unsent_messages_ordered(batch_size INTEGER DEFAULT 2)
+RETURNS SETOF messages AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE messages 
+    SET status = 'processing',
+        updated_at = CURRENT_TIMESTAMP
+    WHERE messages.id IN (
+        SELECT m.id
+        FROM messages m
+        WHERE m.status = 'pending'
+          AND NOT EXISTS (
+              SELECT 1
+              FROM messages p
+              WHERE COALESCE(p.ordering_key, p.phone_number) = COALESCE(m.ordering_key, m.phone_number)
+                AND (p.status = 'processing'
+                     OR (p.status = 'pending' AND (p.created_at, p.id) < (m.created_at, m.id)))
+          )
+        ORDER BY m.created_at ASC
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING messages.*;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
@@ -0,0 +1,26 @@
+-- Adds the suppressed status, the tenant and channel of messages and the
+-- suppressions table on a database created by an older init.sql.
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/000_02_suppressions.sql
+
+BEGIN;
+
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_status_check;
+ALTER TABLE messages ADD CONSTRAINT messages_status_check
+    CHECK (status IN ('pending', 'processing', 'sent', 'failed', 'suppressed'));
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS channel VARCHAR(20) NOT NULL DEFAULT 'sms';
+
+-- Opted-out recipients, nothing is sent to them on that channel for that tenant
+CREATE TABLE IF NOT EXISTS suppressions (
+    id BIGSERIAL PRIMARY KEY,
+    phone_number VARCHAR(20) NOT NULL,
+    channel VARCHAR(20) NOT NULL DEFAULT 'sms',
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    reason TEXT NULL,
+    source VARCHAR(20) NOT NULL DEFAULT 'admin' CHECK (source IN ('admin', 'keyword')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (phone_number, channel, tenant_id)
+);
+
+COMMIT;
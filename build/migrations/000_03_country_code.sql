@@ -0,0 +1,10 @@
+-- Adds the country code of the normalized phone number of messages on a
+-- database created by an older init.sql.
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/000_03_country_code.sql
+
+BEGIN;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS country_code VARCHAR(2) NULL;
+
+COMMIT;
@@ -0,0 +1,13 @@
+-- Adds the SMS encoding and segment count of messages on a database
+-- created by an older init.sql. Existing messages keep the defaults, one
+-- GSM-7 segment.
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/000_04_sms_segments.sql
+
+BEGIN;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS segments SMALLINT NOT NULL DEFAULT 1;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS encoding VARCHAR(8) NOT NULL DEFAULT 'GSM-7'
+    CHECK (encoding IN ('GSM-7', 'UCS-2'));
+
+COMMIT;
@@ -0,0 +1,84 @@
+-- Adds the category, timezone and schedule of messages, the quiet_hours
+-- table, and makes the claim functions skip deferred messages, on a
+-- database created by an older init.sql.
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/000_05_quiet_hours.sql
+
+BEGIN;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS category VARCHAR(20) NOT NULL DEFAULT 'transactional'
+    CHECK (category IN ('transactional', 'marketing'));
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NULL;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS scheduled_at TIMESTAMP WITH TIME ZONE NULL;
+
+-- Messages deferred by quiet hours
+CREATE INDEX IF NOT EXISTS idx_messages_scheduled_at ON messages (scheduled_at)
+    WHERE status = 'pending' AND scheduled_at IS NOT NULL;
+
+-- Daily windows, in the recipient's local time, during which messages of a
+-- category aren't sent for a tenant. start_time after end_time spans midnight
+CREATE TABLE IF NOT EXISTS quiet_hours (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    category VARCHAR(20) NOT NULL DEFAULT 'marketing' CHECK (category IN ('transactional', 'marketing')),
+    start_time VARCHAR(5) NOT NULL CHECK (start_time ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+    end_time VARCHAR(5) NOT NULL CHECK (end_time ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    UNIQUE (tenant_id, category)
+);
+
+-- Messages deferred by quiet hours wait until their scheduled_at
+CREATE OR REPLACE FUNCTION get_unsent_messages(batch_size INTEGER DEFAULT 2)
+RETURNS SETOF messages AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE messages 
+    SET status = 'processing',
+        updated_at = CURRENT_TIMESTAMP
+    WHERE messages.id IN (
+        SELECT m.id
+        FROM messages m
+        WHERE m.status = 'pending'
+          AND (m.scheduled_at IS NULL OR m.scheduled_at <= CURRENT_TIMESTAMP)
+        ORDER BY m.created_at ASC
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING messages.*;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Same as get_unsent_messages but keeps messages of one sequence key
+-- (ordering_key, phone_number otherwise) strictly sequential: only the
+-- oldest pending message of a key is claimed, and only when no other
+-- message of that key is still processing. A deferred message holds back
+-- the later ones of its key
+CREATE OR REPLACE FUNCTION get_unsent_messages_ordered(batch_size INTEGER DEFAULT 2)
+RETURNS SETOF messages AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE messages 
+    SET status = 'processing',
+        updated_at = CURRENT_TIMESTAMP
+    WHERE messages.id IN (
+        SELECT m.id
+        FROM messages m
+        WHERE m.status = 'pending'
+          AND (m.scheduled_at IS NULL OR m.scheduled_at <= CURRENT_TIMESTAMP)
+          AND NOT EXISTS (
+              SELECT 1
+              FROM messages p
+              WHERE COALESCE(p.ordering_key, p.phone_number) = COALESCE(m.ordering_key, m.phone_number)
+                AND (p.status = 'processing'
+                     OR (p.status = 'pending' AND (p.created_at, p.id) < (m.created_at, m.id)))
+          )
+        ORDER BY m.created_at ASC
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING messages.*;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
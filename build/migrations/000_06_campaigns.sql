@@ -0,0 +1,129 @@
+-- Adds the delivered and cancelled statuses, the campaign of messages and
+-- the campaigns tables, and makes the claim functions skip the messages
+-- of campaigns that aren't running, on a database created by an older
+-- init.sql.
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/000_06_campaigns.sql
+
+BEGIN;
+
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS messages_status_check;
+ALTER TABLE messages ADD CONSTRAINT messages_status_check
+    CHECK (status IN ('pending', 'processing', 'sent', 'failed', 'suppressed', 'delivered', 'cancelled'));
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS campaign_id BIGINT NULL;
+
+-- Delivery reports look messages up by the id the provider returned
+CREATE INDEX IF NOT EXISTS idx_messages_message_id ON messages (message_id) WHERE message_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_messages_campaign_status ON messages (campaign_id, status) WHERE campaign_id IS NOT NULL;
+
+-- Broadcast of one content to an uploaded list of recipients, which are
+-- expanded into messages in chunks while the campaign is running
+CREATE TABLE IF NOT EXISTS campaigns (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    name VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    category VARCHAR(20) NOT NULL DEFAULT 'marketing' CHECK (category IN ('transactional', 'marketing')),
+    segments SMALLINT NOT NULL DEFAULT 1,
+    encoding VARCHAR(8) NOT NULL DEFAULT 'GSM-7' CHECK (encoding IN ('GSM-7', 'UCS-2')),
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'running', 'paused', 'completed', 'cancelled')),
+    scheduled_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaigns_tenant_created ON campaigns (tenant_id, created_at);
+
+CREATE TABLE IF NOT EXISTS campaign_recipients (
+    id BIGSERIAL PRIMARY KEY,
+    campaign_id BIGINT NOT NULL REFERENCES campaigns (id) ON DELETE CASCADE,
+    phone_number VARCHAR(20) NOT NULL,
+    country_code VARCHAR(2) NULL,
+    timezone VARCHAR(64) NULL,
+    expanded_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (campaign_id, phone_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_campaign_recipients_unexpanded ON campaign_recipients (campaign_id, id)
+    WHERE expanded_at IS NULL;
+
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS fk_messages_campaign;
+ALTER TABLE messages ADD CONSTRAINT fk_messages_campaign FOREIGN KEY (campaign_id) REFERENCES campaigns (id);
+
+CREATE OR REPLACE VIEW sent_messages AS 
+SELECT 
+    id,
+    phone_number,
+    content,
+    status,
+    created_at,
+    sent_at,
+    message_id,
+    EXTRACT(EPOCH FROM (sent_at - created_at)) as processing_time_seconds
+FROM messages 
+WHERE status IN ('sent', 'delivered')
+ORDER BY sent_at DESC;
+
+-- Messages deferred by quiet hours wait until their scheduled_at, messages
+-- of a campaign are only claimed while it is running
+CREATE OR REPLACE FUNCTION get_unsent_messages(batch_size INTEGER DEFAULT 2)
+RETURNS SETOF messages AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE messages 
+    SET status = 'processing',
+        updated_at = CURRENT_TIMESTAMP
+    WHERE messages.id IN (
+        SELECT m.id
+        FROM messages m
+        WHERE m.status = 'pending'
+          AND (m.scheduled_at IS NULL OR m.scheduled_at <= CURRENT_TIMESTAMP)
+          AND (m.campaign_id IS NULL OR EXISTS (
+              SELECT 1 FROM campaigns c WHERE c.id = m.campaign_id AND c.status = 'running'
+          ))
+        ORDER BY m.created_at ASC
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING messages.*;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Same as get_unsent_messages but keeps messages of one sequence key
+-- (ordering_key, phone_number otherwise) strictly sequential: only the
+-- oldest pending message of a key is claimed, and only when no other
+-- message of that key is still processing. A deferred message holds back
+-- the later ones of its key
+CREATE OR REPLACE FUNCTION get_unsent_messages_ordered(batch_size INTEGER DEFAULT 2)
+RETURNS SETOF messages AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE messages 
+    SET status = 'processing',
+        updated_at = CURRENT_TIMESTAMP
+    WHERE messages.id IN (
+        SELECT m.id
+        FROM messages m
+        WHERE m.status = 'pending'
+          AND (m.scheduled_at IS NULL OR m.scheduled_at <= CURRENT_TIMESTAMP)
+          AND (m.campaign_id IS NULL OR EXISTS (
+              SELECT 1 FROM campaigns c WHERE c.id = m.campaign_id AND c.status = 'running'
+          ))
+          AND NOT EXISTS (
+              SELECT 1
+              FROM messages p
+              WHERE COALESCE(p.ordering_key, p.phone_number) = COALESCE(m.ordering_key, m.phone_number)
+                AND (p.status = 'processing'
+                     OR (p.status = 'pending' AND (p.created_at, p.id) < (m.created_at, m.id)))
+          )
+        ORDER BY m.created_at ASC
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING messages.*;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
@@ -0,0 +1,65 @@
+-- Adds the webhook subscriptions, their delivery queue and its claim
+-- function on a database created by an older init.sql.
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/000_07_webhooks.sql
+
+BEGIN;
+
+-- Endpoints receiving the message lifecycle events of a tenant
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_tenant ON webhook_subscriptions (tenant_id);
+
+-- Durable delivery queue, also the delivery log of each subscription
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivering', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_status_code INTEGER NULL,
+    last_error TEXT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    delivered_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at)
+    WHERE status IN ('pending', 'delivering');
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries (subscription_id, created_at);
+
+-- Claims the webhook deliveries that are due, along with the ones claimed
+-- more than lease_seconds ago by an instance that never finished them
+CREATE OR REPLACE FUNCTION claim_webhook_deliveries(batch_size INTEGER DEFAULT 50, lease_seconds INTEGER DEFAULT 60)
+RETURNS SETOF webhook_deliveries AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE webhook_deliveries
+    SET status = 'delivering',
+        attempts = webhook_deliveries.attempts + 1,
+        updated_at = CURRENT_TIMESTAMP
+    WHERE webhook_deliveries.id IN (
+        SELECT d.id
+        FROM webhook_deliveries d
+        WHERE (d.status = 'pending' AND d.next_attempt_at <= CURRENT_TIMESTAMP)
+           OR (d.status = 'delivering' AND d.updated_at < CURRENT_TIMESTAMP - make_interval(secs => lease_seconds))
+        ORDER BY d.next_attempt_at ASC
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING webhook_deliveries.*;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
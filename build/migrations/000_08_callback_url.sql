@@ -0,0 +1,10 @@
+-- Adds the callback url of messages on a database created by an older
+-- init.sql.
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/000_08_callback_url.sql
+
+BEGIN;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS callback_url TEXT NULL;
+
+COMMIT;
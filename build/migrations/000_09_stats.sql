@@ -0,0 +1,29 @@
+-- Adds the index statistics are computed with and the tenant of the
+-- sent_messages view on a database created by an older init.sql.
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/000_09_stats.sql
+
+BEGIN;
+
+-- Statistics of sent messages are computed over a sent_at range
+CREATE INDEX IF NOT EXISTS idx_messages_sent_at_stats ON messages (sent_at) WHERE sent_at IS NOT NULL;
+
+-- A column can only be added at the end of a view by replacing it
+DROP VIEW IF EXISTS sent_messages;
+
+CREATE VIEW sent_messages AS 
+SELECT 
+    id,
+    tenant_id,
+    phone_number,
+    content,
+    status,
+    created_at,
+    sent_at,
+    message_id,
+    EXTRACT(EPOCH FROM (sent_at - created_at)) as processing_time_seconds
+FROM messages 
+WHERE status IN ('sent', 'delivered')
+ORDER BY sent_at DESC;
+
+COMMIT;
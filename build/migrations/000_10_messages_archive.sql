@@ -0,0 +1,16 @@
+-- Adds the archive table of the retention job on a database created by an
+-- older init.sql. Run it after the other 000_* ones, the archive copies
+-- the columns messages has at that point.
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/000_10_messages_archive.sql
+
+BEGIN;
+
+-- Messages moved out of messages by the retention job in archive mode. The
+-- job inserts the rows of messages as they are, a column added to messages
+-- has to be added here before archived_at
+CREATE TABLE IF NOT EXISTS messages_archive (LIKE messages, PRIMARY KEY (id));
+ALTER TABLE messages_archive ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_messages_archive_created ON messages_archive (created_at);
+
+COMMIT;
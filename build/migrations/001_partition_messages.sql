@@ -0,0 +1,255 @@
+-- Converts a messages table created before the partitioning to the
+-- monthly partitioned layout of init.sql. It runs in a single transaction
+-- holding an exclusive lock on messages, stop the service for the duration. The data is copied, the old table is kept as
+-- messages_unpartitioned and can be dropped once the result is checked:
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/001_partition_messages.sql
+--   DROP TABLE messages_unpartitioned;
+
+BEGIN;
+
+LOCK TABLE messages IN ACCESS EXCLUSIVE MODE;
+
+-- created_at is the partition key, it can't be null anymore
+UPDATE messages SET created_at = COALESCE(updated_at, CURRENT_TIMESTAMP) WHERE created_at IS NULL;
+
+-- The view and the claim functions are bound to the row type of the old table
+DROP VIEW IF EXISTS sent_messages;
+DROP FUNCTION IF EXISTS get_unsent_messages(INTEGER);
+DROP FUNCTION IF EXISTS get_unsent_messages_ordered(INTEGER);
+
+-- Index and constraint names are taken back by the new table, the id
+-- sequence is handed over so ids keep increasing
+ALTER TABLE messages RENAME TO messages_unpartitioned;
+ALTER TABLE messages_unpartitioned RENAME CONSTRAINT messages_pkey TO messages_unpartitioned_pkey;
+ALTER TABLE messages_unpartitioned DROP CONSTRAINT IF EXISTS fk_messages_campaign;
+DROP INDEX IF EXISTS
+    idx_messages_status_created,
+    idx_messages_phone_number,
+    idx_messages_pending,
+    idx_messages_processing_stuck,
+    idx_messages_sequence_key,
+    idx_messages_message_id,
+    idx_messages_campaign_status,
+    idx_messages_sent_at_stats,
+    idx_messages_scheduled_at;
+ALTER SEQUENCE messages_id_seq OWNED BY NONE;
+
+CREATE TABLE messages (
+    id BIGINT NOT NULL DEFAULT nextval('messages_id_seq'),
+    phone_number VARCHAR(20) NOT NULL,
+    content TEXT NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'sent', 'failed', 'suppressed', 'delivered', 'cancelled')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP WITH TIME ZONE NULL,
+    message_id VARCHAR(255) NULL,
+    error_message TEXT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NULL,
+    ordering_key VARCHAR(64) NULL,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    channel VARCHAR(20) NOT NULL DEFAULT 'sms',
+    country_code VARCHAR(2) NULL,
+    segments SMALLINT NOT NULL DEFAULT 1,
+    encoding VARCHAR(8) NOT NULL DEFAULT 'GSM-7' CHECK (encoding IN ('GSM-7', 'UCS-2')),
+    category VARCHAR(20) NOT NULL DEFAULT 'transactional' CHECK (category IN ('transactional', 'marketing')),
+    timezone VARCHAR(64) NULL,
+    scheduled_at TIMESTAMP WITH TIME ZONE NULL,
+    campaign_id BIGINT NULL,
+    callback_url TEXT NULL,
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+-- Creates the partition of messages holding the month of month_start
+-- (UTC), returns its name or NULL when it already exists
+CREATE OR REPLACE FUNCTION create_message_partition(month_start DATE)
+RETURNS TEXT AS $$
+DECLARE
+    partition_name TEXT := format('messages_%s', to_char(month_start, 'YYYY_MM'));
+    range_start TIMESTAMP WITH TIME ZONE := date_trunc('month', month_start)::TIMESTAMP AT TIME ZONE 'UTC';
+BEGIN
+    IF to_regclass(partition_name) IS NOT NULL THEN
+        RETURN NULL;
+    END IF;
+
+    EXECUTE format('CREATE TABLE %I PARTITION OF messages FOR VALUES FROM (%L) TO (%L)',
+        partition_name, range_start, range_start + INTERVAL '1 month');
+    RETURN partition_name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Creates the partitions of the current month and of the months_ahead
+-- next ones that don't exist yet, returns their names. Replicas running it
+-- at the same time are serialized
+CREATE OR REPLACE FUNCTION create_message_partitions(months_ahead INTEGER DEFAULT 3)
+RETURNS SETOF TEXT AS $$
+DECLARE
+    current_month DATE := date_trunc('month', CURRENT_TIMESTAMP AT TIME ZONE 'UTC')::DATE;
+    partition_name TEXT;
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('messages_partitions'));
+
+    FOR i IN 0..months_ahead LOOP
+        partition_name := create_message_partition((current_month + make_interval(months => i))::DATE);
+        IF partition_name IS NOT NULL THEN
+            RETURN NEXT partition_name;
+        END IF;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Detaches and drops the partitions of the months ending more than
+-- keep_months months before the current one, returns their names. A
+-- partition still holding pending or processing messages is kept
+CREATE OR REPLACE FUNCTION drop_message_partitions(keep_months INTEGER)
+RETURNS SETOF TEXT AS $$
+DECLARE
+    cutoff DATE := (date_trunc('month', CURRENT_TIMESTAMP AT TIME ZONE 'UTC') - make_interval(months => keep_months))::DATE;
+    partition_name TEXT;
+    unsent BOOLEAN;
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('messages_partitions'));
+
+    FOR partition_name IN
+        SELECT c.relname
+        FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        WHERE i.inhparent = 'messages'::regclass
+          AND c.relname ~ '^messages_[0-9]{4}_[0-9]{2}$'
+          AND to_date(substr(c.relname, 10), 'YYYY_MM') < cutoff
+        ORDER BY c.relname
+    LOOP
+        EXECUTE format('SELECT EXISTS (SELECT 1 FROM %I WHERE status IN (''pending'', ''processing''))', partition_name)
+            INTO unsent;
+        IF unsent THEN
+            CONTINUE;
+        END IF;
+
+        EXECUTE format('ALTER TABLE messages DETACH PARTITION %I', partition_name);
+        EXECUTE format('DROP TABLE %I', partition_name);
+        RETURN NEXT partition_name;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Partitions for every month holding messages, then the upcoming ones
+DO $$
+DECLARE
+    first_month DATE;
+    partition_month DATE;
+BEGIN
+    SELECT date_trunc('month', MIN(created_at) AT TIME ZONE 'UTC')::DATE INTO first_month FROM messages_unpartitioned;
+    IF first_month IS NULL THEN
+        RETURN;
+    END IF;
+
+    FOR partition_month IN
+        SELECT generate_series(first_month::TIMESTAMP, date_trunc('month', CURRENT_TIMESTAMP AT TIME ZONE 'UTC'), INTERVAL '1 month')::DATE
+    LOOP
+        PERFORM create_message_partition(partition_month);
+    END LOOP;
+END;
+$$;
+
+SELECT create_message_partitions(3);
+
+INSERT INTO messages (id, phone_number, content, status, created_at, sent_at, message_id, error_message, updated_at, ordering_key, tenant_id, channel, country_code, segments, encoding, category, timezone, scheduled_at, campaign_id, callback_url)
+SELECT id, phone_number, content, status, created_at, sent_at, message_id, error_message, updated_at, ordering_key, tenant_id, channel, country_code, segments, encoding, category, timezone, scheduled_at, campaign_id, callback_url
+FROM messages_unpartitioned;
+
+ALTER SEQUENCE messages_id_seq OWNED BY messages.id;
+
+CREATE INDEX IF NOT EXISTS idx_messages_status_created ON messages (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_messages_phone_number ON messages (phone_number);
+-- Keeps pending scans independent of the history accumulated in a partition
+CREATE INDEX IF NOT EXISTS idx_messages_pending ON messages (created_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_messages_processing_stuck ON messages (status, updated_at) WHERE status = 'processing';
+-- Sequence key lookups for ordered delivery (ordering key, phone number otherwise)
+CREATE INDEX IF NOT EXISTS idx_messages_sequence_key ON messages ((COALESCE(ordering_key, phone_number)), created_at)
+    WHERE status IN ('pending', 'processing');
+-- Delivery reports look messages up by the id the provider returned
+CREATE INDEX IF NOT EXISTS idx_messages_message_id ON messages (message_id) WHERE message_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_messages_campaign_status ON messages (campaign_id, status) WHERE campaign_id IS NOT NULL;
+-- Statistics of sent messages are computed over a sent_at range
+CREATE INDEX IF NOT EXISTS idx_messages_sent_at_stats ON messages (sent_at) WHERE sent_at IS NOT NULL;
+-- Messages deferred by quiet hours
+CREATE INDEX IF NOT EXISTS idx_messages_scheduled_at ON messages (scheduled_at)
+    WHERE status = 'pending' AND scheduled_at IS NOT NULL;
+
+ALTER TABLE messages ADD CONSTRAINT fk_messages_campaign FOREIGN KEY (campaign_id) REFERENCES campaigns (id);
+
+CREATE VIEW sent_messages AS 
+SELECT 
+    id,
+    tenant_id,
+    phone_number,
+    content,
+    status,
+    created_at,
+    sent_at,
+    message_id,
+    EXTRACT(EPOCH FROM (sent_at - created_at)) as processing_time_seconds
+FROM messages 
+WHERE status IN ('sent', 'delivered')
+ORDER BY sent_at DESC;
+
+CREATE OR REPLACE FUNCTION get_unsent_messages(batch_size INTEGER DEFAULT 2)
+RETURNS SETOF messages AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE messages 
+    SET status = 'processing',
+        updated_at = CURRENT_TIMESTAMP
+    WHERE (messages.id, messages.created_at) IN (
+        SELECT m.id, m.created_at
+        FROM messages m
+        WHERE m.status = 'pending'
+          AND (m.scheduled_at IS NULL OR m.scheduled_at <= CURRENT_TIMESTAMP)
+          AND (m.campaign_id IS NULL OR EXISTS (
+              SELECT 1 FROM campaigns c WHERE c.id = m.campaign_id AND c.status = 'running'
+          ))
+        ORDER BY m.created_at ASC
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING messages.*;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Same as get_unsent_messages but keeps messages of one sequence key
+-- (ordering_key, phone_number otherwise) strictly sequential: only the
+-- oldest pending message of a key is claimed, and only when no other
+-- message of that key is still processing. A deferred message holds back
+-- the later ones of its key
+CREATE OR REPLACE FUNCTION get_unsent_messages_ordered(batch_size INTEGER DEFAULT 2)
+RETURNS SETOF messages AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE messages 
+    SET status = 'processing',
+        updated_at = CURRENT_TIMESTAMP
+    WHERE (messages.id, messages.created_at) IN (
+        SELECT m.id, m.created_at
+        FROM messages m
+        WHERE m.status = 'pending'
+          AND (m.scheduled_at IS NULL OR m.scheduled_at <= CURRENT_TIMESTAMP)
+          AND (m.campaign_id IS NULL OR EXISTS (
+              SELECT 1 FROM campaigns c WHERE c.id = m.campaign_id AND c.status = 'running'
+          ))
+          AND NOT EXISTS (
+              SELECT 1
+              FROM messages p
+              WHERE COALESCE(p.ordering_key, p.phone_number) = COALESCE(m.ordering_key, m.phone_number)
+                AND (p.status = 'processing'
+                     OR (p.status = 'pending' AND (p.created_at, p.id) < (m.created_at, m.id)))
+          )
+        ORDER BY m.created_at ASC
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING messages.*;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
+
+ANALYZE messages;
@@ -0,0 +1,76 @@
+-- Prepares the messages table for the encryption of phone numbers and
+-- contents: encrypted phone numbers don't fit varchar(20) and are looked
+-- up by phone_number_hash, which ordered delivery also groups by. Run it
+-- after 001_partition_messages.sql, the existing rows stay in plaintext
+-- until ENCRYPTION_REENCRYPT rewrites them.
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/002_encrypt_messages.sql
+
+BEGIN;
+
+-- The view depends on the type of phone_number
+DROP VIEW IF EXISTS sent_messages;
+
+ALTER TABLE messages ALTER COLUMN phone_number TYPE TEXT;
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS phone_number_hash CHAR(64) NULL;
+ALTER TABLE messages_archive ALTER COLUMN phone_number TYPE TEXT;
+ALTER TABLE messages_archive ADD COLUMN IF NOT EXISTS phone_number_hash CHAR(64) NULL;
+
+CREATE INDEX IF NOT EXISTS idx_messages_phone_number_hash ON messages (phone_number_hash) WHERE phone_number_hash IS NOT NULL;
+DROP INDEX IF EXISTS idx_messages_sequence_key;
+-- Sequence key lookups for ordered delivery (ordering key, phone number
+-- otherwise, by its hash when encrypted)
+CREATE INDEX IF NOT EXISTS idx_messages_sequence_key ON messages ((COALESCE(ordering_key, phone_number_hash, phone_number)), created_at)
+    WHERE status IN ('pending', 'processing');
+
+CREATE VIEW sent_messages AS 
+SELECT 
+    id,
+    tenant_id,
+    phone_number,
+    content,
+    status,
+    created_at,
+    sent_at,
+    message_id,
+    EXTRACT(EPOCH FROM (sent_at - created_at)) as processing_time_seconds
+FROM messages 
+WHERE status IN ('sent', 'delivered')
+ORDER BY sent_at DESC;
+
+-- Same as get_unsent_messages but keeps messages of one sequence key
+-- (ordering_key, phone_number otherwise) strictly sequential: only the
+-- oldest pending message of a key is claimed, and only when no other
+-- message of that key is still processing. A deferred message holds back
+-- the later ones of its key
+CREATE OR REPLACE FUNCTION get_unsent_messages_ordered(batch_size INTEGER DEFAULT 2)
+RETURNS SETOF messages AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE messages 
+    SET status = 'processing',
+        updated_at = CURRENT_TIMESTAMP
+    WHERE (messages.id, messages.created_at) IN (
+        SELECT m.id, m.created_at
+        FROM messages m
+        WHERE m.status = 'pending'
+          AND (m.scheduled_at IS NULL OR m.scheduled_at <= CURRENT_TIMESTAMP)
+          AND (m.campaign_id IS NULL OR EXISTS (
+              SELECT 1 FROM campaigns c WHERE c.id = m.campaign_id AND c.status = 'running'
+          ))
+          AND NOT EXISTS (
+              SELECT 1
+              FROM messages p
+              WHERE COALESCE(p.ordering_key, p.phone_number_hash, p.phone_number) = COALESCE(m.ordering_key, m.phone_number_hash, m.phone_number)
+                AND (p.status = 'processing'
+                     OR (p.status = 'pending' AND (p.created_at, p.id) < (m.created_at, m.id)))
+          )
+        ORDER BY m.created_at ASC
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING messages.*;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
@@ -0,0 +1,14 @@
+-- Adds the provider error class and the attempt count of messages on a
+-- database initialised before they existed.
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/004_message_error_class.sql
+
+BEGIN;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS error_class VARCHAR(32) NULL
+    CHECK (error_class IN ('transient', 'rate_limited', 'invalid_recipient', 'auth_failure', 'rejected'));
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS attempts SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE messages_archive ADD COLUMN IF NOT EXISTS error_class VARCHAR(32) NULL;
+ALTER TABLE messages_archive ADD COLUMN IF NOT EXISTS attempts SMALLINT NOT NULL DEFAULT 0;
+
+COMMIT;
@@ -0,0 +1,51 @@
+-- Groups the messages with an empty ordering key by their phone number in
+-- ordered delivery, the way the worker shards route them, on a database
+-- created by an older init.sql.
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/005_empty_ordering_key.sql
+
+BEGIN;
+
+DROP INDEX IF EXISTS idx_messages_sequence_key;
+-- Sequence key lookups for ordered delivery (ordering key unless empty,
+-- phone number otherwise, by its hash when encrypted)
+CREATE INDEX IF NOT EXISTS idx_messages_sequence_key ON messages ((COALESCE(NULLIF(ordering_key, ''), phone_number_hash, phone_number)), created_at)
+    WHERE status IN ('pending', 'processing');
+
+-- Same as get_unsent_messages but keeps messages of one sequence key
+-- (ordering_key unless empty, phone_number otherwise) strictly
+-- sequential: only the oldest pending message of a key is claimed, and
+-- only when no other message of that key is still processing. A deferred
+-- message holds back the later ones of its key, so does one left in
+-- processing until the application resets it after its stuck timeout
+CREATE OR REPLACE FUNCTION get_unsent_messages_ordered(batch_size INTEGER DEFAULT 2)
+RETURNS SETOF messages AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE messages 
+    SET status = 'processing',
+        updated_at = CURRENT_TIMESTAMP
+    WHERE (messages.id, messages.created_at) IN (
+        SELECT m.id, m.created_at
+        FROM messages m
+        WHERE m.status = 'pending'
+          AND (m.scheduled_at IS NULL OR m.scheduled_at <= CURRENT_TIMESTAMP)
+          AND (m.campaign_id IS NULL OR EXISTS (
+              SELECT 1 FROM campaigns c WHERE c.id = m.campaign_id AND c.status = 'running'
+          ))
+          AND NOT EXISTS (
+              SELECT 1
+              FROM messages p
+              WHERE COALESCE(NULLIF(p.ordering_key, ''), p.phone_number_hash, p.phone_number) = COALESCE(NULLIF(m.ordering_key, ''), m.phone_number_hash, m.phone_number)
+                AND (p.status = 'processing'
+                     OR (p.status = 'pending' AND (p.created_at, p.id) < (m.created_at, m.id)))
+          )
+        ORDER BY m.created_at ASC
+        LIMIT batch_size
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING messages.*;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMIT;
@@ -0,0 +1,26 @@
+-- Prepares the other tables holding phone numbers and message contents for
+-- encryption: suppressions and campaign recipients get their phone number
+-- encrypted and looked up by phone_number_hash, which their uniqueness now
+-- relies on, and webhook delivery payloads are encrypted as a whole. Run
+-- it after 002_encrypt_messages.sql, the existing rows stay in plaintext
+-- until ENCRYPTION_REENCRYPT rewrites them.
+--
+--   psql -v ON_ERROR_STOP=1 -f build/migrations/006_encrypt_personal_data.sql
+
+BEGIN;
+
+ALTER TABLE suppressions ALTER COLUMN phone_number TYPE TEXT;
+ALTER TABLE suppressions ADD COLUMN IF NOT EXISTS phone_number_hash CHAR(64) NULL;
+ALTER TABLE suppressions DROP CONSTRAINT IF EXISTS suppressions_phone_number_channel_tenant_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_suppressions_recipient
+    ON suppressions ((COALESCE(phone_number_hash, phone_number)), channel, tenant_id);
+
+ALTER TABLE campaign_recipients ALTER COLUMN phone_number TYPE TEXT;
+ALTER TABLE campaign_recipients ADD COLUMN IF NOT EXISTS phone_number_hash CHAR(64) NULL;
+ALTER TABLE campaign_recipients DROP CONSTRAINT IF EXISTS campaign_recipients_campaign_id_phone_number_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_campaign_recipients_recipient
+    ON campaign_recipients (campaign_id, (COALESCE(phone_number_hash, phone_number)));
+
+ALTER TABLE webhook_deliveries ALTER COLUMN payload TYPE TEXT USING payload::TEXT;
+
+COMMIT;
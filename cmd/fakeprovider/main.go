@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/craftaholic/insider/internal/fakeprovider"
+	"github.com/craftaholic/insider/internal/shared/constant"
+	"github.com/craftaholic/insider/internal/shared/log"
+)
+
+// Simulated notification provider, see the "Fake provider" section of the
+// README. Every option is a flag:
+//
+//	go run ./cmd/fakeprovider -addr :9090 -latency 50ms -error-rate 0.1
+func main() {
+	log.Init()
+	logger := log.BaseLogger
+
+	var config fakeprovider.Config
+	addr := flag.String("addr", ":9090", "Address to listen on")
+	flag.DurationVar(&config.Latency, "latency", 0, "Latency added to every answer")
+	flag.DurationVar(&config.Jitter, "jitter", 0, "Most random latency added on top of -latency")
+	flag.Float64Var(&config.ServerErrorRate, "error-rate", 0, "Share of requests answered 500")
+	flag.Float64Var(&config.RateLimitRate, "rate-limit-rate", 0, "Share of requests answered 429")
+	flag.IntVar(&config.RetryAfter, "retry-after", 1, "Retry-After seconds of the 429 answers")
+	flag.Float64Var(&config.InvalidRecipientRate, "invalid-rate", 0, "Share of requests refused as invalid recipients")
+	flag.Float64Var(&config.MalformedRate, "malformed-rate", 0, "Share of requests answered with a body that isn't JSON")
+	flag.StringVar(&config.AuthKey, "auth-key", "", "Bearer token requests must carry, the WEBHOOK_AUTH_KEY")
+	flag.Uint64Var(&config.Seed, "seed", 1, "Seed of the outcomes, latencies and message ids")
+	flag.StringVar(&config.DLRURL, "dlr-url", "", "Delivery report endpoint, e.g. http://localhost:8080/inbound/dlr")
+	flag.StringVar(&config.DLRKey, "dlr-key", "", "Bearer token of the delivery reports, the INBOUND_API_KEY")
+	flag.DurationVar(&config.DLRDelay, "dlr-delay", time.Second, "Delay between accepting a message and its delivery report")
+	flag.Float64Var(&config.DLRFailureRate, "dlr-failure-rate", 0, "Share of accepted messages reported undelivered")
+	flag.Parse()
+
+	provider := fakeprovider.New(config)
+	srv := &http.Server{
+		Addr:         *addr,
+		Handler:      logRequests(provider),
+		ReadTimeout:  constant.DefaultTimeout * time.Second,
+		WriteTimeout: constant.IdleTimeout * time.Second,
+		IdleTimeout:  constant.DefaultTimeout * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), constant.DefaultTimeout*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("Starting fake provider...", "on address", *addr, "seed", config.Seed)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("Fake provider error", "error", err)
+	}
+
+	provider.Close()
+	logger.Info("Stopping fake provider...", "received", len(provider.Requests()))
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		log.BaseLogger.Info("Request handled", "method", r.Method, "path", r.URL.Path, "duration", time.Since(start))
+	})
+}
@@ -3,6 +3,9 @@ package main
 import (
 	"net/http"
 	"time"
+	// Quiet hours are evaluated in the recipient's timezone, the runtime
+	// image doesn't ship a zoneinfo database
+	_ "time/tzdata"
 
 	"github.com/craftaholic/insider/internal/api/route"
 	"github.com/craftaholic/insider/internal/bootstrap"
@@ -0,0 +1,141 @@
+package custommiddleware
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/craftaholic/insider/internal/utils"
+)
+
+// APIKeyAuth only lets requests carrying "Authorization: Bearer <apiKey>"
+// through. An empty apiKey rejects every request so the protected routes
+// are closed until a key is configured.
+func APIKeyAuth(apiKey string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+			if apiKey == "" || subtle.ConstantTimeCompare([]byte(token), []byte(apiKey)) != 1 {
+				log.FromCtx(r.Context()).Warn("Rejected unauthorized request")
+				writeJSONError(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := entity.ContextWithActor(r.Context(), entity.Actor{
+				Name:     entity.ActorInbound,
+				SourceIP: sourceIP(r),
+			})
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Authenticate only lets requests carrying "Authorization: Bearer <key>"
+// of one of keys through, and puts the matching key in the request
+// context. Without keys every request is rejected.
+func Authenticate(keys []entity.APIKey) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+			// Every key is compared so the time taken doesn't tell which matched
+			var (
+				matched entity.APIKey
+				found   bool
+			)
+			for _, key := range keys {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(key.Key)) == 1 {
+					matched, found = key, true
+				}
+			}
+
+			if !found {
+				log.FromCtx(r.Context()).Warn("Rejected unauthorized request")
+				writeJSONError(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := log.FromCtx(r.Context()).WithFields("caller", matched.Name).WithCtx(r.Context())
+			ctx = entity.ContextWithAPIKey(ctx, matched)
+			ctx = entity.ContextWithActor(ctx, entity.Actor{Name: matched.Name, SourceIP: sourceIP(r)})
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// AuthenticateOptional lets requests without an Authorization header
+// through anonymously, the others go through Authenticate so a wrong key
+// is still rejected. Anonymous requests have no key in their context.
+func AuthenticateOptional(keys []entity.APIKey) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		authenticated := Authenticate(keys)(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireRole only lets requests of keys of role, or admin keys, through.
+// Read-only keys are also let through for reads. It runs after Authenticate.
+func RequireRole(role entity.APIRole) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, _ := entity.APIKeyFromContext(r.Context())
+
+			read := r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions
+			allowed := key.Role == entity.RoleAdmin || key.Role == role ||
+				(read && key.Role == entity.RoleReadOnly)
+			if !allowed {
+				log.FromCtx(r.Context()).Warn("Rejected forbidden request", "role", key.Role)
+				writeJSONError(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAdmin only lets requests of admin keys through, reads included,
+// for the endpoints exposing every message or the actions of every key.
+// It runs after Authenticate.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, _ := entity.APIKeyFromContext(r.Context())
+		if key.Role != entity.RoleAdmin {
+			log.FromCtx(r.Context()).Warn("Rejected forbidden request", "role", key.Role)
+			writeJSONError(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sourceIP returns the address the request came from, RealIP has already
+// replaced RemoteAddr with the forwarded address when there is one.
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func writeJSONError(w http.ResponseWriter, message string, statusCode int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write([]byte(utils.JSONError(message)))
+}
@@ -0,0 +1,10 @@
+package route
+
+import (
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/go-chi/chi/v5"
+)
+
+func NewAuditRouter(router chi.Router, ac interfaces.AuditController) {
+	router.Get("/audit", ac.List)
+}
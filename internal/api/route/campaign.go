@@ -0,0 +1,17 @@
+package route
+
+import (
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/go-chi/chi/v5"
+)
+
+func NewCampaignRouter(router chi.Router, cc interfaces.CampaignController) {
+	router.Get("/campaigns", cc.List)
+	router.Post("/campaigns", cc.Create)
+	router.Get("/campaigns/{id}", cc.Get)
+	router.Post("/campaigns/{id}/recipients", cc.AddRecipients)
+	router.Post("/campaigns/{id}/segment", cc.AddSegment)
+	router.Post("/campaigns/{id}/start", cc.Start)
+	router.Post("/campaigns/{id}/pause", cc.Pause)
+	router.Post("/campaigns/{id}/cancel", cc.Cancel)
+}
@@ -0,0 +1,10 @@
+package route
+
+import (
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/go-chi/chi/v5"
+)
+
+func NewEventStreamRouter(router chi.Router, ec interfaces.EventStreamController) {
+	router.Get("/events/stream", ec.Stream)
+}
@@ -0,0 +1,11 @@
+package route
+
+import (
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/go-chi/chi/v5"
+)
+
+func NewInboundRouter(router chi.Router, ic interfaces.InboundController) {
+	router.Post("/inbound", ic.Receive)
+	router.Post("/inbound/dlr", ic.DeliveryReport)
+}
@@ -6,8 +6,24 @@ import (
 )
 
 func NewMessageRouter(router chi.Router, mc interfaces.MessageController) {
-	router.Post("/service/start", mc.Start)
-	router.Post("/service/stop", mc.Stop)
 	router.Get("/service/status", mc.Status)
+}
+
+// NewSentMessageRouter serves the sent messages to anyone, masked unless
+// the request carries a key that doesn't mask them.
+func NewSentMessageRouter(router chi.Router, mc interfaces.MessageController) {
 	router.Get("/message/sent", mc.GetSentMessagesWithPagination)
 }
+
+func NewMessageAdminRouter(router chi.Router, mc interfaces.MessageController) {
+	router.Post("/message", mc.Create)
+	router.Post("/message/{id}/requeue", mc.Requeue)
+	router.Post("/service/start", mc.Start)
+	router.Post("/service/stop", mc.Stop)
+	router.Get("/service/config", mc.GetConfig)
+	router.Patch("/service/config", mc.UpdateConfig)
+}
+
+func NewMessageExportRouter(router chi.Router, mc interfaces.MessageController) {
+	router.Get("/messages/export", mc.Export)
+}
@@ -0,0 +1,12 @@
+package route
+
+import (
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/go-chi/chi/v5"
+)
+
+func NewQuietHoursRouter(router chi.Router, qc interfaces.QuietHoursController) {
+	router.Get("/quiet-hours", qc.List)
+	router.Put("/quiet-hours", qc.Set)
+	router.Delete("/quiet-hours/{id}", qc.Delete)
+}
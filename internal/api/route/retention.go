@@ -0,0 +1,10 @@
+package route
+
+import (
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/go-chi/chi/v5"
+)
+
+func NewRetentionRouter(router chi.Router, rc interfaces.RetentionController) {
+	router.Get("/retention", rc.Get)
+}
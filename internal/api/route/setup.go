@@ -5,6 +5,8 @@ import (
 
 	custommiddleware "github.com/craftaholic/insider/internal/api/middleware"
 	"github.com/craftaholic/insider/internal/bootstrap"
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/shared/config"
 	"github.com/craftaholic/insider/internal/shared/constant"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -18,7 +20,7 @@ func SetupRoute(app bootstrap.Application) *chi.Mux {
 	// CORS middleware
 	cors := cors.New(cors.Options{
 		AllowedOrigins: []string{"https://*", "http://*"}, // Use your allowed origins
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders: []string{
 			"Accept",
 			"Authorization",
@@ -50,5 +52,38 @@ func SetupRoute(app bootstrap.Application) *chi.Mux {
 		NewMessageRouter(r, app.MessageController)
 	})
 
+	// Public APIs answering callers with a key in more detail
+	r.Group(func(r chi.Router) {
+		r.Use(custommiddleware.AuthenticateOptional(app.APIKeys))
+		NewSentMessageRouter(r, app.MessageController)
+	})
+
+	// Admin APIs
+	r.Group(func(r chi.Router) {
+		r.Use(custommiddleware.Authenticate(app.APIKeys))
+		r.Use(custommiddleware.RequireRole(entity.RoleAdmin))
+		NewMessageAdminRouter(r, app.MessageController)
+		NewSuppressionRouter(r, app.SuppressionController)
+		NewQuietHoursRouter(r, app.QuietHoursController)
+		NewCampaignRouter(r, app.CampaignController)
+		NewWebhookRouter(r, app.WebhookController)
+		NewStatsRouter(r, app.StatsController)
+		NewRetentionRouter(r, app.RetentionController)
+
+		// Bulk reads of the messages and the audit log, not for read-only keys
+		r.Group(func(r chi.Router) {
+			r.Use(custommiddleware.RequireAdmin)
+			NewMessageExportRouter(r, app.MessageController)
+			NewEventStreamRouter(r, app.EventStreamController)
+			NewAuditRouter(r, app.AuditController)
+		})
+	})
+
+	// Provider callbacks
+	r.Group(func(r chi.Router) {
+		r.Use(custommiddleware.APIKeyAuth(config.Env.InboundAPIKey))
+		NewInboundRouter(r, app.InboundController)
+	})
+
 	return r
 }
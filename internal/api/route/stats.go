@@ -0,0 +1,10 @@
+package route
+
+import (
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/go-chi/chi/v5"
+)
+
+func NewStatsRouter(router chi.Router, sc interfaces.StatsController) {
+	router.Get("/stats", sc.Get)
+}
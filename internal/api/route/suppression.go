@@ -0,0 +1,12 @@
+package route
+
+import (
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/go-chi/chi/v5"
+)
+
+func NewSuppressionRouter(router chi.Router, sc interfaces.SuppressionController) {
+	router.Get("/suppressions", sc.List)
+	router.Post("/suppressions", sc.Create)
+	router.Delete("/suppressions/{id}", sc.Delete)
+}
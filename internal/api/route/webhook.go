@@ -0,0 +1,14 @@
+package route
+
+import (
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/go-chi/chi/v5"
+)
+
+func NewWebhookRouter(router chi.Router, wc interfaces.WebhookController) {
+	router.Get("/webhooks", wc.List)
+	router.Post("/webhooks", wc.Create)
+	router.Get("/webhooks/{id}", wc.Get)
+	router.Delete("/webhooks/{id}", wc.Delete)
+	router.Get("/webhooks/{id}/deliveries", wc.ListDeliveries)
+}
@@ -4,12 +4,17 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net"
+	"net/http"
+	"os"
 	"time"
 
 	"github.com/craftaholic/insider/internal/controller"
+	"github.com/craftaholic/insider/internal/domain/entity"
 	"github.com/craftaholic/insider/internal/domain/interfaces"
 	"github.com/craftaholic/insider/internal/repository"
 	"github.com/craftaholic/insider/internal/usecase"
+	"github.com/craftaholic/insider/internal/utils"
 	"github.com/go-redis/redis"
 	"github.com/go-resty/resty/v2"
 	"gorm.io/driver/postgres"
@@ -18,26 +23,64 @@ import (
 
 	"github.com/craftaholic/insider/internal/shared/config"
 	"github.com/craftaholic/insider/internal/shared/constant"
+	"github.com/craftaholic/insider/internal/shared/crypto"
 	"github.com/craftaholic/insider/internal/shared/log"
 )
 
 type Application struct {
 	// Infra Layer
 	db          *gorm.DB
+	keyring     *crypto.Keyring
 	redisClient *redis.Client
 	restyClient *resty.Client
+	eventClient *resty.Client
 
 	// Repo Layer
-	messageRepository   interfaces.MessageRepository
-	notificationService interfaces.NotificationService
-	cacheRepository     interfaces.CacheRepository
+	messageRepository     interfaces.MessageRepository
+	messageQueue          interfaces.MessageQueue
+	notificationService   interfaces.NotificationService
+	circuitBreaker        interfaces.CircuitBreaker
+	cacheRepository       interfaces.CacheRepository
+	suppressionRepository interfaces.SuppressionRepository
+	quietHoursRepository  interfaces.QuietHoursRepository
+	campaignRepository    interfaces.CampaignRepository
+	webhookRepository     interfaces.WebhookRepository
+	webhookSender         interfaces.WebhookSender
+	statsRepository       interfaces.StatsRepository
+	eventStream           interfaces.EventStream
+	retentionRepository   interfaces.RetentionRepository
+	partitionRepository   interfaces.PartitionRepository
+	encryptionRepository  interfaces.EncryptionRepository
+	auditRepository       interfaces.AuditRepository
 
 	// Usecase Layer
-	messageUsecase interfaces.MessageUsecase
+	messageUsecase     interfaces.MessageUsecase
+	suppressionUsecase interfaces.SuppressionUsecase
+	quietHoursUsecase  interfaces.QuietHoursUsecase
+	campaignUsecase    interfaces.CampaignUsecase
+	webhookUsecase     interfaces.WebhookUsecase
+	statsUsecase       interfaces.StatsUsecase
+	eventStreamUsecase interfaces.EventStreamUsecase
+	retentionUsecase   interfaces.RetentionUsecase
+	partitionUsecase   interfaces.PartitionUsecase
+	encryptionUsecase  interfaces.EncryptionUsecase
+	auditUsecase       interfaces.AuditUsecase
 
 	// Controller/Handler Layer
-	HealthController  interfaces.HealthController
-	MessageController interfaces.MessageController
+	HealthController      interfaces.HealthController
+	MessageController     interfaces.MessageController
+	SuppressionController interfaces.SuppressionController
+	InboundController     interfaces.InboundController
+	QuietHoursController  interfaces.QuietHoursController
+	CampaignController    interfaces.CampaignController
+	WebhookController     interfaces.WebhookController
+	StatsController       interfaces.StatsController
+	EventStreamController interfaces.EventStreamController
+	RetentionController   interfaces.RetentionController
+	AuditController       interfaces.AuditController
+
+	// APIKeys are the keys accepted on the admin endpoints
+	APIKeys []entity.APIKey
 }
 
 func App() Application {
@@ -66,6 +109,21 @@ func App() Application {
 	}
 	app.db = db
 
+	// Init the keyring phone numbers and contents are encrypted with, the
+	// serializer has to know it before the first query
+	app.keyring, err = crypto.LoadKeyring(
+		config.Env.EncryptionKeys,
+		config.Env.EncryptionKeysFile,
+		config.Env.EncryptionHashKey,
+	)
+	if err != nil {
+		logger.Fatal("Invalid encryption keyring", "error", err)
+	}
+	if !app.keyring.Enabled() {
+		logger.Warn("Encryption keyring is empty, messages are stored in plaintext")
+	}
+	repository.RegisterEncryption(app.keyring)
+
 	// Init Redis client
 	app.redisClient = redis.NewClient(&redis.Options{
 		Addr: fmt.Sprintf(
@@ -99,29 +157,224 @@ func App() Application {
 			return backoff, nil
 		})
 
+	// Init the client posting events to webhook subscriptions, the
+	// delivery queue retries with its own backoff. It only connects to
+	// public addresses, straight rather than through a proxy so the address
+	// checked is the endpoint's.
+	eventTransport := http.DefaultTransport.(*http.Transport).Clone()
+	eventTransport.Proxy = nil
+	eventTransport.DialContext = (&net.Dialer{
+		Timeout:   constant.EventWebhookDialTimeout,
+		KeepAlive: constant.EventWebhookDialTimeout,
+		Control:   utils.PublicDialControl,
+	}).DialContext
+	app.eventClient = resty.New().
+		SetTransport(eventTransport).
+		SetTimeout(time.Duration(config.Env.EventWebhookTimeout) * time.Second)
+
 	// Init Repository Layer
-	app.messageRepository = repository.NewMessageRepository(app.db)
+	app.messageRepository = repository.NewMessageRepository(app.db, app.keyring)
 	app.cacheRepository = repository.NewCacheRepository(app.redisClient)
-	app.notificationService = repository.NewNotificationService(
-		app.restyClient,
-		config.Env.WebhookAuthKey,
-		config.Env.WebhookURL,
+	app.suppressionRepository = repository.NewSuppressionRepository(app.db, app.keyring)
+	app.quietHoursRepository = repository.NewQuietHoursRepository(app.db)
+	app.campaignRepository = repository.NewCampaignRepository(app.db, app.keyring)
+	app.webhookRepository = repository.NewWebhookRepository(app.db)
+	app.webhookSender = repository.NewWebhookSender(app.eventClient)
+	app.statsRepository = repository.NewStatsRepository(app.db)
+	app.eventStream = repository.NewEventStream(app.redisClient, constant.EventStreamChannel)
+	app.retentionRepository = repository.NewRetentionRepository(app.db, app.keyring)
+	app.partitionRepository = repository.NewPartitionRepository(app.db)
+	app.encryptionRepository = repository.NewEncryptionRepository(app.db, app.keyring)
+	app.auditRepository = repository.NewAuditRepository(app.db)
+
+	// Init the settings of the automated sending, the queue depends on them
+	serviceConfig := entity.ServiceConfig{
+		WorkerCount:           config.Env.WorkerCount,
+		JobBuffer:             config.Env.WorkerChanBuffer,
+		ProducerCronDuration:  config.Env.MessageCronDuration,
+		ProducerBatchNumber:   config.Env.MessageBatchNumber,
+		JobTimeout:            config.Env.MessageSendTimeout,
+		StuckTimeout:          config.Env.MessageStuckTimeout,
+		OrderedDelivery:       config.Env.OrderedDelivery,
+		WorkerMinCount:        config.Env.WorkerMinCount,
+		WorkerMaxCount:        config.Env.WorkerMaxCount,
+		AutoscaleInterval:     config.Env.AutoscaleInterval,
+		AutoscaleUpCooldown:   config.Env.AutoscaleUpCooldown,
+		AutoscaleDownCooldown: config.Env.AutoscaleDownCooldown,
+		MaxAttempts:           config.Env.MessageMaxAttempts,
+		RetryBaseDelay:        config.Env.MessageRetryBaseDelay,
+		RetryMaxDelay:         config.Env.MessageRetryMaxDelay,
+	}
+	if err = serviceConfig.Validate(); err != nil {
+		logger.Fatal("Invalid service config", "error", err)
+	}
+
+	// Init the queue the fetchers claim messages from, a consumer is named
+	// after its host unless configured
+	queueConfig := entity.QueueConfig{
+		Backend:       entity.QueueBackend(config.Env.QueueBackend),
+		Stream:        config.Env.QueueStream,
+		Group:         config.Env.QueueGroup,
+		Consumer:      config.Env.QueueConsumer,
+		ReclaimIdle:   config.Env.QueueReclaimIdle,
+		SweepInterval: config.Env.QueueSweepInterval,
+		SweepBatch:    config.Env.QueueSweepBatch,
+	}
+	if queueConfig.Consumer == "" {
+		queueConfig.Consumer, _ = os.Hostname()
+	}
+	if err = queueConfig.Validate(serviceConfig); err != nil {
+		logger.Fatal("Invalid message queue config", "error", err)
+	}
+	if queueConfig.Backend == entity.QueueRedis {
+		app.messageQueue = repository.NewRedisMessageQueue(app.redisClient, app.messageRepository, queueConfig)
+	} else {
+		app.messageQueue = repository.NewPostgresMessageQueue(app.messageRepository, config.Env.OrderedDelivery)
+	}
+	logger.Info("Message queue initialized", "backend", queueConfig.Backend)
+
+	circuitBreakerConfig := entity.CircuitBreakerConfig{
+		Enabled:        config.Env.CircuitBreakerEnabled,
+		WindowSize:     config.Env.CircuitBreakerWindow,
+		MinRequests:    config.Env.CircuitBreakerMinRequests,
+		FailureRate:    config.Env.CircuitBreakerFailureRate,
+		OpenDuration:   config.Env.CircuitBreakerOpenDuration,
+		HalfOpenProbes: config.Env.CircuitBreakerHalfOpenProbes,
+	}
+	if err = circuitBreakerConfig.Validate(); err != nil {
+		logger.Fatal("Invalid circuit breaker config", "error", err)
+	}
+	notificationCircuitBreaker := repository.NewNotificationCircuitBreaker(
+		repository.NewNotificationService(
+			app.restyClient,
+			config.Env.WebhookAuthKey,
+			config.Env.WebhookURL,
+		),
+		circuitBreakerConfig,
 	)
+	app.notificationService = notificationCircuitBreaker
+	app.circuitBreaker = notificationCircuitBreaker
 
 	// Init Usecase Layer
+	ingestConfig := entity.IngestConfig{
+		DefaultRegion: config.Env.PhoneDefaultRegion,
+		MaxSegments:   config.Env.SMSMaxSegments,
+		Transliterate: config.Env.SMSTransliterate,
+
+		CallbacksEnabled: config.Env.CallbackSigningSecret != "",
+	}
+
+	app.auditUsecase = usecase.NewAuditUsecase(app.auditRepository)
+
+	app.webhookUsecase = usecase.NewWebhookUsecase(
+		app.webhookRepository,
+		app.webhookSender,
+		app.auditUsecase,
+		entity.WebhookConfig{
+			DispatchInterval: config.Env.EventWebhookDispatchInterval,
+			DispatchBatch:    config.Env.EventWebhookDispatchBatch,
+			MaxAttempts:      config.Env.EventWebhookMaxAttempts,
+			RetryBaseDelay:   config.Env.EventWebhookRetryBaseDelay,
+			RetryMaxDelay:    config.Env.EventWebhookRetryMaxDelay,
+			Lease:            config.Env.EventWebhookTimeout * 2,
+			CallbackSecret:   config.Env.CallbackSigningSecret,
+		},
+	)
+
+	app.eventStreamUsecase = usecase.NewEventStreamUsecase(app.eventStream, constant.EventStreamSubscriberBuffer)
+
 	app.messageUsecase = usecase.NewMessageUsecase(
 		app.messageRepository,
+		app.messageQueue,
 		app.cacheRepository,
 		app.notificationService,
-		config.Env.WorkerChanBuffer,
-		config.Env.WorkerCount,
-		config.Env.MessageCronDuration,
-		config.Env.MessageBatchNumber,
+		app.circuitBreaker,
+		app.suppressionRepository,
+		app.quietHoursRepository,
+		usecase.NewEventPublishers(app.webhookUsecase, app.eventStreamUsecase),
+		app.auditUsecase,
+		serviceConfig,
+		ingestConfig,
 	)
 
+	app.suppressionUsecase = usecase.NewSuppressionUsecase(app.suppressionRepository, app.auditUsecase, ingestConfig)
+	app.quietHoursUsecase = usecase.NewQuietHoursUsecase(app.quietHoursRepository, app.auditUsecase)
+	app.campaignUsecase = usecase.NewCampaignUsecase(
+		app.campaignRepository,
+		app.auditUsecase,
+		entity.CampaignConfig{
+			ExpandInterval: config.Env.CampaignExpandInterval,
+			ExpandChunk:    config.Env.CampaignExpandChunk,
+		},
+		ingestConfig,
+	)
+
+	app.statsUsecase = usecase.NewStatsUsecase(app.statsRepository)
+
+	retentionRules, err := entity.ParseRetentionPolicy(config.Env.RetentionPolicy)
+	if err != nil {
+		logger.Fatal("Invalid retention policy", "error", err)
+	}
+	retentionMode := entity.RetentionMode(config.Env.RetentionMode)
+	if !retentionMode.Valid() {
+		logger.Fatal("Invalid retention mode", "mode", retentionMode)
+	}
+	app.retentionUsecase = usecase.NewRetentionUsecase(
+		app.retentionRepository,
+		app.cacheRepository,
+		entity.RetentionConfig{
+			Rules:      retentionRules,
+			Mode:       retentionMode,
+			Interval:   config.Env.RetentionInterval,
+			Batch:      config.Env.RetentionBatch,
+			ArchiveDir: config.Env.RetentionArchiveDir,
+		},
+	)
+
+	app.partitionUsecase = usecase.NewPartitionUsecase(
+		app.partitionRepository,
+		entity.PartitionConfig{
+			MonthsAhead:     config.Env.PartitionMonthsAhead,
+			RetentionMonths: config.Env.PartitionRetentionMonths,
+			Interval:        config.Env.PartitionMaintenanceInterval,
+		},
+	)
+
+	app.encryptionUsecase = usecase.NewEncryptionUsecase(app.encryptionRepository)
+
+	// Init the admin API keys, ADMIN_API_KEY is an admin key of its own
+	app.APIKeys, err = entity.ParseAPIKeys(config.Env.APIKeys)
+	if err != nil {
+		logger.Fatal("Invalid api keys", "error", err)
+	}
+	if config.Env.AdminAPIKey != "" {
+		app.APIKeys = append(app.APIKeys, entity.APIKey{
+			Name: "admin",
+			Role: entity.RoleAdmin,
+			Key:  config.Env.AdminAPIKey,
+		})
+	}
+	// Keys are only configured through the environment, a change shows at boot
+	app.auditUsecase.RecordAPIKeys(context.Background(), app.APIKeys)
+
 	// Init Controller
 	app.HealthController = controller.NewHealthController()
 	app.MessageController = controller.NewMessageController(app.messageUsecase)
+	app.SuppressionController = controller.NewSuppressionController(app.suppressionUsecase)
+	app.InboundController = controller.NewInboundController(app.suppressionUsecase, app.messageUsecase)
+	app.QuietHoursController = controller.NewQuietHoursController(app.quietHoursUsecase)
+	app.CampaignController = controller.NewCampaignController(app.campaignUsecase)
+	app.WebhookController = controller.NewWebhookController(app.webhookUsecase)
+	app.StatsController = controller.NewStatsController(app.statsUsecase)
+	app.EventStreamController = controller.NewEventStreamController(app.eventStreamUsecase)
+	app.RetentionController = controller.NewRetentionController(app.retentionUsecase)
+	app.AuditController = controller.NewAuditController(app.auditUsecase)
+
+	// Receive the events of every replica for the live stream, the service
+	// works without it
+	if err = app.eventStreamUsecase.Start(context.Background()); err != nil {
+		logger.Error("Live event stream is unavailable", "error", err)
+	}
 
 	// Execute the start automated sending in background context
 	err = app.messageUsecase.StartAutomatedSending(context.Background())
@@ -130,6 +383,23 @@ func App() Application {
 	}
 	logger.Info("Automated sending notification is running")
 
+	// Expand running campaigns into messages in background context
+	app.campaignUsecase.StartExpander(context.Background())
+
+	// Deliver message events to webhook subscriptions in background context
+	app.webhookUsecase.StartDispatcher(context.Background())
+
+	// Prune messages past their retention in background context
+	app.retentionUsecase.StartJob(context.Background())
+
+	// Keep partitions of the messages table ahead of time in background context
+	app.partitionUsecase.StartMaintenance(context.Background())
+
+	// Bring plaintext and old key messages under the primary key in background context
+	if config.Env.EncryptionReencrypt && app.keyring.Enabled() {
+		app.encryptionUsecase.StartReencryption(context.Background())
+	}
+
 	return *app
 }
 
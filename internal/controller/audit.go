@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/craftaholic/insider/internal/domain/dto"
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/craftaholic/insider/internal/utils"
+)
+
+type AuditController struct {
+	AuditUsecase interfaces.AuditUsecase
+}
+
+func NewAuditController(auditUsecase interfaces.AuditUsecase) *AuditController {
+	return &AuditController{
+		AuditUsecase: auditUsecase,
+	}
+}
+
+// List retrieves the audit log with pagination
+// swagger:route GET /audit audit listAuditRecords
+//
+// # List Audit Records
+//
+// Retrieves a paginated list of the administrative actions, newest first.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: auditRecordsResponse
+//	400: errorResponse
+//	401: errorResponse
+//	403: errorResponse
+//	500: errorResponse
+func (ac *AuditController) List(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(ac))
+	logger.Info("Listing audit records")
+	ctx := logger.WithCtx(r.Context())
+
+	page, err := parsePage(r)
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := entity.AuditFilter{
+		Actor:  r.URL.Query().Get("actor"),
+		Action: entity.AuditAction(r.URL.Query().Get("action")),
+	}
+	if filter.From, err = parseTimeParam(r, "from"); err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.To, err = parseTimeParam(r, "to"); err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	records, err := ac.AuditUsecase.ListAuditRecords(ctx, filter, page)
+	if errors.Is(err, entity.ErrValidation) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertAuditRecordsToDTO(records), http.StatusOK)
+	logger.Info("Finished listing audit records request")
+}
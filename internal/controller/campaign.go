@@ -0,0 +1,407 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/craftaholic/insider/internal/domain/dto"
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/craftaholic/insider/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type CampaignController struct {
+	CampaignUsecase interfaces.CampaignUsecase
+}
+
+func NewCampaignController(campaignUsecase interfaces.CampaignUsecase) *CampaignController {
+	return &CampaignController{
+		CampaignUsecase: campaignUsecase,
+	}
+}
+
+// List retrieves the campaigns with pagination
+// swagger:route GET /campaigns campaign listCampaigns
+//
+// # List Campaigns
+//
+// Retrieves a paginated list of campaigns, newest first.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: campaignsResponse
+//	400: errorResponse
+//	401: errorResponse
+//	500: errorResponse
+func (cc *CampaignController) List(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(cc))
+	logger.Info("Listing campaigns")
+	ctx := logger.WithCtx(r.Context())
+
+	page, err := parsePage(r)
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	campaigns, err := cc.CampaignUsecase.ListCampaigns(ctx, r.URL.Query().Get("tenant_id"), page)
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertCampaignsToDTO(campaigns), http.StatusOK)
+	logger.Info("Finished listing campaigns request")
+}
+
+// Create creates a draft campaign
+// swagger:route POST /campaigns campaign createCampaign
+//
+// # Create Campaign
+//
+// Creates a draft campaign. Its audience is uploaded with
+// POST /campaigns/{id}/recipients before it is started.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	201: campaignResponse
+//	400: errorResponse
+//	401: errorResponse
+//	500: errorResponse
+func (cc *CampaignController) Create(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(cc))
+	logger.Info("Creating campaign")
+	ctx := logger.WithCtx(r.Context())
+
+	var request dto.CreateCampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendErrorResponse(ctx, w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.ValidateStruct(request); err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	campaign, err := cc.CampaignUsecase.CreateCampaign(ctx, entity.Campaign{
+		TenantID:    request.TenantID,
+		Name:        request.Name,
+		Content:     request.Content,
+		Category:    entity.MessageCategory(request.Category),
+		ScheduledAt: request.ScheduledAt,
+	})
+	if errors.Is(err, entity.ErrValidation) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertCampaignToDTO(campaign), http.StatusCreated)
+	logger.Info("Finished create campaign request")
+}
+
+// Get retrieves a campaign with its aggregate counts
+// swagger:route GET /campaigns/{id} campaign getCampaign
+//
+// # Get Campaign
+//
+// Retrieves a campaign along with the number of recipients and the
+// number of its messages in each status.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: campaignResponse
+//	400: errorResponse
+//	401: errorResponse
+//	404: errorResponse
+//	500: errorResponse
+func (cc *CampaignController) Get(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(cc))
+	logger.Info("Getting campaign")
+	ctx := logger.WithCtx(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		sendErrorResponse(ctx, w, "Invalid campaign id", http.StatusBadRequest)
+		return
+	}
+
+	campaign, stats, err := cc.CampaignUsecase.GetCampaign(ctx, id)
+	if errors.Is(err, entity.ErrNotFound) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.ConvertCampaignToDTO(campaign)
+	statsDTO := dto.ConvertCampaignStatsToDTO(stats)
+	response.Stats = &statsDTO
+
+	sendJSONResponse(ctx, w, response, http.StatusOK)
+	logger.Info("Finished get campaign request")
+}
+
+// AddRecipients uploads a part of the campaign audience
+// swagger:route POST /campaigns/{id}/recipients campaign addCampaignRecipients
+//
+// # Add Campaign Recipients
+//
+// Adds phone numbers to the audience of a draft campaign, a large list
+// can be uploaded in several requests. Duplicates are skipped and numbers
+// that can't receive a message are returned as rejected.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: campaignRecipientsResponse
+//	400: errorResponse
+//	401: errorResponse
+//	404: errorResponse
+//	409: errorResponse
+//	500: errorResponse
+func (cc *CampaignController) AddRecipients(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(cc))
+	logger.Info("Adding campaign recipients")
+	ctx := logger.WithCtx(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		sendErrorResponse(ctx, w, "Invalid campaign id", http.StatusBadRequest)
+		return
+	}
+
+	var request dto.AddCampaignRecipientsRequest
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendErrorResponse(ctx, w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err = utils.ValidateStruct(request); err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	added, rejected, err := cc.CampaignUsecase.AddRecipients(ctx, id, request.PhoneNumbers)
+	if errors.Is(err, entity.ErrNotFound) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, entity.ErrConflict) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.CampaignRecipientsResultDTO{Added: added, Rejected: rejected}
+	sendJSONResponse(ctx, w, response, http.StatusOK)
+	logger.Info("Finished adding campaign recipients request")
+}
+
+// AddSegment adds a segment of past recipients to the campaign audience
+// swagger:route POST /campaigns/{id}/segment campaign addCampaignSegment
+//
+// # Add Campaign Segment
+//
+// Adds the recipients the tenant of a draft campaign has already sent
+// messages to, narrowed by country, category, creation time and delivery,
+// to its audience. Numbers already in the audience are skipped.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: campaignRecipientsResponse
+//	400: errorResponse
+//	401: errorResponse
+//	404: errorResponse
+//	409: errorResponse
+//	500: errorResponse
+func (cc *CampaignController) AddSegment(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(cc))
+	logger.Info("Adding campaign segment")
+	ctx := logger.WithCtx(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		sendErrorResponse(ctx, w, "Invalid campaign id", http.StatusBadRequest)
+		return
+	}
+
+	var request dto.AddCampaignSegmentRequest
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendErrorResponse(ctx, w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err = utils.ValidateStruct(request); err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	segment := entity.CampaignSegment{Since: request.Since, Delivered: request.Delivered}
+	if request.CountryCode != "" {
+		segment.CountryCode = &request.CountryCode
+	}
+	if request.Category != "" {
+		category := entity.MessageCategory(request.Category)
+		segment.Category = &category
+	}
+
+	added, err := cc.CampaignUsecase.AddSegment(ctx, id, segment)
+	if errors.Is(err, entity.ErrNotFound) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, entity.ErrConflict) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.CampaignRecipientsResultDTO{Added: added, Rejected: []string{}}
+	sendJSONResponse(ctx, w, response, http.StatusOK)
+	logger.Info("Finished adding campaign segment request")
+}
+
+// Start starts or resumes a campaign
+// swagger:route POST /campaigns/{id}/start campaign startCampaign
+//
+// # Start Campaign
+//
+// Starts a draft campaign or resumes a paused one. Its recipients are
+// expanded into messages in chunks once its schedule has come.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: campaignResponse
+//	400: errorResponse
+//	401: errorResponse
+//	404: errorResponse
+//	409: errorResponse
+//	500: errorResponse
+func (cc *CampaignController) Start(w http.ResponseWriter, r *http.Request) {
+	cc.changeStatus(w, r, "Starting campaign", cc.CampaignUsecase.StartCampaign)
+}
+
+// Pause pauses a running campaign
+// swagger:route POST /campaigns/{id}/pause campaign pauseCampaign
+//
+// # Pause Campaign
+//
+// Pauses a running campaign, its messages that haven't been claimed yet
+// aren't sent until it is started again.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: campaignResponse
+//	400: errorResponse
+//	401: errorResponse
+//	404: errorResponse
+//	409: errorResponse
+//	500: errorResponse
+func (cc *CampaignController) Pause(w http.ResponseWriter, r *http.Request) {
+	cc.changeStatus(w, r, "Pausing campaign", cc.CampaignUsecase.PauseCampaign)
+}
+
+// Cancel cancels a campaign
+// swagger:route POST /campaigns/{id}/cancel campaign cancelCampaign
+//
+// # Cancel Campaign
+//
+// Cancels a campaign for good, its messages that haven't been claimed
+// yet are cancelled.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: campaignResponse
+//	400: errorResponse
+//	401: errorResponse
+//	404: errorResponse
+//	409: errorResponse
+//	500: errorResponse
+func (cc *CampaignController) Cancel(w http.ResponseWriter, r *http.Request) {
+	cc.changeStatus(w, r, "Cancelling campaign", cc.CampaignUsecase.CancelCampaign)
+}
+
+func (cc *CampaignController) changeStatus(
+	w http.ResponseWriter,
+	r *http.Request,
+	action string,
+	change func(ctx context.Context, id uint64) (entity.Campaign, error),
+) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(cc))
+	logger.Info(action)
+	ctx := logger.WithCtx(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		sendErrorResponse(ctx, w, "Invalid campaign id", http.StatusBadRequest)
+		return
+	}
+
+	campaign, err := change(ctx, id)
+	if errors.Is(err, entity.ErrNotFound) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, entity.ErrConflict) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertCampaignToDTO(campaign), http.StatusOK)
+	logger.Info("Finished " + strings.ToLower(action) + " request")
+}
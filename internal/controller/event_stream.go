@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/dto"
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/constant"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/craftaholic/insider/internal/utils"
+)
+
+type EventStreamController struct {
+	EventStreamUsecase interfaces.EventStreamUsecase
+}
+
+func NewEventStreamController(eventStreamUsecase interfaces.EventStreamUsecase) *EventStreamController {
+	return &EventStreamController{
+		EventStreamUsecase: eventStreamUsecase,
+	}
+}
+
+// Stream pushes events as they happen
+// swagger:route GET /events/stream events streamEvents
+//
+// # Stream Events
+//
+// Pushes message status changes and service start/stop events as
+// Server-Sent Events, until the client disconnects. Events happening
+// while the client is disconnected aren't replayed.
+//
+// Produces:
+// - text/event-stream
+//
+// Responses:
+//
+//	200: eventStreamResponse
+//	400: errorResponse
+//	401: errorResponse
+//	403: errorResponse
+//	500: errorResponse
+func (ec *EventStreamController) Stream(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(ec))
+	logger.Info("Streaming events")
+	ctx := logger.WithCtx(r.Context())
+
+	filter := entity.StreamFilter{TenantID: r.URL.Query().Get("tenant_id")}
+	if types := r.URL.Query().Get("types"); types != "" {
+		for _, eventType := range strings.Split(types, ",") {
+			eventType := entity.EventType(strings.TrimSpace(eventType))
+			if !slices.Contains(entity.StreamEventTypes, eventType) {
+				sendErrorResponse(ctx, w, fmt.Sprintf("Unknown event type %q", eventType), http.StatusBadRequest)
+				return
+			}
+			filter.Types = append(filter.Types, eventType)
+		}
+	}
+
+	// The stream outlives the server write timeout
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		sendErrorResponse(ctx, w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	messages, unsubscribe := ec.EventStreamUsecase.Subscribe(ctx, filter)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		logger.Error("Failed to start event stream", "error", err)
+		return
+	}
+
+	masked := maskPII(ctx)
+
+	heartbeat := time.NewTicker(constant.EventStreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			logger.Info("Event stream client disconnected")
+			return
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		case message := <-messages:
+			payload := message.Payload
+			if masked {
+				if payload, err = maskStreamPayload(payload); err != nil {
+					logger.Error("Failed to mask event, dropping it", "error", err, "event_id", message.ID)
+					continue
+				}
+			}
+			_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", message.ID, message.Type, payload)
+		}
+
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			logger.Info("Event stream closed", "error", err)
+			return
+		}
+	}
+}
+
+// maskStreamPayload masks the message of an event as encoded for the stream.
+func maskStreamPayload(payload []byte) ([]byte, error) {
+	var event dto.StreamEventDTO
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+
+	if event.Data != nil {
+		masked := event.Data.Masked()
+		event.Data = &masked
+	}
+
+	return json.Marshal(event)
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/dto"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+)
+
+type exportOptions struct {
+	format  string
+	columns []string
+	gzip    bool
+}
+
+// parseExportOptions reads the format, columns and gzip query parameters.
+func parseExportOptions(r *http.Request) (exportOptions, error) {
+	options := exportOptions{
+		format:  exportFormatCSV,
+		columns: dto.MessageExportColumns,
+	}
+
+	if format := r.URL.Query().Get("format"); format != "" {
+		if format != exportFormatCSV && format != exportFormatNDJSON {
+			return exportOptions{}, fmt.Errorf("invalid format %q, expected csv or ndjson", format)
+		}
+		options.format = format
+	}
+
+	if columns := r.URL.Query().Get("columns"); columns != "" {
+		options.columns = nil
+		for _, column := range strings.Split(columns, ",") {
+			column = strings.TrimSpace(column)
+			if !slices.Contains(dto.MessageExportColumns, column) {
+				return exportOptions{}, fmt.Errorf("unknown column %q", column)
+			}
+			options.columns = append(options.columns, column)
+		}
+	}
+
+	if gzipParam := r.URL.Query().Get("gzip"); gzipParam != "" {
+		compress, err := strconv.ParseBool(gzipParam)
+		if err != nil {
+			return exportOptions{}, fmt.Errorf("invalid gzip %q", gzipParam)
+		}
+		options.gzip = compress
+	}
+
+	return options, nil
+}
+
+// messageExporter writes messages to the response in the export format.
+type messageExporter struct {
+	options exportOptions
+	gzip    *gzip.Writer
+	buffer  *bufio.Writer
+	csv     *csv.Writer
+	json    *json.Encoder
+}
+
+// newMessageExporter starts the response, a CSV export begins with its header row.
+func newMessageExporter(w http.ResponseWriter, options exportOptions) (*messageExporter, error) {
+	contentType, extension := "text/csv; charset=utf-8", exportFormatCSV
+	if options.format == exportFormatNDJSON {
+		contentType, extension = "application/x-ndjson", exportFormatNDJSON
+	}
+
+	filename := fmt.Sprintf("messages-%s.%s", time.Now().UTC().Format("20060102T150405Z"), extension)
+	exporter := &messageExporter{options: options}
+
+	var out io.Writer = w
+	if options.gzip {
+		contentType = "application/gzip"
+		filename += ".gz"
+		exporter.gzip = gzip.NewWriter(w)
+		out = exporter.gzip
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	exporter.buffer = bufio.NewWriter(out)
+	if options.format == exportFormatNDJSON {
+		exporter.json = json.NewEncoder(exporter.buffer)
+		return exporter, nil
+	}
+
+	exporter.csv = csv.NewWriter(exporter.buffer)
+	if err := exporter.csv.Write(options.columns); err != nil {
+		return nil, fmt.Errorf("failed to write export header: %w", err)
+	}
+	return exporter, nil
+}
+
+func (e *messageExporter) Write(message dto.MessageDTO) error {
+	if e.json != nil {
+		record := make(map[string]any, len(e.options.columns))
+		for _, column := range e.options.columns {
+			record[column] = dto.MessageExportValue(message, column)
+		}
+		return e.json.Encode(record)
+	}
+
+	record := make([]string, len(e.options.columns))
+	for i, column := range e.options.columns {
+		record[i] = formatExportValue(dto.MessageExportValue(message, column))
+	}
+	return e.csv.Write(record)
+}
+
+// Close flushes what is left of the export.
+func (e *messageExporter) Close() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+
+	if err := e.buffer.Flush(); err != nil {
+		return err
+	}
+
+	if e.gzip != nil {
+		return e.gzip.Close()
+	}
+	return nil
+}
+
+// formatExportValue writes a column value as a CSV field, null values are empty.
+func formatExportValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/craftaholic/insider/internal/domain/dto"
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/craftaholic/insider/internal/utils"
+)
+
+type InboundController struct {
+	SuppressionUsecase interfaces.SuppressionUsecase
+	MessageUsecase     interfaces.MessageUsecase
+}
+
+func NewInboundController(
+	suppressionUsecase interfaces.SuppressionUsecase,
+	messageUsecase interfaces.MessageUsecase,
+) *InboundController {
+	return &InboundController{
+		SuppressionUsecase: suppressionUsecase,
+		MessageUsecase:     messageUsecase,
+	}
+}
+
+// Receive handles a message sent back by a recipient
+// swagger:route POST /inbound inbound receiveInbound
+//
+// # Receive Inbound Message
+//
+// Called by the provider for every message a recipient sends back.
+// A STOP keyword adds the sender to the suppression list.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: startResponse
+//	400: errorResponse
+//	401: errorResponse
+//	500: errorResponse
+func (ic *InboundController) Receive(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(ic))
+	logger.Info("Receiving inbound message")
+	ctx := logger.WithCtx(r.Context())
+
+	var request dto.InboundMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendErrorResponse(ctx, w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.ValidateStruct(request); err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := ic.SuppressionUsecase.HandleInboundMessage(ctx, entity.InboundMessage{
+		From:     request.From,
+		Content:  request.Content,
+		Channel:  request.Channel,
+		TenantID: request.TenantID,
+	})
+	if errors.Is(err, entity.ErrValidation) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.CreateStandardResponse("OK", "Inbound message received")
+	sendJSONResponse(ctx, w, response, http.StatusOK)
+	logger.Info("Finished receiving inbound message request")
+}
+
+// DeliveryReport handles the final outcome of a sent message
+// swagger:route POST /inbound/dlr inbound receiveDeliveryReport
+//
+// # Receive Delivery Report
+//
+// Called by the provider once a sent message has been delivered to the
+// handset, or couldn't be. Undelivered messages are marked failed.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: startResponse
+//	400: errorResponse
+//	401: errorResponse
+//	404: errorResponse
+//	500: errorResponse
+func (ic *InboundController) DeliveryReport(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(ic))
+	logger.Info("Receiving delivery report")
+	ctx := logger.WithCtx(r.Context())
+
+	var request dto.DeliveryReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendErrorResponse(ctx, w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.ValidateStruct(request); err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := ic.MessageUsecase.HandleDeliveryReport(ctx, entity.DeliveryReport{
+		MessageID: request.MessageID,
+		Delivered: request.Status == "delivered",
+		Error:     request.Error,
+	})
+	if errors.Is(err, entity.ErrNotFound) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.CreateStandardResponse("OK", "Delivery report received")
+	sendJSONResponse(ctx, w, response, http.StatusOK)
+	logger.Info("Finished receiving delivery report request")
+}
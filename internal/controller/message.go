@@ -2,13 +2,19 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/craftaholic/insider/internal/domain/dto"
+	"github.com/craftaholic/insider/internal/domain/entity"
 	"github.com/craftaholic/insider/internal/domain/interfaces"
 	"github.com/craftaholic/insider/internal/shared/log"
 	"github.com/craftaholic/insider/internal/utils"
+	"github.com/go-chi/chi/v5"
 )
 
 type MessageController struct {
@@ -21,6 +27,66 @@ func NewMessageController(messageUsecase interfaces.MessageUsecase) *MessageCont
 	}
 }
 
+// Create handles the creation of a new message
+// swagger:route POST /message message createMessage
+//
+// # Create Message
+//
+// Validates a new message and stores it as pending, it is sent on one of
+// the next fetch cycles. The phone number is normalized to E.164.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	201: messageResponse
+//	400: errorResponse
+//	401: errorResponse
+//	403: errorResponse
+//	500: errorResponse
+func (mc *MessageController) Create(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(mc))
+	logger.Info("Creating message")
+	ctx := logger.WithCtx(r.Context())
+
+	var request dto.CreateMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendErrorResponse(ctx, w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.ValidateStruct(request); err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	message, err := mc.MessageUsecase.CreateMessage(ctx, entity.Message{
+		PhoneNumber: request.PhoneNumber,
+		Content:     request.Content,
+		TenantID:    request.TenantID,
+		Channel:     request.Channel,
+		OrderingKey: request.OrderingKey,
+		Category:    entity.MessageCategory(request.Category),
+		Timezone:    request.Timezone,
+		CallbackURL: request.CallbackURL,
+	})
+	if errors.Is(err, entity.ErrValidation) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertMessageToDTO(message), http.StatusCreated)
+	logger.Info("Finished create message request")
+}
+
 // Start handles starting the automated sending notification
 // swagger:route POST /service/start message start
 //
@@ -34,21 +100,24 @@ func NewMessageController(messageUsecase interfaces.MessageUsecase) *MessageCont
 // Responses:
 //
 //	200: startResponse
+//	401: errorResponse
+//	403: errorResponse
 //	500: errorResponse
 func (mc *MessageController) Start(w http.ResponseWriter, r *http.Request) {
 	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(mc))
 	logger.Info("Starting automated sending message")
 
 	// Use background context because the logic will run in another thread
-	// in the background.
-	err := mc.MessageUsecase.StartAutomatedSending(context.Background())
+	// in the background. It keeps the caller for the audit log.
+	ctx := entity.ContextWithActor(context.Background(), entity.ActorFromContext(r.Context()))
+	err := mc.MessageUsecase.StartAutomatedSending(ctx)
 	if err != nil {
-		mc.sendErrorResponse(r.Context(), w, err.Error(), http.StatusInternalServerError)
+		sendErrorResponse(r.Context(), w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	response := dto.CreateStandardResponse("OK", "Automated sending started successfully")
-	mc.sendJSONResponse(r.Context(), w, response, http.StatusAccepted)
+	sendJSONResponse(r.Context(), w, response, http.StatusAccepted)
 	logger.Info("Finished start automated sending message request")
 }
 
@@ -65,6 +134,8 @@ func (mc *MessageController) Start(w http.ResponseWriter, r *http.Request) {
 // Responses:
 //
 //	200: stopResponse
+//	401: errorResponse
+//	403: errorResponse
 //	500: errorResponse
 func (mc *MessageController) Stop(w http.ResponseWriter, r *http.Request) {
 	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(mc))
@@ -73,12 +144,12 @@ func (mc *MessageController) Stop(w http.ResponseWriter, r *http.Request) {
 
 	err := mc.MessageUsecase.StopAutomatedSending(ctx)
 	if err != nil {
-		mc.sendErrorResponse(r.Context(), w, err.Error(), http.StatusInternalServerError)
+		sendErrorResponse(r.Context(), w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	response := dto.CreateStandardResponse("OK", "Automated sending stopped successfully")
-	mc.sendJSONResponse(r.Context(), w, response, http.StatusAccepted)
+	sendJSONResponse(r.Context(), w, response, http.StatusAccepted)
 	logger.Info("Finished stop automated sending message request")
 }
 
@@ -103,7 +174,19 @@ func (mc *MessageController) Status(w http.ResponseWriter, r *http.Request) {
 
 	status, err := mc.MessageUsecase.GetAutomatedSendingStatus(ctx)
 	if err != nil {
-		mc.sendErrorResponse(r.Context(), w, err.Error(), http.StatusInternalServerError)
+		sendErrorResponse(r.Context(), w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	stats, err := mc.MessageUsecase.GetWorkerPoolStats(ctx)
+	if err != nil {
+		sendErrorResponse(r.Context(), w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	breaker, err := mc.MessageUsecase.GetCircuitBreakerStats(ctx)
+	if err != nil {
+		sendErrorResponse(r.Context(), w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -112,17 +195,124 @@ func (mc *MessageController) Status(w http.ResponseWriter, r *http.Request) {
 		message = "Automated sending service is stopped"
 	}
 
-	response := dto.CreateStandardResponse("OK", message)
-	mc.sendJSONResponse(r.Context(), w, response, http.StatusOK)
+	response := dto.ServiceStatusDTO{
+		Status:         "OK",
+		Message:        message,
+		WorkerPool:     dto.ConvertWorkerPoolStatsToDTO(stats),
+		CircuitBreaker: dto.ConvertCircuitBreakerStatsToDTO(breaker),
+	}
+	sendJSONResponse(r.Context(), w, response, http.StatusOK)
 	logger.Info("Finished stop automated sending message request")
 }
 
+// GetConfig returns the effective settings of the automated sending service
+// swagger:route GET /service/config message getServiceConfig
+//
+// # Get Service Config
+//
+// Returns the current worker pool and fetcher settings.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: serviceConfigResponse
+//	401: errorResponse
+//	500: errorResponse
+func (mc *MessageController) GetConfig(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(mc))
+	logger.Info("Getting automated sending service config")
+	ctx := logger.WithCtx(r.Context())
+
+	config, err := mc.MessageUsecase.GetServiceConfig(ctx)
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertServiceConfigToDTO(config), http.StatusOK)
+	logger.Info("Finished get service config request")
+}
+
+// UpdateConfig tunes the automated sending service while it is running
+// swagger:route PATCH /service/config message updateServiceConfig
+//
+// # Update Service Config
+//
+// Resizes the worker pool and changes the fetcher interval and batch size
+// without a restart. Returns the effective settings.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: serviceConfigResponse
+//	400: errorResponse
+//	401: errorResponse
+//	500: errorResponse
+func (mc *MessageController) UpdateConfig(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(mc))
+	logger.Info("Updating automated sending service config")
+	ctx := logger.WithCtx(r.Context())
+
+	var request dto.UpdateServiceConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendErrorResponse(ctx, w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.ValidateStruct(request); err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	config, err := mc.MessageUsecase.GetServiceConfig(ctx)
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Only override what has been sent
+	if request.WorkerCount != nil {
+		config.WorkerCount = *request.WorkerCount
+	}
+	if request.ProducerCronDuration != nil {
+		config.ProducerCronDuration = *request.ProducerCronDuration
+	}
+	if request.ProducerBatchNumber != nil {
+		config.ProducerBatchNumber = *request.ProducerBatchNumber
+	}
+	if request.WorkerMinCount != nil {
+		config.WorkerMinCount = *request.WorkerMinCount
+	}
+	if request.WorkerMaxCount != nil {
+		config.WorkerMaxCount = *request.WorkerMaxCount
+	}
+
+	config, err = mc.MessageUsecase.UpdateServiceConfig(ctx, config)
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertServiceConfigToDTO(config), http.StatusOK)
+	logger.Info("Finished update service config request")
+}
+
 // GetSentMessagesWithPagination retrieves sent messages with pagination
 // swagger:route GET /message/sent message getSentMessages
 //
 // # Get Sent Messages with Pagination
 //
-// Retrieves a paginated list of sent messages.
+// Retrieves a paginated list of sent messages, optionally filtered by
+// tenant, phone number and creation time. Callers without an API key or
+// with a masked one get them masked, only callers with a key may filter
+// by phone number.
 //
 // Produces:
 // - application/json
@@ -131,41 +321,211 @@ func (mc *MessageController) Status(w http.ResponseWriter, r *http.Request) {
 //
 //	200: messagesResponse
 //	400: errorResponse
+//	401: errorResponse
 //	500: errorResponse
 func (mc *MessageController) GetSentMessagesWithPagination(w http.ResponseWriter, r *http.Request) {
 	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(mc))
 	logger.Info("Getting sent messages with pagination")
 	ctx := logger.WithCtx(r.Context())
 
-	page := r.URL.Query().Get("page")
-	// Default value if page not declared
-	pageInt := 1
+	pageInt, err := parsePage(r)
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	if page != "" {
-		var err error
-		pageInt, err = strconv.Atoi(page)
-		if err != nil {
-			mc.sendErrorResponse(ctx, w, "Invalid page number", http.StatusBadRequest)
-			return
-		}
+	filter, err := parseMessageFilter(r)
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-		// Validate page number is positive
-		if pageInt < 1 {
-			mc.sendErrorResponse(ctx, w, "Page number must be greater than 0", http.StatusBadRequest)
-			return
-		}
+	// Masking the numbers is moot if anyone can look one up
+	if _, ok := entity.APIKeyFromContext(ctx); !ok && filter.PhoneNumber != "" {
+		sendErrorResponse(ctx, w, "Filtering by phone number needs an API key", http.StatusUnauthorized)
+		return
 	}
 
 	// Get domain entities from usecase
-	messages, err := mc.MessageUsecase.GetSentMessagesWithPagination(ctx, pageInt)
+	messages, err := mc.MessageUsecase.GetSentMessagesWithPagination(ctx, filter, pageInt)
+	if errors.Is(err, entity.ErrValidation) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
-		mc.sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Convert domain entities to DTOs
 	messageDTOs := dto.ConvertMessagesToDTO(messages)
+	if maskPII(ctx) {
+		for i := range messageDTOs {
+			messageDTOs[i] = messageDTOs[i].Masked()
+		}
+	}
 
-	mc.sendJSONResponse(ctx, w, messageDTOs, http.StatusOK)
+	sendJSONResponse(ctx, w, messageDTOs, http.StatusOK)
 	logger.Info("Finished getting sent messages with pagination request")
 }
+
+// Requeue puts a failed message back to pending
+// swagger:route POST /message/{id}/requeue message requeueMessage
+//
+// # Requeue Message
+//
+// Puts a failed message back to pending with its attempts reset, it is
+// sent on one of the next fetch cycles. Only failed messages are requeued.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: messageResponse
+//	400: errorResponse
+//	401: errorResponse
+//	403: errorResponse
+//	404: errorResponse
+//	500: errorResponse
+func (mc *MessageController) Requeue(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(mc))
+	logger.Info("Requeuing message")
+	ctx := logger.WithCtx(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		sendErrorResponse(ctx, w, "Invalid message id", http.StatusBadRequest)
+		return
+	}
+
+	message, err := mc.MessageUsecase.RequeueMessage(ctx, id)
+	if errors.Is(err, entity.ErrNotFound) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertMessageToDTO(message), http.StatusOK)
+	logger.Info("Finished requeue message request")
+}
+
+// Export streams the messages matching the filters as a file
+// swagger:route GET /messages/export message exportMessages
+//
+// # Export Messages
+//
+// Streams the sent, delivered and failed messages, or the given final
+// statuses, as CSV or NDJSON, oldest first. An export interrupted by an
+// error is cut off rather than completed.
+//
+// Produces:
+// - text/csv
+// - application/x-ndjson
+// - application/gzip
+//
+// Responses:
+//
+//	200: exportResponse
+//	400: errorResponse
+//	401: errorResponse
+//	403: errorResponse
+//	500: errorResponse
+func (mc *MessageController) Export(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(mc))
+	logger.Info("Exporting messages")
+	ctx := logger.WithCtx(r.Context())
+
+	filter, err := parseMessageFilter(r)
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		for _, s := range strings.Split(status, ",") {
+			filter.Statuses = append(filter.Statuses, entity.MessageStatus(strings.TrimSpace(s)))
+		}
+	}
+
+	options, err := parseExportOptions(r)
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// A large export outlives the server write timeout
+	if err = http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn("Export is bound by the write timeout", "error", err)
+	}
+
+	// The response only starts with the first message, so an error of the
+	// query itself can still be answered with an error status
+	var encoder *messageExporter
+	masked := maskPII(ctx)
+	err = mc.MessageUsecase.ExportMessages(ctx, filter, func(message entity.Message) error {
+		if encoder == nil {
+			exporter, err := newMessageExporter(w, options)
+			if err != nil {
+				return err
+			}
+			encoder = exporter
+		}
+		messageDTO := dto.ConvertMessageToDTO(message)
+		if masked {
+			messageDTO = messageDTO.Masked()
+		}
+		return encoder.Write(messageDTO)
+	})
+
+	if encoder == nil {
+		switch {
+		case errors.Is(err, entity.ErrValidation):
+			sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Nothing matched, still send the header row
+		if encoder, err = newMessageExporter(w, options); err != nil {
+			logger.Error("Failed to write export", "error", err)
+			return
+		}
+	}
+
+	if err != nil {
+		// The status is already sent, abort the connection so the client
+		// doesn't take a truncated export for a complete one
+		logger.Error("Export interrupted", "error", err)
+		panic(http.ErrAbortHandler)
+	}
+
+	if err = encoder.Close(); err != nil {
+		logger.Error("Failed to finish export", "error", err)
+		return
+	}
+
+	logger.Info("Finished export messages request")
+}
+
+// parseMessageFilter reads the tenant_id, phone_number, from and to query parameters.
+func parseMessageFilter(r *http.Request) (entity.MessageFilter, error) {
+	filter := entity.MessageFilter{
+		TenantID:    r.URL.Query().Get("tenant_id"),
+		PhoneNumber: r.URL.Query().Get("phone_number"),
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(r, "from"); err != nil {
+		return entity.MessageFilter{}, err
+	}
+	if filter.To, err = parseTimeParam(r, "to"); err != nil {
+		return entity.MessageFilter{}, err
+	}
+
+	return filter, nil
+}
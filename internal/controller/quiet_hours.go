@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/craftaholic/insider/internal/domain/dto"
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/craftaholic/insider/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type QuietHoursController struct {
+	QuietHoursUsecase interfaces.QuietHoursUsecase
+}
+
+func NewQuietHoursController(quietHoursUsecase interfaces.QuietHoursUsecase) *QuietHoursController {
+	return &QuietHoursController{
+		QuietHoursUsecase: quietHoursUsecase,
+	}
+}
+
+// List retrieves the configured quiet hours
+// swagger:route GET /quiet-hours quietHours listQuietHours
+//
+// # List Quiet Hours
+//
+// Retrieves the quiet hours of every tenant, or of one tenant.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: quietHoursListResponse
+//	401: errorResponse
+//	500: errorResponse
+func (qc *QuietHoursController) List(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(qc))
+	logger.Info("Listing quiet hours")
+	ctx := logger.WithCtx(r.Context())
+
+	quietHours, err := qc.QuietHoursUsecase.ListQuietHours(ctx, r.URL.Query().Get("tenant_id"))
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertQuietHoursListToDTO(quietHours), http.StatusOK)
+	logger.Info("Finished listing quiet hours request")
+}
+
+// Set creates or replaces the quiet hours of a tenant
+// swagger:route PUT /quiet-hours quietHours setQuietHours
+//
+// # Set Quiet Hours
+//
+// Sets the daily window, in the recipient's local time, during which
+// messages of a category aren't sent for a tenant. Messages claimed inside
+// the window are deferred to its end.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: quietHoursResponse
+//	400: errorResponse
+//	401: errorResponse
+//	500: errorResponse
+func (qc *QuietHoursController) Set(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(qc))
+	logger.Info("Setting quiet hours")
+	ctx := logger.WithCtx(r.Context())
+
+	var request dto.SetQuietHoursRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendErrorResponse(ctx, w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.ValidateStruct(request); err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	quietHours, err := qc.QuietHoursUsecase.SetQuietHours(ctx, entity.QuietHours{
+		TenantID:  request.TenantID,
+		Category:  entity.MessageCategory(request.Category),
+		StartTime: request.StartTime,
+		EndTime:   request.EndTime,
+	})
+	if errors.Is(err, entity.ErrValidation) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertQuietHoursToDTO(quietHours), http.StatusOK)
+	logger.Info("Finished setting quiet hours request")
+}
+
+// Delete removes quiet hours
+// swagger:route DELETE /quiet-hours/{id} quietHours deleteQuietHours
+//
+// # Remove Quiet Hours
+//
+// Removes quiet hours, messages of that tenant and category are sent at any time again.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: stopResponse
+//	400: errorResponse
+//	401: errorResponse
+//	404: errorResponse
+//	500: errorResponse
+func (qc *QuietHoursController) Delete(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(qc))
+	logger.Info("Removing quiet hours")
+	ctx := logger.WithCtx(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		sendErrorResponse(ctx, w, "Invalid quiet hours id", http.StatusBadRequest)
+		return
+	}
+
+	err = qc.QuietHoursUsecase.RemoveQuietHours(ctx, id)
+	if errors.Is(err, entity.ErrNotFound) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.CreateStandardResponse("OK", "Quiet hours removed successfully")
+	sendJSONResponse(ctx, w, response, http.StatusOK)
+	logger.Info("Finished removing quiet hours request")
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/craftaholic/insider/internal/domain/dto"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/craftaholic/insider/internal/utils"
+)
+
+type RetentionController struct {
+	RetentionUsecase interfaces.RetentionUsecase
+}
+
+func NewRetentionController(retentionUsecase interfaces.RetentionUsecase) *RetentionController {
+	return &RetentionController{
+		RetentionUsecase: retentionUsecase,
+	}
+}
+
+// Get retrieves the retention policy and its last run
+// swagger:route GET /retention retention getRetention
+//
+// # Get Message Retention
+//
+// Retrieves the retention policy of messages and the report of the last
+// run of the retention job, with how many messages it pruned per status.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: retentionResponse
+//	401: errorResponse
+//	500: errorResponse
+func (rc *RetentionController) Get(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(rc))
+	logger.Info("Getting retention")
+	ctx := logger.WithCtx(r.Context())
+
+	config, report, err := rc.RetentionUsecase.GetRetention(ctx)
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertRetentionToDTO(config, report), http.StatusOK)
+	logger.Info("Finished getting retention request")
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/craftaholic/insider/internal/domain/dto"
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/craftaholic/insider/internal/utils"
+)
+
+type StatsController struct {
+	StatsUsecase interfaces.StatsUsecase
+}
+
+func NewStatsController(statsUsecase interfaces.StatsUsecase) *StatsController {
+	return &StatsController{
+		StatsUsecase: statsUsecase,
+	}
+}
+
+// Get retrieves the message statistics
+// swagger:route GET /stats stats getStats
+//
+// # Get Message Statistics
+//
+// Retrieves the messages created in a time range per status and the
+// failure reasons among them, along with the throughput and the creation
+// to sent latency of the messages sent in it. The range defaults to the
+// last day and can't exceed a month.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: statsResponse
+//	400: errorResponse
+//	401: errorResponse
+//	500: errorResponse
+func (sc *StatsController) Get(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(sc))
+	logger.Info("Getting stats")
+	ctx := logger.WithCtx(r.Context())
+
+	filter := entity.StatsFilter{TenantID: r.URL.Query().Get("tenant_id")}
+
+	var err error
+	if filter.From, err = parseTimeParam(r, "from"); err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.To, err = parseTimeParam(r, "to"); err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats, err := sc.StatsUsecase.GetStats(ctx, filter)
+	if errors.Is(err, entity.ErrValidation) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertStatsToDTO(stats), http.StatusOK)
+	logger.Info("Finished getting stats request")
+}
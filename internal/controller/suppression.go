@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/craftaholic/insider/internal/domain/dto"
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/craftaholic/insider/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type SuppressionController struct {
+	SuppressionUsecase interfaces.SuppressionUsecase
+}
+
+func NewSuppressionController(suppressionUsecase interfaces.SuppressionUsecase) *SuppressionController {
+	return &SuppressionController{
+		SuppressionUsecase: suppressionUsecase,
+	}
+}
+
+// List retrieves the suppression list with pagination
+// swagger:route GET /suppressions suppression listSuppressions
+//
+// # List Suppressions
+//
+// Retrieves a paginated list of opted-out recipients.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: suppressionsResponse
+//	400: errorResponse
+//	401: errorResponse
+//	500: errorResponse
+func (sc *SuppressionController) List(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(sc))
+	logger.Info("Listing suppressions")
+	ctx := logger.WithCtx(r.Context())
+
+	page, err := parsePage(r)
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	suppressions, err := sc.SuppressionUsecase.ListSuppressions(ctx, r.URL.Query().Get("tenant_id"), page)
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertSuppressionsToDTO(suppressions), http.StatusOK)
+	logger.Info("Finished listing suppressions request")
+}
+
+// Create adds a recipient to the suppression list
+// swagger:route POST /suppressions suppression createSuppression
+//
+// # Add Suppression
+//
+// Adds a recipient to the suppression list, messages to this recipient
+// won't be sent anymore. Adding an existing recipient returns it.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	201: suppressionResponse
+//	400: errorResponse
+//	401: errorResponse
+//	500: errorResponse
+func (sc *SuppressionController) Create(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(sc))
+	logger.Info("Adding suppression")
+	ctx := logger.WithCtx(r.Context())
+
+	var request dto.CreateSuppressionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendErrorResponse(ctx, w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.ValidateStruct(request); err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	suppression, err := sc.SuppressionUsecase.AddSuppression(ctx, entity.Suppression{
+		PhoneNumber: request.PhoneNumber,
+		Channel:     request.Channel,
+		TenantID:    request.TenantID,
+		Reason:      request.Reason,
+	})
+	if errors.Is(err, entity.ErrValidation) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertSuppressionToDTO(suppression), http.StatusCreated)
+	logger.Info("Finished adding suppression request")
+}
+
+// Delete removes a recipient from the suppression list
+// swagger:route DELETE /suppressions/{id} suppression deleteSuppression
+//
+// # Remove Suppression
+//
+// Removes a recipient from the suppression list.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: stopResponse
+//	400: errorResponse
+//	401: errorResponse
+//	404: errorResponse
+//	500: errorResponse
+func (sc *SuppressionController) Delete(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(sc))
+	logger.Info("Removing suppression")
+	ctx := logger.WithCtx(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		sendErrorResponse(ctx, w, "Invalid suppression id", http.StatusBadRequest)
+		return
+	}
+
+	err = sc.SuppressionUsecase.RemoveSuppression(ctx, id)
+	if errors.Is(err, entity.ErrNotFound) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.CreateStandardResponse("OK", "Suppression removed successfully")
+	sendJSONResponse(ctx, w, response, http.StatusOK)
+	logger.Info("Finished removing suppression request")
+}
@@ -3,14 +3,19 @@ package controller
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/craftaholic/insider/internal/domain/dto"
+	"github.com/craftaholic/insider/internal/domain/entity"
 	"github.com/craftaholic/insider/internal/shared/log"
 )
 
 // sendJSONResponse for response handling.
-func (mc *MessageController) sendJSONResponse(c context.Context, w http.ResponseWriter, data any, statusCode int) {
+func sendJSONResponse(c context.Context, w http.ResponseWriter, data any, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 
@@ -23,7 +28,7 @@ func (mc *MessageController) sendJSONResponse(c context.Context, w http.Response
 	}
 }
 
-func (mc *MessageController) sendErrorResponse(
+func sendErrorResponse(
 	c context.Context,
 	w http.ResponseWriter,
 	message string,
@@ -43,3 +48,46 @@ func (mc *MessageController) sendErrorResponse(
 
 	logger.Error("Request handled failed", "error", message)
 }
+
+// maskPII tells whether the API key of the request gets messages masked,
+// anonymous requests always do.
+func maskPII(c context.Context) bool {
+	key, ok := entity.APIKeyFromContext(c)
+	return !ok || key.MaskPII
+}
+
+// parsePage reads the page query parameter, 1 when it is not declared.
+func parsePage(r *http.Request) (int, error) {
+	page := r.URL.Query().Get("page")
+	if page == "" {
+		return 1, nil
+	}
+
+	pageInt, err := strconv.Atoi(page)
+	if err != nil {
+		return 0, errors.New("invalid page number")
+	}
+
+	// Validate page number is positive
+	if pageInt < 1 {
+		return 0, errors.New("page number must be greater than 0")
+	}
+
+	return pageInt, nil
+}
+
+// parseTimeParam reads an RFC 3339 query parameter, the zero time when it
+// is not declared.
+func parseTimeParam(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s, expected an RFC 3339 time", name)
+	}
+
+	return parsed, nil
+}
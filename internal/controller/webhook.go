@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/craftaholic/insider/internal/domain/dto"
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/craftaholic/insider/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type WebhookController struct {
+	WebhookUsecase interfaces.WebhookUsecase
+}
+
+func NewWebhookController(webhookUsecase interfaces.WebhookUsecase) *WebhookController {
+	return &WebhookController{
+		WebhookUsecase: webhookUsecase,
+	}
+}
+
+// List retrieves the webhook subscriptions
+// swagger:route GET /webhooks webhook listWebhookSubscriptions
+//
+// # List Webhook Subscriptions
+//
+// Retrieves the webhook subscriptions of every tenant, or of one tenant.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: webhookSubscriptionsResponse
+//	401: errorResponse
+//	500: errorResponse
+func (wc *WebhookController) List(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(wc))
+	logger.Info("Listing webhook subscriptions")
+	ctx := logger.WithCtx(r.Context())
+
+	subscriptions, err := wc.WebhookUsecase.ListSubscriptions(ctx, r.URL.Query().Get("tenant_id"))
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertWebhookSubscriptionsToDTO(subscriptions), http.StatusOK)
+	logger.Info("Finished listing webhook subscriptions request")
+}
+
+// Create registers a webhook endpoint
+// swagger:route POST /webhooks webhook createWebhookSubscription
+//
+// # Create Webhook Subscription
+//
+// Registers an endpoint receiving the message lifecycle events of a
+// tenant. Events are posted as signed JSON and retried with backoff
+// until the endpoint answers with a 2xx. The secret is only returned here.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	201: webhookSubscriptionResponse
+//	400: errorResponse
+//	401: errorResponse
+//	500: errorResponse
+func (wc *WebhookController) Create(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(wc))
+	logger.Info("Creating webhook subscription")
+	ctx := logger.WithCtx(r.Context())
+
+	var request dto.CreateWebhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendErrorResponse(ctx, w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.ValidateStruct(request); err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	eventTypes := make([]entity.EventType, len(request.EventTypes))
+	for i, eventType := range request.EventTypes {
+		eventTypes[i] = entity.EventType(eventType)
+	}
+
+	subscription, err := wc.WebhookUsecase.CreateSubscription(ctx, entity.WebhookSubscription{
+		TenantID:   request.TenantID,
+		URL:        request.URL,
+		Secret:     request.Secret,
+		EventTypes: eventTypes,
+	})
+	if errors.Is(err, entity.ErrValidation) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.ConvertWebhookSubscriptionToDTO(subscription)
+	response.Secret = subscription.Secret
+
+	sendJSONResponse(ctx, w, response, http.StatusCreated)
+	logger.Info("Finished create webhook subscription request")
+}
+
+// Get retrieves a webhook subscription
+// swagger:route GET /webhooks/{id} webhook getWebhookSubscription
+//
+// # Get Webhook Subscription
+//
+// Retrieves a webhook subscription, without its secret.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: webhookSubscriptionResponse
+//	400: errorResponse
+//	401: errorResponse
+//	404: errorResponse
+//	500: errorResponse
+func (wc *WebhookController) Get(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(wc))
+	logger.Info("Getting webhook subscription")
+	ctx := logger.WithCtx(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		sendErrorResponse(ctx, w, "Invalid webhook subscription id", http.StatusBadRequest)
+		return
+	}
+
+	subscription, err := wc.WebhookUsecase.GetSubscription(ctx, id)
+	if errors.Is(err, entity.ErrNotFound) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertWebhookSubscriptionToDTO(subscription), http.StatusOK)
+	logger.Info("Finished get webhook subscription request")
+}
+
+// Delete removes a webhook subscription
+// swagger:route DELETE /webhooks/{id} webhook deleteWebhookSubscription
+//
+// # Delete Webhook Subscription
+//
+// Removes a webhook subscription along with its pending deliveries and delivery log.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: stopResponse
+//	400: errorResponse
+//	401: errorResponse
+//	404: errorResponse
+//	500: errorResponse
+func (wc *WebhookController) Delete(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(wc))
+	logger.Info("Deleting webhook subscription")
+	ctx := logger.WithCtx(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		sendErrorResponse(ctx, w, "Invalid webhook subscription id", http.StatusBadRequest)
+		return
+	}
+
+	err = wc.WebhookUsecase.DeleteSubscription(ctx, id)
+	if errors.Is(err, entity.ErrNotFound) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := dto.CreateStandardResponse("OK", "Webhook subscription deleted successfully")
+	sendJSONResponse(ctx, w, response, http.StatusOK)
+	logger.Info("Finished delete webhook subscription request")
+}
+
+// ListDeliveries retrieves the delivery log of a subscription
+// swagger:route GET /webhooks/{id}/deliveries webhook listWebhookDeliveries
+//
+// # List Webhook Deliveries
+//
+// Retrieves a paginated delivery log of a subscription, newest first,
+// with the attempts made and the last response of the endpoint.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+//
+//	200: webhookDeliveriesResponse
+//	400: errorResponse
+//	401: errorResponse
+//	404: errorResponse
+//	500: errorResponse
+func (wc *WebhookController) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	logger := log.FromCtx(r.Context()).WithFields("controller", utils.GetStructName(wc))
+	logger.Info("Listing webhook deliveries")
+	ctx := logger.WithCtx(r.Context())
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		sendErrorResponse(ctx, w, "Invalid webhook subscription id", http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePage(r)
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := wc.WebhookUsecase.ListDeliveries(ctx, id, page)
+	if errors.Is(err, entity.ErrNotFound) {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendErrorResponse(ctx, w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONResponse(ctx, w, dto.ConvertWebhookDeliveriesToDTO(deliveries), http.StatusOK)
+	logger.Info("Finished listing webhook deliveries request")
+}
@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditRecordDTO represents an audit log entry for API responses
+// swagger:model
+type AuditRecordDTO struct {
+	// Audit record ID
+	// example: 42
+	ID uint64 `json:"id"`
+
+	// Name of the API key that took the action, system or inbound otherwise
+	// example: ops
+	Actor string `json:"actor"`
+
+	// Action taken
+	// example: service.stop
+	Action string `json:"action"`
+
+	// Parameters of the action
+	// example: {"was_running": true}
+	Parameters json.RawMessage `json:"parameters"`
+
+	// IP address the request came from
+	// example: 10.0.0.12
+	SourceIP *string `json:"source_ip,omitempty"`
+
+	// Timestamp when the action was taken
+	// example: 2025-06-22T10:30:00Z
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// swagger:parameters listAuditRecords
+type ListAuditRecordsParams struct {
+	// Page number for pagination
+	// in: query
+	// minimum: 1
+	Page int `json:"page"`
+
+	// Only return the actions of this actor
+	// in: query
+	Actor string `json:"actor"`
+
+	// Only return this action
+	// in: query
+	// enum: service.start,service.stop,service.config.update,message.requeue,suppression.create,suppression.delete,quiet_hours.set,quiet_hours.delete,campaign.create,campaign.recipients.add,campaign.start,campaign.pause,campaign.cancel,webhook.create,webhook.delete,api_keys.update
+	Action string `json:"action"`
+
+	// Only return actions taken at or after this time (RFC 3339)
+	// in: query
+	From string `json:"from"`
+
+	// Only return actions taken before this time (RFC 3339)
+	// in: query
+	To string `json:"to"`
+}
+
+// swagger:response auditRecordsResponse
+type AuditRecordsResponse struct {
+	// List of audit records, newest first
+	// in: body
+	Body []AuditRecordDTO `json:"body"`
+}
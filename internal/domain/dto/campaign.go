@@ -0,0 +1,243 @@
+package dto
+
+import "time"
+
+// CampaignDTO represents a campaign for API responses
+// swagger:model
+type CampaignDTO struct {
+	// Campaign ID
+	// example: 7
+	ID uint64 `json:"id"`
+
+	// Tenant the campaign belongs to
+	// example: default
+	TenantID string `json:"tenant_id"`
+
+	// Campaign name
+	// example: Summer sale
+	Name string `json:"name"`
+
+	// Content sent to every recipient
+	// example: Summer sale, 20% off everything this weekend
+	Content string `json:"content"`
+
+	// Message category, quiet hours are set per category
+	// example: marketing
+	Category string `json:"category"`
+
+	// Number of billable SMS segments per message
+	// example: 1
+	Segments int `json:"segments"`
+
+	// Character encoding, GSM-7 or UCS-2
+	// example: GSM-7
+	Encoding string `json:"encoding"`
+
+	// Campaign status (draft, running, paused, completed or cancelled)
+	// example: running
+	Status string `json:"status"`
+
+	// Recipients aren't expanded into messages before this time
+	// example: 2025-06-23T09:00:00Z
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+
+	// Timestamp when the campaign was created
+	// example: 2025-06-22T10:30:00Z
+	CreatedAt time.Time `json:"created_at"`
+
+	// Timestamp of the last status change
+	// example: 2025-06-22T10:35:00Z
+	UpdatedAt *time.Time `json:"updated_at"`
+
+	// Timestamp when the last message was handled
+	// example: null
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	// Aggregate counts, only returned for a single campaign
+	Stats *CampaignStatsDTO `json:"stats,omitempty"`
+}
+
+// CampaignStatsDTO aggregates the recipients and messages of a campaign
+// swagger:model
+type CampaignStatsDTO struct {
+	// Recipients in the audience
+	// example: 1000
+	Recipients int64 `json:"recipients"`
+
+	// Recipients expanded into messages
+	// example: 500
+	Expanded int64 `json:"expanded"`
+
+	// Messages waiting to be sent
+	// example: 120
+	Pending int64 `json:"pending"`
+
+	// Messages being sent
+	// example: 10
+	Processing int64 `json:"processing"`
+
+	// Messages sent without a delivery report yet
+	// example: 300
+	Sent int64 `json:"sent"`
+
+	// Messages reported delivered by the provider
+	// example: 60
+	Delivered int64 `json:"delivered"`
+
+	// Messages that failed or were reported undelivered
+	// example: 8
+	Failed int64 `json:"failed"`
+
+	// Messages not sent because the recipient opted out
+	// example: 2
+	Suppressed int64 `json:"suppressed"`
+
+	// Messages cancelled with the campaign
+	// example: 0
+	Cancelled int64 `json:"cancelled"`
+}
+
+// CreateCampaignRequest is the body of the campaign creation
+// swagger:model
+type CreateCampaignRequest struct {
+	// Campaign name
+	// required: true
+	// example: Summer sale
+	Name string `json:"name" validate:"required,max=255"`
+
+	// Content sent to every recipient
+	// required: true
+	// example: Summer sale, 20% off everything this weekend
+	Content string `json:"content" validate:"required"`
+
+	// Tenant, default when omitted
+	// example: default
+	TenantID string `json:"tenant_id" validate:"omitempty,max=64"`
+
+	// Message category, marketing when omitted
+	// example: marketing
+	Category string `json:"category" validate:"omitempty,oneof=transactional marketing"`
+
+	// Recipients aren't expanded into messages before this time, right
+	// away when the campaign is started if omitted
+	// example: 2025-06-23T09:00:00Z
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+}
+
+// AddCampaignRecipientsRequest is an uploaded part of the campaign audience
+// swagger:model
+type AddCampaignRecipientsRequest struct {
+	// Recipient phone numbers, in E.164 or in the local format of the default region
+	// required: true
+	// example: ["+905551111111", "+905551111112"]
+	PhoneNumbers []string `json:"phone_numbers" validate:"required,min=1,max=10000,dive,max=32"`
+}
+
+// AddCampaignSegmentRequest selects recipients the tenant of the campaign
+// has already sent messages to, the ones with a message matching every
+// criterion given
+// swagger:model
+type AddCampaignSegmentRequest struct {
+	// Only recipients with a message to this country (ISO 3166-1 alpha-2)
+	// example: TR
+	CountryCode string `json:"country_code" validate:"omitempty,len=2,alpha"`
+
+	// Only recipients with a message of this category
+	// example: marketing
+	Category string `json:"category" validate:"omitempty,oneof=transactional marketing"`
+
+	// Only recipients with a message created since this time
+	// example: 2025-01-01T00:00:00Z
+	Since *time.Time `json:"since,omitempty"`
+
+	// Only recipients with a message reported delivered, rather than sent
+	// example: true
+	Delivered bool `json:"delivered"`
+}
+
+// CampaignRecipientsResultDTO tells what happened to an uploaded list
+// swagger:model
+type CampaignRecipientsResultDTO struct {
+	// Recipients added to the audience, duplicates aren't counted
+	// example: 2
+	Added int64 `json:"added"`
+
+	// Phone numbers that can't receive a message, always empty for a segment
+	// example: ["123"]
+	Rejected []string `json:"rejected"`
+}
+
+// swagger:parameters createCampaign
+type CreateCampaignParams struct {
+	// Campaign to create
+	// in: body
+	// required: true
+	Body CreateCampaignRequest
+}
+
+// swagger:parameters addCampaignRecipients
+type AddCampaignRecipientsParams struct {
+	// Campaign ID
+	// in: path
+	// required: true
+	ID uint64 `json:"id"`
+
+	// Recipients to add
+	// in: body
+	// required: true
+	Body AddCampaignRecipientsRequest
+}
+
+// swagger:parameters addCampaignSegment
+type AddCampaignSegmentParams struct {
+	// Campaign ID
+	// in: path
+	// required: true
+	ID uint64 `json:"id"`
+
+	// Segment to add
+	// in: body
+	// required: true
+	Body AddCampaignSegmentRequest
+}
+
+// swagger:parameters getCampaign startCampaign pauseCampaign cancelCampaign
+type CampaignIDParams struct {
+	// Campaign ID
+	// in: path
+	// required: true
+	ID uint64 `json:"id"`
+}
+
+// swagger:parameters listCampaigns
+type ListCampaignsParams struct {
+	// Page number for pagination
+	// in: query
+	// minimum: 1
+	Page int `json:"page"`
+
+	// Only return campaigns of this tenant
+	// in: query
+	TenantID string `json:"tenant_id"`
+}
+
+// swagger:response campaignResponse
+type CampaignResponse struct {
+	// Campaign
+	// in: body
+	Body CampaignDTO `json:"body"`
+}
+
+// swagger:response campaignsResponse
+type CampaignsResponse struct {
+	// List of campaigns
+	// in: body
+	Body []CampaignDTO `json:"body"`
+}
+
+// swagger:response campaignRecipientsResponse
+type CampaignRecipientsResponse struct {
+	// Result of the upload
+	// in: body
+	Body CampaignRecipientsResultDTO `json:"body"`
+}
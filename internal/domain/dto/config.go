@@ -0,0 +1,130 @@
+package dto
+
+import "time"
+
+// ServiceConfigDTO represents the effective settings of the automated sending service
+// swagger:model
+type ServiceConfigDTO struct {
+	// Number of concurrent workers
+	// example: 5
+	WorkerCount int `json:"worker_count"`
+
+	// Size of the worker job buffer
+	// example: 100
+	JobBuffer int `json:"job_buffer"`
+
+	// Interval between two fetches in seconds
+	// example: 30
+	ProducerCronDuration int `json:"producer_cron_duration"`
+
+	// Number of messages fetched on each tick
+	// example: 2
+	ProducerBatchNumber int `json:"producer_batch_number"`
+
+	// Deadline of a single message send in seconds, 0 for none
+	// example: 120
+	JobTimeout int `json:"job_timeout"`
+
+	// Lower bound of the autoscaled worker pool
+	// example: 2
+	WorkerMinCount int `json:"worker_min_count"`
+
+	// Upper bound of the autoscaled worker pool
+	// example: 20
+	WorkerMaxCount int `json:"worker_max_count"`
+
+	// Whether the worker count is managed by the autoscaler
+	// example: true
+	Autoscaled bool `json:"autoscaled"`
+
+	// Interval between two autoscaling decisions in seconds
+	// example: 15
+	AutoscaleInterval int `json:"autoscale_interval"`
+
+	// Minimum time between two scale ups in seconds
+	// example: 30
+	AutoscaleUpCooldown int `json:"autoscale_up_cooldown"`
+
+	// Minimum time between two scale downs in seconds
+	// example: 120
+	AutoscaleDownCooldown int `json:"autoscale_down_cooldown"`
+}
+
+// ServiceStatusDTO represents the status of the automated sending service
+// swagger:model
+type ServiceStatusDTO struct {
+	// Status of the operation
+	// example: OK
+	Status string `json:"status"`
+
+	// Descriptive message
+	// example: Automated sending service is running
+	Message string `json:"message"`
+
+	// Worker pool usage, all zero while the service is stopped
+	WorkerPool WorkerPoolStatsDTO `json:"worker_pool"`
+
+	// Circuit breaker of the notification provider
+	CircuitBreaker CircuitBreakerStatsDTO `json:"circuit_breaker"`
+}
+
+// WorkerPoolStatsDTO represents a snapshot of the worker pool usage
+// swagger:model
+type WorkerPoolStatsDTO struct {
+	// Number of running workers
+	// example: 5
+	Workers int `json:"workers"`
+
+	// Messages being sent right now
+	// example: 3
+	InFlight int `json:"in_flight"`
+
+	// Messages waiting in the job buffer
+	// example: 10
+	Queued int `json:"queued"`
+
+	// Messages the fetcher can still claim
+	// example: 90
+	FreeSlots int `json:"free_slots"`
+
+	// Size of the job buffer
+	// example: 100
+	Capacity int `json:"capacity"`
+
+	// Whether messages are sharded per ordering key
+	// example: false
+	Ordered bool `json:"ordered"`
+}
+
+// CircuitBreakerStatsDTO represents a snapshot of the circuit breaker of
+// the notification provider
+// swagger:model
+type CircuitBreakerStatsDTO struct {
+	// Whether the circuit breaker is enabled
+	// example: true
+	Enabled bool `json:"enabled"`
+
+	// State of the circuit (closed, open or half_open)
+	// example: closed
+	State string `json:"state"`
+
+	// Requests in the failure rate window
+	// example: 20
+	Requests int `json:"requests"`
+
+	// Failed requests in the failure rate window
+	// example: 2
+	Failures int `json:"failures"`
+
+	// Percentage of failed requests in the window
+	// example: 10
+	FailureRate float64 `json:"failure_rate"`
+
+	// When the circuit last opened, only while not closed
+	// example: 2025-06-22T10:30:00Z
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+
+	// When the circuit lets probes through, only while not closed
+	// example: 2025-06-22T10:30:30Z
+	RetryAt *time.Time `json:"retry_at,omitempty"`
+}
@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"encoding/json"
+
 	"github.com/craftaholic/insider/internal/domain/entity"
 )
 
@@ -14,7 +16,19 @@ func ConvertMessageToDTO(msg entity.Message) MessageDTO {
 		UpdatedAt:    msg.UpdatedAt,
 		Status:       msg.Status,
 		ErrorMessage: msg.ErrorMessage,
+		ErrorClass:   (*string)(msg.ErrorClass),
+		Attempts:     msg.Attempts,
 		MessageID:    msg.MessageID,
+		TenantID:     msg.TenantID,
+		Channel:      msg.Channel,
+		CountryCode:  msg.CountryCode,
+		OrderingKey:  msg.OrderingKey,
+		Segments:     msg.Segments,
+		Encoding:     msg.Encoding,
+		Category:     msg.Category,
+		Timezone:     msg.Timezone,
+		ScheduledAt:  msg.ScheduledAt,
+		CallbackURL:  msg.CallbackURL,
 	}
 
 	// Handle nullable SentAt
@@ -39,6 +53,70 @@ func ConvertMessagesToDTO(messages []entity.Message) []MessageDTO {
 	return dtos
 }
 
+// ConvertServiceConfigToDTO converts the service settings to DTO.
+func ConvertServiceConfigToDTO(config entity.ServiceConfig) ServiceConfigDTO {
+	return ServiceConfigDTO{
+		WorkerCount:           config.WorkerCount,
+		JobBuffer:             config.JobBuffer,
+		ProducerCronDuration:  config.ProducerCronDuration,
+		ProducerBatchNumber:   config.ProducerBatchNumber,
+		JobTimeout:            config.JobTimeout,
+		WorkerMinCount:        config.WorkerMinCount,
+		WorkerMaxCount:        config.WorkerMaxCount,
+		Autoscaled:            config.Autoscaled(),
+		AutoscaleInterval:     config.AutoscaleInterval,
+		AutoscaleUpCooldown:   config.AutoscaleUpCooldown,
+		AutoscaleDownCooldown: config.AutoscaleDownCooldown,
+	}
+}
+
+// ConvertWorkerPoolStatsToDTO converts the worker pool usage to DTO.
+func ConvertWorkerPoolStatsToDTO(stats entity.WorkerPoolStats) WorkerPoolStatsDTO {
+	return WorkerPoolStatsDTO{
+		Workers:   stats.Workers,
+		InFlight:  stats.InFlight,
+		Queued:    stats.Queued,
+		FreeSlots: stats.FreeSlots,
+		Capacity:  stats.Capacity,
+		Ordered:   stats.Ordered,
+	}
+}
+
+// ConvertCircuitBreakerStatsToDTO converts the circuit breaker snapshot to DTO.
+func ConvertCircuitBreakerStatsToDTO(stats entity.CircuitBreakerStats) CircuitBreakerStatsDTO {
+	return CircuitBreakerStatsDTO{
+		Enabled:     stats.Enabled,
+		State:       string(stats.State),
+		Requests:    stats.Requests,
+		Failures:    stats.Failures,
+		FailureRate: stats.FailureRate,
+		OpenedAt:    stats.OpenedAt,
+		RetryAt:     stats.RetryAt,
+	}
+}
+
+// ConvertSuppressionToDTO converts a suppression to DTO.
+func ConvertSuppressionToDTO(suppression entity.Suppression) SuppressionDTO {
+	return SuppressionDTO{
+		ID:          suppression.ID,
+		PhoneNumber: suppression.PhoneNumber,
+		Channel:     suppression.Channel,
+		TenantID:    suppression.TenantID,
+		Reason:      suppression.Reason,
+		Source:      string(suppression.Source),
+		CreatedAt:   suppression.CreatedAt,
+	}
+}
+
+// ConvertSuppressionsToDTO converts a slice of suppressions to DTOs.
+func ConvertSuppressionsToDTO(suppressions []entity.Suppression) []SuppressionDTO {
+	dtos := make([]SuppressionDTO, len(suppressions))
+	for i, suppression := range suppressions {
+		dtos[i] = ConvertSuppressionToDTO(suppression)
+	}
+	return dtos
+}
+
 // CreateStandardResponse creates a standard success response.
 func CreateStandardResponse(status, message string) StandardResponse {
 	return StandardResponse{
@@ -53,3 +131,238 @@ func CreateErrorResponse(err string) ErrorResponse {
 		Error: err,
 	}
 }
+
+// ConvertQuietHoursToDTO converts quiet hours to DTO.
+func ConvertQuietHoursToDTO(quietHours entity.QuietHours) QuietHoursDTO {
+	return QuietHoursDTO{
+		ID:        quietHours.ID,
+		TenantID:  quietHours.TenantID,
+		Category:  string(quietHours.Category),
+		StartTime: quietHours.StartTime,
+		EndTime:   quietHours.EndTime,
+		CreatedAt: quietHours.CreatedAt,
+		UpdatedAt: quietHours.UpdatedAt,
+	}
+}
+
+// ConvertQuietHoursListToDTO converts a slice of quiet hours to DTOs.
+func ConvertQuietHoursListToDTO(quietHoursList []entity.QuietHours) []QuietHoursDTO {
+	dtos := make([]QuietHoursDTO, len(quietHoursList))
+	for i, quietHours := range quietHoursList {
+		dtos[i] = ConvertQuietHoursToDTO(quietHours)
+	}
+	return dtos
+}
+
+// ConvertCampaignToDTO converts a campaign to DTO.
+func ConvertCampaignToDTO(campaign entity.Campaign) CampaignDTO {
+	return CampaignDTO{
+		ID:          campaign.ID,
+		TenantID:    campaign.TenantID,
+		Name:        campaign.Name,
+		Content:     campaign.Content,
+		Category:    string(campaign.Category),
+		Segments:    campaign.Segments,
+		Encoding:    string(campaign.Encoding),
+		Status:      string(campaign.Status),
+		ScheduledAt: campaign.ScheduledAt,
+		CreatedAt:   campaign.CreatedAt,
+		UpdatedAt:   campaign.UpdatedAt,
+		CompletedAt: campaign.CompletedAt,
+	}
+}
+
+// ConvertCampaignsToDTO converts a slice of campaigns to DTOs.
+func ConvertCampaignsToDTO(campaigns []entity.Campaign) []CampaignDTO {
+	dtos := make([]CampaignDTO, len(campaigns))
+	for i, campaign := range campaigns {
+		dtos[i] = ConvertCampaignToDTO(campaign)
+	}
+	return dtos
+}
+
+// ConvertCampaignStatsToDTO converts the campaign counts to DTO.
+func ConvertCampaignStatsToDTO(stats entity.CampaignStats) CampaignStatsDTO {
+	return CampaignStatsDTO{
+		Recipients: stats.Recipients,
+		Expanded:   stats.Expanded,
+		Pending:    stats.Pending,
+		Processing: stats.Processing,
+		Sent:       stats.Sent,
+		Delivered:  stats.Delivered,
+		Failed:     stats.Failed,
+		Suppressed: stats.Suppressed,
+		Cancelled:  stats.Cancelled,
+	}
+}
+
+// ConvertEventToDTO converts a message event to the webhook body.
+func ConvertEventToDTO(event entity.Event) EventDTO {
+	return EventDTO{
+		ID:        event.ID,
+		Type:      event.Type,
+		CreatedAt: event.OccurredAt,
+		Data:      ConvertMessageToDTO(event.Message),
+	}
+}
+
+// ConvertStreamEventToDTO converts an event to the live stream data.
+func ConvertStreamEventToDTO(event entity.Event) StreamEventDTO {
+	dto := StreamEventDTO{
+		ID:        event.ID,
+		Type:      event.Type,
+		TenantID:  event.Message.TenantID,
+		CreatedAt: event.OccurredAt,
+	}
+
+	// Service events don't concern any message
+	if event.Message.ID != 0 {
+		message := ConvertMessageToDTO(event.Message)
+		dto.Data = &message
+	}
+
+	return dto
+}
+
+// ConvertWebhookSubscriptionToDTO converts a webhook subscription to DTO, without its secret.
+func ConvertWebhookSubscriptionToDTO(subscription entity.WebhookSubscription) WebhookSubscriptionDTO {
+	return WebhookSubscriptionDTO{
+		ID:         subscription.ID,
+		TenantID:   subscription.TenantID,
+		URL:        subscription.URL,
+		EventTypes: subscription.EventTypes,
+		CreatedAt:  subscription.CreatedAt,
+	}
+}
+
+// ConvertWebhookSubscriptionsToDTO converts a slice of webhook subscriptions to DTOs.
+func ConvertWebhookSubscriptionsToDTO(subscriptions []entity.WebhookSubscription) []WebhookSubscriptionDTO {
+	dtos := make([]WebhookSubscriptionDTO, len(subscriptions))
+	for i, subscription := range subscriptions {
+		dtos[i] = ConvertWebhookSubscriptionToDTO(subscription)
+	}
+	return dtos
+}
+
+// ConvertWebhookDeliveriesToDTO converts a slice of webhook deliveries to DTOs.
+func ConvertWebhookDeliveriesToDTO(deliveries []entity.WebhookDelivery) []WebhookDeliveryDTO {
+	dtos := make([]WebhookDeliveryDTO, len(deliveries))
+	for i, delivery := range deliveries {
+		dtos[i] = WebhookDeliveryDTO{
+			ID:             delivery.ID,
+			EventID:        delivery.EventID,
+			EventType:      delivery.EventType,
+			Status:         string(delivery.Status),
+			Attempts:       delivery.Attempts,
+			NextAttemptAt:  delivery.NextAttemptAt,
+			LastStatusCode: delivery.LastStatusCode,
+			LastError:      delivery.LastError,
+			CreatedAt:      delivery.CreatedAt,
+			DeliveredAt:    delivery.DeliveredAt,
+		}
+	}
+	return dtos
+}
+
+// ConvertStatsToDTO converts the message statistics to DTO.
+func ConvertStatsToDTO(stats entity.MessageStats) StatsDTO {
+	counts := make(map[string]int64, len(stats.Counts))
+	for status, count := range stats.Counts {
+		counts[string(status)] = count
+	}
+
+	reasons := make([]FailureReasonDTO, len(stats.FailureReasons))
+	for i, reason := range stats.FailureReasons {
+		reasons[i] = FailureReasonDTO{
+			Reason: reason.Reason,
+			Count:  reason.Count,
+		}
+	}
+
+	return StatsDTO{
+		From:     stats.Filter.From,
+		To:       stats.Filter.To,
+		TenantID: stats.Filter.TenantID,
+		Counts:   counts,
+		Throughput: ThroughputDTO{
+			PerMinute: convertThroughputToDTO(stats.ThroughputPerMin),
+			PerHour:   convertThroughputToDTO(stats.ThroughputPerHour),
+		},
+		Latency: LatencyDTO{
+			Samples:    stats.Latency.Samples,
+			P50Seconds: stats.Latency.P50,
+			P95Seconds: stats.Latency.P95,
+		},
+		FailureReasons: reasons,
+	}
+}
+
+func convertThroughputToDTO(buckets []entity.ThroughputBucket) []ThroughputBucketDTO {
+	dtos := make([]ThroughputBucketDTO, len(buckets))
+	for i, bucket := range buckets {
+		dtos[i] = ThroughputBucketDTO{
+			Start: bucket.Start,
+			Sent:  bucket.Sent,
+		}
+	}
+	return dtos
+}
+
+// ConvertRetentionToDTO converts the retention policy and its last run to DTO.
+func ConvertRetentionToDTO(config entity.RetentionConfig, report *entity.RetentionReport) RetentionDTO {
+	rules := make([]RetentionRuleDTO, len(config.Rules))
+	for i, rule := range config.Rules {
+		rules[i] = RetentionRuleDTO{
+			Status: string(rule.Status),
+			Days:   rule.Days,
+		}
+	}
+
+	dto := RetentionDTO{
+		Enabled:         len(config.Rules) > 0 && config.Interval > 0 && config.Batch > 0,
+		Mode:            string(config.Mode),
+		IntervalSeconds: config.Interval,
+		Batch:           config.Batch,
+		Rules:           rules,
+	}
+
+	if report != nil {
+		pruned := make(map[string]int64, len(report.Pruned))
+		for status, count := range report.Pruned {
+			pruned[string(status)] = count
+		}
+
+		dto.LastRun = &RetentionReportDTO{
+			StartedAt:  report.StartedAt,
+			FinishedAt: report.FinishedAt,
+			Mode:       string(report.Mode),
+			Pruned:     pruned,
+			Total:      report.Total(),
+			Files:      report.Files,
+			Error:      report.Error,
+		}
+	}
+
+	return dto
+}
+
+// ConvertAuditRecordToDTO converts an audit record to DTO.
+func ConvertAuditRecordToDTO(record entity.AuditRecord) AuditRecordDTO {
+	return AuditRecordDTO{
+		ID:         record.ID,
+		Actor:      record.Actor,
+		Action:     string(record.Action),
+		Parameters: json.RawMessage(record.Parameters),
+		SourceIP:   record.SourceIP,
+		CreatedAt:  record.CreatedAt,
+	}
+}
+
+// ConvertAuditRecordsToDTO converts a slice of audit records to DTOs.
+func ConvertAuditRecordsToDTO(records []entity.AuditRecord) []AuditRecordDTO {
+	dtos := make([]AuditRecordDTO, len(records))
+	for i, record := range records {
+		dtos[i] = ConvertAuditRecordToDTO(record)
+	}
+	return dtos
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+)
+
+// StreamEventDTO is the data of an event pushed to the live stream
+// swagger:model
+type StreamEventDTO struct {
+	// Event ID
+	// example: 1f0c8a52-8b5e-4b8e-9b7a-2c1a0b7d9e11
+	ID string `json:"id"`
+
+	// Event type
+	// example: message.sent
+	Type entity.EventType `json:"type"`
+
+	// Tenant of the message, omitted for service events
+	// example: default
+	TenantID string `json:"tenant_id,omitempty"`
+
+	// Timestamp of the change
+	// example: 2025-06-22T10:35:00Z
+	CreatedAt time.Time `json:"created_at"`
+
+	// The message as it was after the change, omitted for service events
+	Data *MessageDTO `json:"data,omitempty"`
+}
+
+// swagger:parameters streamEvents
+type StreamEventsParams struct {
+	// Only push the events of this tenant, service events are always pushed
+	// in: query
+	TenantID string `json:"tenant_id"`
+
+	// Comma separated event types to push, every type when omitted
+	// in: query
+	// example: message.sent,message.failed
+	Types string `json:"types"`
+}
+
+// Server-Sent Events stream, every event is sent as `id`, `event` (its type)
+// and `data` (a StreamEventDTO) and a comment is sent as heartbeat
+// swagger:response eventStreamResponse
+type EventStreamResponse struct {
+	// in: body
+	Body StreamEventDTO `json:"body"`
+}
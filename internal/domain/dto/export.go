@@ -0,0 +1,100 @@
+package dto
+
+// MessageExportColumns are the columns a message export can contain, in
+// the order they are written when none are selected.
+var MessageExportColumns = []string{
+	"id", "tenant_id", "phone_number", "country_code", "channel", "category", "content",
+	"encoding", "segments", "status", "error_message", "error_class", "attempts", "message_id", "created_at", "sent_at", "updated_at",
+}
+
+// MessageExportValue returns the value of one export column of message.
+func MessageExportValue(message MessageDTO, column string) any {
+	switch column {
+	case "id":
+		return message.ID
+	case "tenant_id":
+		return message.TenantID
+	case "phone_number":
+		return message.PhoneNumber
+	case "country_code":
+		return message.CountryCode
+	case "channel":
+		return message.Channel
+	case "category":
+		return message.Category
+	case "content":
+		return message.Content
+	case "encoding":
+		return message.Encoding
+	case "segments":
+		return message.Segments
+	case "status":
+		return message.Status
+	case "error_message":
+		return message.ErrorMessage
+	case "error_class":
+		return message.ErrorClass
+	case "attempts":
+		return message.Attempts
+	case "message_id":
+		return message.MessageID
+	case "created_at":
+		return message.CreatedAt
+	case "sent_at":
+		return message.SentAt
+	case "updated_at":
+		return message.UpdatedAt
+	default:
+		return nil
+	}
+}
+
+// The exported messages, a CSV row or a JSON line per message
+// swagger:response exportResponse
+type ExportResponse struct {
+	// in: body
+	// swagger:file
+	Body []byte
+}
+
+// swagger:parameters exportMessages
+type ExportMessagesParams struct {
+	// csv or ndjson, csv when omitted
+	// in: query
+	// example: ndjson
+	Format string `json:"format"`
+
+	// Comma separated columns to export, every column when omitted
+	// in: query
+	// example: id,phone_number,status,sent_at
+	Columns string `json:"columns"`
+
+	// Compress the export with gzip
+	// in: query
+	Gzip bool `json:"gzip"`
+
+	// Comma separated statuses among sent, delivered, failed, suppressed and
+	// cancelled, sent, delivered and failed when omitted
+	// in: query
+	// example: sent,delivered
+	Status string `json:"status"`
+
+	// Only export messages of this tenant
+	// in: query
+	TenantID string `json:"tenant_id"`
+
+	// Only export messages to this phone number
+	// in: query
+	// example: +905551111111
+	PhoneNumber string `json:"phone_number"`
+
+	// Only export messages created from this time (RFC 3339)
+	// in: query
+	// example: 2025-03-01T00:00:00Z
+	From string `json:"from"`
+
+	// Only export messages created before this time (RFC 3339)
+	// in: query
+	// example: 2025-04-01T00:00:00Z
+	To string `json:"to"`
+}
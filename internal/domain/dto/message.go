@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/shared/redact"
 )
 
 // MessageDTO represents a message for API responses
@@ -41,7 +42,68 @@ type MessageDTO struct {
 	// example: null
 	ErrorMessage *string `json:"error_message,omitempty"`
 
+	// Class of the last provider error (transient, rate_limited,
+	// invalid_recipient, auth_failure or rejected)
+	// example: transient
+	ErrorClass *string `json:"error_class,omitempty"`
+
+	// Number of times the message was handed to the provider
+	// example: 1
+	Attempts int `json:"attempts"`
+
 	// Updated At
 	// example: 2025-06-22T10:35:00Z
 	UpdatedAt *time.Time `json:"updated_at"`
+
+	// Tenant the message belongs to
+	// example: default
+	TenantID string `json:"tenant_id"`
+
+	// Channel the message is sent on
+	// example: sms
+	Channel string `json:"channel"`
+
+	// ISO 3166 country code of the phone number
+	// example: TR
+	CountryCode *string `json:"country_code,omitempty"`
+
+	// Messages sharing this key are sent in order
+	// example: otp-123
+	OrderingKey *string `json:"ordering_key,omitempty"`
+
+	// Number of billable SMS segments
+	// example: 1
+	Segments int `json:"segments"`
+
+	// Character encoding, GSM-7 or UCS-2
+	// example: GSM-7
+	Encoding entity.SMSEncoding `json:"encoding"`
+
+	// Message category, quiet hours are set per category
+	// example: marketing
+	Category entity.MessageCategory `json:"category"`
+
+	// IANA timezone of the recipient, quiet hours are evaluated in it
+	// example: Europe/Istanbul
+	Timezone *string `json:"timezone,omitempty"`
+
+	// The message isn't sent before this time (ISO 8601 string, nullable)
+	// example: 2025-06-23T08:00:00+03:00
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+
+	// The final message is posted to this url
+	// example: https://booking.example.com/sms-callback
+	CallbackURL *string `json:"callback_url,omitempty"`
+}
+
+// Masked returns the message with its phone number reduced to its last
+// digits and its content hidden, for the callers that mustn't see them.
+func (m MessageDTO) Masked() MessageDTO {
+	m.PhoneNumber = redact.PhoneNumber(m.PhoneNumber)
+	m.Content = redact.String(redact.KindContent, m.Content)
+	if m.ErrorMessage != nil {
+		errorMessage := redact.PhoneNumbers(*m.ErrorMessage)
+		m.ErrorMessage = &errorMessage
+	}
+	return m
 }
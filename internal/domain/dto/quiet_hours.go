@@ -0,0 +1,95 @@
+package dto
+
+import "time"
+
+// QuietHoursDTO represents the quiet hours of a tenant for API responses
+// swagger:model
+type QuietHoursDTO struct {
+	// Quiet hours ID
+	// example: 3
+	ID uint64 `json:"id"`
+
+	// Tenant the quiet hours apply to
+	// example: default
+	TenantID string `json:"tenant_id"`
+
+	// Message category the quiet hours apply to
+	// example: marketing
+	Category string `json:"category"`
+
+	// Start of the window in the recipient's local time
+	// example: 21:00
+	StartTime string `json:"start_time"`
+
+	// End of the window in the recipient's local time
+	// example: 08:00
+	EndTime string `json:"end_time"`
+
+	// Timestamp when the quiet hours were created
+	// example: 2025-06-22T10:30:00Z
+	CreatedAt time.Time `json:"created_at"`
+
+	// Timestamp of the last change
+	// example: 2025-06-22T10:30:00Z
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// SetQuietHoursRequest is the body of the quiet hours update, a start
+// time after the end time spans midnight
+// swagger:model
+type SetQuietHoursRequest struct {
+	// Tenant, default when omitted
+	// example: default
+	TenantID string `json:"tenant_id" validate:"omitempty,max=64"`
+
+	// Message category, marketing when omitted
+	// example: marketing
+	Category string `json:"category" validate:"omitempty,oneof=transactional marketing"`
+
+	// Start of the window (HH:MM)
+	// required: true
+	// example: 21:00
+	StartTime string `json:"start_time" validate:"required,datetime=15:04"`
+
+	// End of the window (HH:MM)
+	// required: true
+	// example: 08:00
+	EndTime string `json:"end_time" validate:"required,datetime=15:04"`
+}
+
+// swagger:parameters setQuietHours
+type SetQuietHoursParams struct {
+	// Quiet hours window
+	// in: body
+	// required: true
+	Body SetQuietHoursRequest
+}
+
+// swagger:parameters deleteQuietHours
+type DeleteQuietHoursParams struct {
+	// Quiet hours ID
+	// in: path
+	// required: true
+	ID uint64 `json:"id"`
+}
+
+// swagger:parameters listQuietHours
+type ListQuietHoursParams struct {
+	// Only return quiet hours of this tenant
+	// in: query
+	TenantID string `json:"tenant_id"`
+}
+
+// swagger:response quietHoursResponse
+type QuietHoursResponse struct {
+	// Quiet hours
+	// in: body
+	Body QuietHoursDTO `json:"body"`
+}
+
+// swagger:response quietHoursListResponse
+type QuietHoursListResponse struct {
+	// List of quiet hours
+	// in: body
+	Body []QuietHoursDTO `json:"body"`
+}
@@ -8,6 +8,25 @@ type GetSentMessagesParams struct {
 	// minimum: 1
 	// example: 1
 	Page int `json:"page"`
+
+	// Only return messages of this tenant
+	// in: query
+	TenantID string `json:"tenant_id"`
+
+	// Only return messages to this phone number
+	// in: query
+	// example: +905551111111
+	PhoneNumber string `json:"phone_number"`
+
+	// Only return messages created from this time (RFC 3339)
+	// in: query
+	// example: 2025-03-01T00:00:00Z
+	From string `json:"from"`
+
+	// Only return messages created before this time (RFC 3339)
+	// in: query
+	// example: 2025-04-01T00:00:00Z
+	To string `json:"to"`
 }
 
 // swagger:parameters start
@@ -19,3 +38,91 @@ type StartParams struct {
 type StopParams struct {
 	// No parameters required for this endpoint
 }
+
+// UpdateServiceConfigRequest is the body of the service config update.
+// Omitted fields keep their current value.
+// swagger:model
+type UpdateServiceConfigRequest struct {
+	// Number of concurrent workers
+	// example: 10
+	WorkerCount *int `json:"worker_count,omitempty" validate:"omitempty,min=1"`
+
+	// Interval between two fetches in seconds
+	// example: 30
+	ProducerCronDuration *int `json:"producer_cron_duration,omitempty" validate:"omitempty,min=1"`
+
+	// Number of messages fetched on each tick
+	// example: 20
+	ProducerBatchNumber *int `json:"producer_batch_number,omitempty" validate:"omitempty,min=1"`
+
+	// Lower bound of the autoscaled worker pool
+	// example: 2
+	WorkerMinCount *int `json:"worker_min_count,omitempty" validate:"omitempty,min=1"`
+
+	// Upper bound of the autoscaled worker pool, equal to the lower
+	// bound to turn autoscaling off
+	// example: 20
+	WorkerMaxCount *int `json:"worker_max_count,omitempty" validate:"omitempty,min=1"`
+}
+
+// swagger:parameters updateServiceConfig
+type UpdateServiceConfigParams struct {
+	// New service settings
+	// in: body
+	// required: true
+	Body UpdateServiceConfigRequest
+}
+
+// CreateMessageRequest is the body of the message creation
+// swagger:model
+type CreateMessageRequest struct {
+	// Recipient phone number, in E.164 or in the local format of the default region
+	// required: true
+	// example: +905551111111
+	PhoneNumber string `json:"phone_number" validate:"required,max=32"`
+
+	// Message content
+	// required: true
+	// example: Hello, this is a test message
+	Content string `json:"content" validate:"required"`
+
+	// Tenant, default when omitted
+	// example: default
+	TenantID string `json:"tenant_id" validate:"omitempty,max=64"`
+
+	// Channel, sms when omitted
+	// example: sms
+	Channel string `json:"channel" validate:"omitempty,max=20"`
+
+	// Messages sharing this key are sent in order, the phone number when omitted
+	// example: otp-123
+	OrderingKey *string `json:"ordering_key,omitempty" validate:"omitempty,max=64"`
+
+	// Message category, quiet hours are set per category, transactional when omitted
+	// example: marketing
+	Category string `json:"category" validate:"omitempty,oneof=transactional marketing"`
+
+	// IANA timezone of the recipient, derived from the phone number when omitted
+	// example: Europe/Istanbul
+	Timezone *string `json:"timezone,omitempty" validate:"omitempty,max=64"`
+
+	// The final message is posted to this url once it is sent, failed or suppressed
+	// example: https://booking.example.com/sms-callback
+	CallbackURL *string `json:"callback_url,omitempty" validate:"omitempty,url,max=2048"`
+}
+
+// swagger:parameters createMessage
+type CreateMessageParams struct {
+	// Message to send
+	// in: body
+	// required: true
+	Body CreateMessageRequest
+}
+
+// swagger:parameters requeueMessage
+type RequeueMessageParams struct {
+	// Message ID
+	// in: path
+	// required: true
+	ID uint64 `json:"id"`
+}
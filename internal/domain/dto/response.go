@@ -34,9 +34,16 @@ type StopResponse struct {
 
 // swagger:response statusResponse
 type StatusResponse struct {
-	// Success response for stop operation
+	// Status of the automated sending service
 	// in: body
-	Body StandardResponse `json:"body"`
+	Body ServiceStatusDTO `json:"body"`
+}
+
+// swagger:response serviceConfigResponse
+type ServiceConfigResponse struct {
+	// Effective service settings
+	// in: body
+	Body ServiceConfigDTO `json:"body"`
 }
 
 // swagger:response healthResponse
@@ -46,6 +53,13 @@ type HealthResponse struct {
 	Body StandardResponse `json:"body"`
 }
 
+// swagger:response messageResponse
+type MessageResponse struct {
+	// Created message
+	// in: body
+	Body MessageDTO `json:"body"`
+}
+
 // swagger:response messagesResponse
 type MessagesResponse struct {
 	// List of sent messages
@@ -0,0 +1,80 @@
+package dto
+
+import "time"
+
+// RetentionDTO represents the retention policy and its last run for API responses
+// swagger:model
+type RetentionDTO struct {
+	// Whether the retention job runs
+	// example: true
+	Enabled bool `json:"enabled"`
+
+	// What happens to pruned messages: delete, archive (messages_archive table) or file
+	// example: archive
+	Mode string `json:"mode"`
+
+	// Seconds between two runs
+	// example: 3600
+	IntervalSeconds int `json:"interval_seconds"`
+
+	// Most messages pruned per statement
+	// example: 1000
+	Batch int `json:"batch"`
+
+	// Retention per status, statuses without a rule are kept forever
+	Rules []RetentionRuleDTO `json:"rules"`
+
+	// Last run of any replica, omitted when none has run yet
+	LastRun *RetentionReportDTO `json:"last_run,omitempty"`
+}
+
+// RetentionRuleDTO keeps the messages of a status for a number of days
+// swagger:model
+type RetentionRuleDTO struct {
+	// Message status
+	// example: sent
+	Status string `json:"status"`
+
+	// Days after creation the messages are kept
+	// example: 90
+	Days int `json:"days"`
+}
+
+// RetentionReportDTO is the outcome of one run of the retention job
+// swagger:model
+type RetentionReportDTO struct {
+	// Timestamp when the run started
+	// example: 2025-06-22T03:00:00Z
+	StartedAt time.Time `json:"started_at"`
+
+	// Timestamp when the run finished
+	// example: 2025-06-22T03:02:10Z
+	FinishedAt time.Time `json:"finished_at"`
+
+	// Mode of the run
+	// example: archive
+	Mode string `json:"mode"`
+
+	// Messages pruned per status
+	// example: {"sent": 120000, "failed": 830}
+	Pruned map[string]int64 `json:"pruned"`
+
+	// Messages pruned over every status
+	// example: 120830
+	Total int64 `json:"total"`
+
+	// Archive files written in file mode
+	// example: ["./archive/messages-20250622T030000Z.ndjson.gz"]
+	Files []string `json:"files,omitempty"`
+
+	// Error that stopped the run, the counts are what was pruned before it
+	// example: null
+	Error string `json:"error,omitempty"`
+}
+
+// swagger:response retentionResponse
+type RetentionResponse struct {
+	// Retention policy and last run
+	// in: body
+	Body RetentionDTO `json:"body"`
+}
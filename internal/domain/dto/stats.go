@@ -0,0 +1,105 @@
+package dto
+
+import "time"
+
+// StatsDTO is the breakdown of the messages of a time range for API responses
+// swagger:model
+type StatsDTO struct {
+	// Start of the range (ISO 8601 string, inclusive)
+	// example: 2025-06-22T00:00:00Z
+	From time.Time `json:"from"`
+
+	// End of the range (ISO 8601 string, exclusive)
+	// example: 2025-06-23T00:00:00Z
+	To time.Time `json:"to"`
+
+	// Tenant the statistics are restricted to, every tenant when omitted
+	// example: default
+	TenantID string `json:"tenant_id,omitempty"`
+
+	// Messages created in the range per status
+	// example: {"sent": 1200, "delivered": 950, "failed": 14, "pending": 30}
+	Counts map[string]int64 `json:"counts"`
+
+	// Messages sent in the range per minute and per hour
+	Throughput ThroughputDTO `json:"throughput"`
+
+	// Creation to sent latency of the messages sent in the range
+	Latency LatencyDTO `json:"latency"`
+
+	// Failed messages created in the range grouped by the prefix of their error
+	FailureReasons []FailureReasonDTO `json:"failure_reasons"`
+}
+
+// ThroughputDTO lists the number of messages sent per bucket, buckets
+// without any message are left out
+// swagger:model
+type ThroughputDTO struct {
+	// Per minute buckets, only computed for ranges up to a day
+	PerMinute []ThroughputBucketDTO `json:"per_minute"`
+
+	// Per hour buckets
+	PerHour []ThroughputBucketDTO `json:"per_hour"`
+}
+
+// ThroughputBucketDTO is the number of messages sent during one bucket
+// swagger:model
+type ThroughputBucketDTO struct {
+	// Start of the bucket (ISO 8601 string)
+	// example: 2025-06-22T10:00:00Z
+	Start time.Time `json:"start"`
+
+	// Messages sent during the bucket
+	// example: 42
+	Sent int64 `json:"sent"`
+}
+
+// LatencyDTO are percentiles of the time from creation to sending
+// swagger:model
+type LatencyDTO struct {
+	// Number of sent messages the percentiles are computed over
+	// example: 1200
+	Samples int64 `json:"samples"`
+
+	// Median in seconds
+	// example: 4.2
+	P50Seconds float64 `json:"p50_seconds"`
+
+	// 95th percentile in seconds
+	// example: 95.7
+	P95Seconds float64 `json:"p95_seconds"`
+}
+
+// FailureReasonDTO counts the failed messages sharing an error prefix
+// swagger:model
+type FailureReasonDTO struct {
+	// Error prefix, the part of the error before the first colon
+	// example: notification_failed
+	Reason string `json:"reason"`
+
+	// Failed messages with this prefix
+	// example: 9
+	Count int64 `json:"count"`
+}
+
+// swagger:parameters getStats
+type GetStatsParams struct {
+	// Start of the range (RFC 3339), a day before to when omitted
+	// in: query
+	From string `json:"from"`
+
+	// End of the range (RFC 3339), now when omitted
+	// in: query
+	To string `json:"to"`
+
+	// Only count messages of this tenant
+	// in: query
+	TenantID string `json:"tenant_id"`
+}
+
+// swagger:response statsResponse
+type StatsResponse struct {
+	// Message statistics
+	// in: body
+	Body StatsDTO `json:"body"`
+}
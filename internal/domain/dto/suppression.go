@@ -0,0 +1,153 @@
+package dto
+
+import "time"
+
+// SuppressionDTO represents an opted-out recipient for API responses
+// swagger:model
+type SuppressionDTO struct {
+	// Suppression ID
+	// example: 12
+	ID uint64 `json:"id"`
+
+	// Phone Number
+	// example: +905551111111
+	PhoneNumber string `json:"phone_number"`
+
+	// Channel the recipient opted out of
+	// example: sms
+	Channel string `json:"channel"`
+
+	// Tenant the suppression applies to
+	// example: default
+	TenantID string `json:"tenant_id"`
+
+	// Why the recipient is suppressed
+	// example: Replied STOP
+	Reason *string `json:"reason,omitempty"`
+
+	// How the recipient was suppressed (admin or keyword)
+	// example: keyword
+	Source string `json:"source"`
+
+	// Timestamp when the suppression was created
+	// example: 2025-06-22T10:30:00Z
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateSuppressionRequest is the body of the suppression creation
+// swagger:model
+type CreateSuppressionRequest struct {
+	// Phone Number
+	// required: true
+	// example: +905551111111
+	PhoneNumber string `json:"phone_number" validate:"required,max=20"`
+
+	// Channel, sms when omitted
+	// example: sms
+	Channel string `json:"channel" validate:"omitempty,max=20"`
+
+	// Tenant, default when omitted
+	// example: default
+	TenantID string `json:"tenant_id" validate:"omitempty,max=64"`
+
+	// Why the recipient is suppressed
+	// example: Customer asked by phone
+	Reason *string `json:"reason,omitempty"`
+}
+
+// InboundMessageRequest is a message received from a recipient, posted by the provider
+// swagger:model
+type InboundMessageRequest struct {
+	// Sender phone number
+	// required: true
+	// example: +905551111111
+	From string `json:"from" validate:"required,max=20"`
+
+	// Message content
+	// example: STOP
+	Content string `json:"content"`
+
+	// Channel, sms when omitted
+	// example: sms
+	Channel string `json:"channel" validate:"omitempty,max=20"`
+
+	// Tenant, default when omitted
+	// example: default
+	TenantID string `json:"tenant_id" validate:"omitempty,max=64"`
+}
+
+// DeliveryReportRequest is the final outcome of a sent message, posted by the provider
+// swagger:model
+type DeliveryReportRequest struct {
+	// Message ID the provider returned when the message was sent
+	// required: true
+	// example: e975f171-3ce5-4ea4-bf03-ae5b8849d2cb
+	MessageID string `json:"message_id" validate:"required,max=255"`
+
+	// Delivery status
+	// required: true
+	// example: delivered
+	Status string `json:"status" validate:"required,oneof=delivered undelivered"`
+
+	// Why the message wasn't delivered
+	// example: absent subscriber
+	Error *string `json:"error,omitempty"`
+}
+
+// swagger:parameters createSuppression
+type CreateSuppressionParams struct {
+	// Recipient to suppress
+	// in: body
+	// required: true
+	Body CreateSuppressionRequest
+}
+
+// swagger:parameters deleteSuppression
+type DeleteSuppressionParams struct {
+	// Suppression ID
+	// in: path
+	// required: true
+	ID uint64 `json:"id"`
+}
+
+// swagger:parameters listSuppressions
+type ListSuppressionsParams struct {
+	// Page number for pagination
+	// in: query
+	// minimum: 1
+	Page int `json:"page"`
+
+	// Only return suppressions of this tenant
+	// in: query
+	TenantID string `json:"tenant_id"`
+}
+
+// swagger:parameters receiveInbound
+type ReceiveInboundParams struct {
+	// Inbound message
+	// in: body
+	// required: true
+	Body InboundMessageRequest
+}
+
+// swagger:parameters receiveDeliveryReport
+type ReceiveDeliveryReportParams struct {
+	// Delivery report
+	// in: body
+	// required: true
+	Body DeliveryReportRequest
+}
+
+// swagger:response suppressionResponse
+type SuppressionResponse struct {
+	// Suppression
+	// in: body
+	Body SuppressionDTO `json:"body"`
+}
+
+// swagger:response suppressionsResponse
+type SuppressionsResponse struct {
+	// List of suppressions
+	// in: body
+	Body []SuppressionDTO `json:"body"`
+}
@@ -0,0 +1,178 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+)
+
+// EventDTO is the body posted to webhook endpoints. It is signed in the
+// X-Webhook-Signature header as t=<unix timestamp>,v1=<hex HMAC-SHA256 of
+// "<timestamp>.<body>"> with the subscription secret.
+// swagger:model
+type EventDTO struct {
+	// Event ID, the same for every retry of the event
+	// example: 1f0c8a52-8b5e-4b8e-9b7a-2c1a0b7d9e11
+	ID string `json:"id"`
+
+	// Event type
+	// example: message.sent
+	Type entity.EventType `json:"type"`
+
+	// Timestamp when the status changed
+	// example: 2025-06-22T10:35:00Z
+	CreatedAt time.Time `json:"created_at"`
+
+	// The message as it was after the change
+	Data MessageDTO `json:"data"`
+}
+
+// WebhookSubscriptionDTO represents a webhook subscription for API responses
+// swagger:model
+type WebhookSubscriptionDTO struct {
+	// Subscription ID
+	// example: 4
+	ID uint64 `json:"id"`
+
+	// Tenant whose message events are sent
+	// example: default
+	TenantID string `json:"tenant_id"`
+
+	// Endpoint the events are posted to
+	// example: https://example.com/hooks/messages
+	URL string `json:"url"`
+
+	// Secret the events are signed with, only returned on creation
+	// example: whsec_5f2b8c...
+	Secret string `json:"secret,omitempty"`
+
+	// Events the endpoint receives
+	// example: ["message.sent", "message.failed"]
+	EventTypes []entity.EventType `json:"event_types"`
+
+	// Timestamp when the subscription was created
+	// example: 2025-06-22T10:30:00Z
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDeliveryDTO is an entry of the delivery log of a subscription
+// swagger:model
+type WebhookDeliveryDTO struct {
+	// Delivery ID, sent in the X-Webhook-Delivery header
+	// example: 91
+	ID uint64 `json:"id"`
+
+	// Event ID
+	// example: 1f0c8a52-8b5e-4b8e-9b7a-2c1a0b7d9e11
+	EventID string `json:"event_id"`
+
+	// Event type
+	// example: message.sent
+	EventType entity.EventType `json:"event_type"`
+
+	// Delivery status (pending, delivering, succeeded or failed)
+	// example: succeeded
+	Status string `json:"status"`
+
+	// Number of attempts made so far
+	// example: 1
+	Attempts int `json:"attempts"`
+
+	// Timestamp of the next attempt while pending
+	// example: 2025-06-22T10:35:10Z
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+
+	// Status code of the last response
+	// example: 200
+	LastStatusCode *int `json:"last_status_code,omitempty"`
+
+	// Error of the last attempt
+	// example: endpoint responded with status 503
+	LastError *string `json:"last_error,omitempty"`
+
+	// Timestamp when the event was queued
+	// example: 2025-06-22T10:35:00Z
+	CreatedAt time.Time `json:"created_at"`
+
+	// Timestamp when the endpoint accepted the event
+	// example: 2025-06-22T10:35:01Z
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+}
+
+// CreateWebhookSubscriptionRequest is the body of the subscription creation
+// swagger:model
+type CreateWebhookSubscriptionRequest struct {
+	// Endpoint the events are posted to
+	// required: true
+	// example: https://example.com/hooks/messages
+	URL string `json:"url" validate:"required,url,max=2048"`
+
+	// Secret the events are signed with, generated when omitted
+	// example: my-shared-secret
+	Secret string `json:"secret" validate:"omitempty,min=16,max=255"`
+
+	// Events to receive, all of them when omitted
+	// example: ["message.sent", "message.failed", "message.delivered"]
+	EventTypes []string `json:"event_types" validate:"omitempty,dive,oneof=message.sent message.failed message.delivered message.suppressed"`
+
+	// Tenant whose message events are sent, default when omitted
+	// example: default
+	TenantID string `json:"tenant_id" validate:"omitempty,max=64"`
+}
+
+// swagger:parameters createWebhookSubscription
+type CreateWebhookSubscriptionParams struct {
+	// Subscription to create
+	// in: body
+	// required: true
+	Body CreateWebhookSubscriptionRequest
+}
+
+// swagger:parameters getWebhookSubscription deleteWebhookSubscription
+type WebhookSubscriptionIDParams struct {
+	// Subscription ID
+	// in: path
+	// required: true
+	ID uint64 `json:"id"`
+}
+
+// swagger:parameters listWebhookSubscriptions
+type ListWebhookSubscriptionsParams struct {
+	// Only return subscriptions of this tenant
+	// in: query
+	TenantID string `json:"tenant_id"`
+}
+
+// swagger:parameters listWebhookDeliveries
+type ListWebhookDeliveriesParams struct {
+	// Subscription ID
+	// in: path
+	// required: true
+	ID uint64 `json:"id"`
+
+	// Page number for pagination
+	// in: query
+	// minimum: 1
+	Page int `json:"page"`
+}
+
+// swagger:response webhookSubscriptionResponse
+type WebhookSubscriptionResponse struct {
+	// Webhook subscription
+	// in: body
+	Body WebhookSubscriptionDTO `json:"body"`
+}
+
+// swagger:response webhookSubscriptionsResponse
+type WebhookSubscriptionsResponse struct {
+	// List of webhook subscriptions
+	// in: body
+	Body []WebhookSubscriptionDTO `json:"body"`
+}
+
+// swagger:response webhookDeliveriesResponse
+type WebhookDeliveriesResponse struct {
+	// Delivery log, newest first
+	// in: body
+	Body []WebhookDeliveryDTO `json:"body"`
+}
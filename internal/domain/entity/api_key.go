@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// APIRole is what an API key may do on the admin endpoints.
+type APIRole string
+
+const (
+	// RoleAdmin may call every admin endpoint
+	RoleAdmin APIRole = "admin"
+	// RoleReadOnly may only read
+	RoleReadOnly APIRole = "readonly"
+)
+
+// APIKey is a bearer token accepted on the admin endpoints.
+type APIKey struct {
+	// Name identifies the key in logs, it is never the key itself
+	Name string
+	Role APIRole
+	Key  string
+
+	// MaskPII returns phone numbers and contents of messages masked, only
+	// read-only keys can have it
+	MaskPII bool
+}
+
+// ParseAPIKeys reads keys such as "ops:admin:<key>,dashboard:readonly:<key>:masked",
+// names and keys must be unique.
+func ParseAPIKeys(spec string) ([]APIKey, error) {
+	var keys []APIKey
+	names := map[string]struct{}{}
+	secrets := map[string]struct{}{}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) < 3 || len(parts) > 4 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("%w: api key entry isn't name:role:key[:masked]", ErrValidation)
+		}
+
+		key := APIKey{Name: parts[0], Role: APIRole(parts[1]), Key: parts[2]}
+		if key.Role != RoleAdmin && key.Role != RoleReadOnly {
+			return nil, fmt.Errorf("%w: api key %q has unknown role %q", ErrValidation, key.Name, key.Role)
+		}
+
+		if len(parts) == 4 {
+			if parts[3] != "masked" || key.Role != RoleReadOnly {
+				return nil, fmt.Errorf("%w: only read-only api key %q can be masked", ErrValidation, key.Name)
+			}
+			key.MaskPII = true
+		}
+
+		if _, exists := names[key.Name]; exists {
+			return nil, fmt.Errorf("%w: api key %q is configured twice", ErrValidation, key.Name)
+		}
+		if _, exists := secrets[key.Key]; exists {
+			return nil, fmt.Errorf("%w: api key %q reuses the key of another one", ErrValidation, key.Name)
+		}
+		names[key.Name] = struct{}{}
+		secrets[key.Key] = struct{}{}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// CanWrite tells whether the key may change anything.
+func (k APIKey) CanWrite() bool {
+	return k.Role == RoleAdmin
+}
+
+type apiKeyCtxKey struct{}
+
+// ContextWithAPIKey returns a copy of ctx carrying the key the request
+// was authenticated with.
+func ContextWithAPIKey(ctx context.Context, key APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyCtxKey{}, key)
+}
+
+// APIKeyFromContext returns the key the request was authenticated with.
+func APIKeyFromContext(ctx context.Context) (APIKey, bool) {
+	key, ok := ctx.Value(apiKeyCtxKey{}).(APIKey)
+	return key, ok
+}
@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+// AuditAction is an administrative action recorded in the audit log.
+type AuditAction string
+
+const (
+	AuditServiceStart        AuditAction = "service.start"
+	AuditServiceStop         AuditAction = "service.stop"
+	AuditServiceConfigUpdate AuditAction = "service.config.update"
+	AuditMessageRequeue      AuditAction = "message.requeue"
+	AuditSuppressionCreate   AuditAction = "suppression.create"
+	AuditSuppressionDelete   AuditAction = "suppression.delete"
+	AuditQuietHoursSet       AuditAction = "quiet_hours.set"
+	AuditQuietHoursDelete    AuditAction = "quiet_hours.delete"
+	AuditCampaignCreate      AuditAction = "campaign.create"
+	AuditCampaignRecipients  AuditAction = "campaign.recipients.add"
+	AuditCampaignStart       AuditAction = "campaign.start"
+	AuditCampaignPause       AuditAction = "campaign.pause"
+	AuditCampaignCancel      AuditAction = "campaign.cancel"
+	AuditWebhookCreate       AuditAction = "webhook.create"
+	AuditWebhookDelete       AuditAction = "webhook.delete"
+	AuditAPIKeysUpdate       AuditAction = "api_keys.update"
+)
+
+// AuditActions are the recorded actions, in the order they are documented.
+var AuditActions = []AuditAction{
+	AuditServiceStart,
+	AuditServiceStop,
+	AuditServiceConfigUpdate,
+	AuditMessageRequeue,
+	AuditSuppressionCreate,
+	AuditSuppressionDelete,
+	AuditQuietHoursSet,
+	AuditQuietHoursDelete,
+	AuditCampaignCreate,
+	AuditCampaignRecipients,
+	AuditCampaignStart,
+	AuditCampaignPause,
+	AuditCampaignCancel,
+	AuditWebhookCreate,
+	AuditWebhookDelete,
+	AuditAPIKeysUpdate,
+}
+
+const (
+	// ActorSystem is the actor of the actions the service takes on its
+	// own, such as starting the dispatcher at boot
+	ActorSystem = "system"
+	// ActorInbound is the actor of the actions triggered by the provider
+	ActorInbound = "inbound"
+)
+
+// AuditRecord is an entry of the audit log, records are never changed
+// once written. Parameters is a JSON object.
+type AuditRecord struct {
+	ID         uint64      `json:"id"         gorm:"primaryKey;column:id"`
+	Actor      string      `json:"actor"      gorm:"column:actor;type:varchar(64);not null"`
+	Action     AuditAction `json:"action"     gorm:"column:action;type:varchar(64);not null"`
+	Parameters string      `json:"parameters" gorm:"column:parameters;type:jsonb;not null;default:'{}'"`
+	SourceIP   *string     `json:"source_ip"  gorm:"column:source_ip;type:varchar(64)"`
+	CreatedAt  time.Time   `json:"created_at" gorm:"column:created_at;type:timestamptz;default:CURRENT_TIMESTAMP"`
+}
+
+func (AuditRecord) TableName() string {
+	return "audit_logs"
+}
+
+// AuditFilter selects audit records, empty fields don't filter. From and
+// To bound the time of the action, [From, To).
+type AuditFilter struct {
+	Actor  string
+	Action AuditAction
+	From   time.Time
+	To     time.Time
+}
+
+// Actor is who a request acts as, for the audit log.
+type Actor struct {
+	Name     string
+	SourceIP string
+}
+
+type actorCtxKey struct{}
+
+// ContextWithActor returns a copy of ctx acting as actor.
+func ContextWithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorCtxKey{}, actor)
+}
+
+// ActorFromContext returns who ctx acts as, the system when it isn't a request.
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorCtxKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Name: ActorSystem}
+}
@@ -0,0 +1,112 @@
+package entity
+
+import "time"
+
+// CampaignStatus represents the lifecycle of a campaign.
+type CampaignStatus string
+
+const (
+	CampaignDraft     CampaignStatus = "draft"
+	CampaignRunning   CampaignStatus = "running"
+	CampaignPaused    CampaignStatus = "paused"
+	CampaignCompleted CampaignStatus = "completed"
+	CampaignCancelled CampaignStatus = "cancelled"
+)
+
+// Campaign broadcasts the same content to a list of recipients. While it
+// is running its recipients are expanded into messages in chunks, only
+// messages of running campaigns are claimed for sending.
+type Campaign struct {
+	ID          uint64          `json:"id"           gorm:"primaryKey;column:id"`
+	TenantID    string          `json:"tenant_id"    gorm:"column:tenant_id;type:varchar(64);not null;default:default"`
+	Name        string          `json:"name"         gorm:"column:name;type:varchar(255);not null"`
+	Content     string          `json:"content"      gorm:"column:content;type:text;not null"`
+	Category    MessageCategory `json:"category"     gorm:"column:category;type:varchar(20);not null;default:marketing"`
+	Segments    int             `json:"segments"     gorm:"column:segments;type:smallint;not null;default:1"`
+	Encoding    SMSEncoding     `json:"encoding"     gorm:"column:encoding;type:varchar(8);not null;default:GSM-7"`
+	Status      CampaignStatus  `json:"status"       gorm:"column:status;type:varchar(20);not null;default:draft"`
+	ScheduledAt *time.Time      `json:"scheduled_at" gorm:"column:scheduled_at;type:timestamptz"`
+	CreatedAt   time.Time       `json:"created_at"   gorm:"column:created_at;type:timestamptz;default:CURRENT_TIMESTAMP"`
+	UpdatedAt   *time.Time      `json:"updated_at"   gorm:"column:updated_at;type:timestamptz"`
+	CompletedAt *time.Time      `json:"completed_at" gorm:"column:completed_at;type:timestamptz"`
+}
+
+// CanTransition reports whether the campaign may go from its status to next.
+func (c Campaign) CanTransition(next CampaignStatus) bool {
+	switch next {
+	case CampaignRunning:
+		return c.Status == CampaignDraft || c.Status == CampaignPaused
+	case CampaignPaused:
+		return c.Status == CampaignRunning
+	case CampaignCancelled:
+		return c.Status == CampaignDraft || c.Status == CampaignRunning || c.Status == CampaignPaused
+	default:
+		return false
+	}
+}
+
+// CampaignRecipient is one entry of the uploaded audience, ExpandedAt is
+// set once its message has been created.
+type CampaignRecipient struct {
+	ID          uint64     `json:"id"           gorm:"primaryKey;column:id"`
+	CampaignID  uint64     `json:"campaign_id"  gorm:"column:campaign_id;not null"`
+	PhoneNumber string     `json:"phone_number" gorm:"column:phone_number;type:text;not null;serializer:encrypted"`
+	CountryCode *string    `json:"country_code" gorm:"column:country_code;type:varchar(2)"`
+	Timezone    *string    `json:"timezone"     gorm:"column:timezone;type:varchar(64)"`
+	ExpandedAt  *time.Time `json:"expanded_at"  gorm:"column:expanded_at;type:timestamptz"`
+	CreatedAt   time.Time  `json:"created_at"   gorm:"column:created_at;type:timestamptz;default:CURRENT_TIMESTAMP"`
+
+	// PhoneNumberHash is the keyed hash phone numbers are looked up by
+	// once they are encrypted, nil when encryption is disabled
+	PhoneNumberHash *string `json:"-" gorm:"column:phone_number_hash;type:char(64)"`
+}
+
+// CampaignSegment selects recipients the tenant of a campaign has already
+// sent messages to, the ones with a message matching every criterion set.
+// Only messages that reached the provider count, Delivered narrows them to
+// the ones reported delivered.
+type CampaignSegment struct {
+	CountryCode *string
+	Category    *MessageCategory
+	Since       *time.Time
+	Delivered   bool
+}
+
+// CampaignStats aggregates the recipients of a campaign and the status of their messages.
+type CampaignStats struct {
+	Recipients int64
+	Expanded   int64
+	Pending    int64
+	Processing int64
+	Sent       int64
+	Delivered  int64
+	Failed     int64
+	Suppressed int64
+	Cancelled  int64
+}
+
+// CampaignConfig configures the expansion of campaign recipients into messages.
+type CampaignConfig struct {
+	// ExpandInterval is the time between two expansion rounds in seconds
+	ExpandInterval int
+
+	// ExpandChunk is the most recipients expanded per campaign and round
+	ExpandChunk int
+}
+
+// MessageFor returns the pending message the campaign sends to recipient.
+func (c Campaign) MessageFor(recipient CampaignRecipient) Message {
+	return Message{
+		PhoneNumber: recipient.PhoneNumber,
+		Content:     c.Content,
+		Status:      StatusPending,
+		TenantID:    c.TenantID,
+		Channel:     DefaultChannel,
+		CountryCode: recipient.CountryCode,
+		Segments:    c.Segments,
+		Encoding:    c.Encoding,
+		Category:    c.Category,
+		Timezone:    recipient.Timezone,
+		CampaignID:  &c.ID,
+	}
+}
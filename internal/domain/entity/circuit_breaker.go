@@ -0,0 +1,84 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// CircuitState is the state of the circuit breaker of the notification
+// provider.
+type CircuitState string
+
+const (
+	// CircuitClosed lets every request through
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen rejects every request until the open duration is over
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a few probe requests through, their outcome
+	// closes the circuit or opens it again
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// CircuitBreakerConfig configures the circuit breaker of the notification
+// provider.
+type CircuitBreakerConfig struct {
+	Enabled bool
+
+	// WindowSize is the number of latest requests the failure rate is
+	// computed over
+	WindowSize int
+
+	// MinRequests is how many requests the window needs before the
+	// failure rate can open the circuit
+	MinRequests int
+
+	// FailureRate is the percentage of failed requests in the window that
+	// opens the circuit
+	FailureRate int
+
+	// OpenDuration is the time in seconds the circuit stays open before
+	// probing the provider
+	OpenDuration int
+
+	// HalfOpenProbes is the number of requests let through while half
+	// open, the circuit closes once they all succeed
+	HalfOpenProbes int
+}
+
+// Validate reports settings the circuit breaker can't work with.
+func (cc CircuitBreakerConfig) Validate() error {
+	if !cc.Enabled {
+		return nil
+	}
+
+	switch {
+	case cc.WindowSize < 1:
+		return fmt.Errorf("%w: circuit breaker window must be at least 1", ErrValidation)
+	case cc.MinRequests < 1 || cc.MinRequests > cc.WindowSize:
+		return fmt.Errorf("%w: circuit breaker min requests must be between 1 and the window size", ErrValidation)
+	case cc.FailureRate < 1 || cc.FailureRate > 100:
+		return fmt.Errorf("%w: circuit breaker failure rate must be between 1 and 100", ErrValidation)
+	case cc.OpenDuration < 1:
+		return fmt.Errorf("%w: circuit breaker open duration must be at least 1 second", ErrValidation)
+	case cc.HalfOpenProbes < 1:
+		return fmt.Errorf("%w: circuit breaker half open probes must be at least 1", ErrValidation)
+	}
+
+	return nil
+}
+
+// CircuitBreakerStats is a snapshot of the circuit breaker.
+type CircuitBreakerStats struct {
+	Enabled bool
+	State   CircuitState
+
+	// Requests and Failures are counted over the current window
+	Requests    int
+	Failures    int
+	FailureRate float64
+
+	// OpenedAt is when the circuit last opened, RetryAt when it will let
+	// probes through. Both are nil while closed
+	OpenedAt *time.Time
+	RetryAt  *time.Time
+}
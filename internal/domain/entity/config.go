@@ -0,0 +1,106 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// ServiceConfig holds the tunable settings of the automated sending service.
+type ServiceConfig struct {
+	WorkerCount          int
+	JobBuffer            int
+	ProducerCronDuration int
+	ProducerBatchNumber  int
+	JobTimeout           int // seconds a single message may take, 0 for none
+
+	// StuckTimeout is the time in seconds after which a message left in
+	// processing, by a replica that died or an update that failed, is put
+	// back to pending
+	StuckTimeout int
+
+	// OrderedDelivery sends messages sharing a SequenceKey one after the
+	// other, only the oldest pending message of a key is ever claimed
+	OrderedDelivery bool
+
+	// Autoscaling, the pool size moves between WorkerMinCount and
+	// WorkerMaxCount. Durations are in seconds.
+	WorkerMinCount        int
+	WorkerMaxCount        int
+	AutoscaleInterval     int
+	AutoscaleUpCooldown   int
+	AutoscaleDownCooldown int
+
+	// Retries of the messages the provider failed with a retryable error,
+	// MaxAttempts counts the first one. Delays are in seconds, the base
+	// delay is doubled on every attempt
+	MaxAttempts    int
+	RetryBaseDelay int
+	RetryMaxDelay  int
+}
+
+// RetryDelay returns the backoff before the attempt following attempt.
+func (sc ServiceConfig) RetryDelay(attempt int) time.Duration {
+	return backoff(sc.RetryBaseDelay, sc.RetryMaxDelay, attempt)
+}
+
+// backoff returns the delay before the attempt following attempt, base
+// seconds doubled on every attempt after the first and capped at maxDelay
+// seconds.
+func backoff(base int, maxDelay int, attempt int) time.Duration {
+	delay := time.Duration(base) * time.Second
+	limit := time.Duration(maxDelay) * time.Second
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	return min(delay, limit)
+}
+
+// Validate reports settings the service can't work with. A message still
+// being sent must not be taken for a stuck one, so the send deadline has
+// to be shorter than StuckTimeout. Without a deadline a send lasting
+// longer than StuckTimeout is sent again. Ordered delivery shards the
+// messages by worker, the number of workers can't move.
+func (sc ServiceConfig) Validate() error {
+	switch {
+	case sc.StuckTimeout < 1:
+		return fmt.Errorf("%w: stuck timeout must be at least 1", ErrValidation)
+	case sc.JobTimeout >= sc.StuckTimeout:
+		return fmt.Errorf("%w: send timeout must be below the stuck timeout", ErrValidation)
+	case sc.OrderedDelivery && sc.Autoscaled():
+		return fmt.Errorf("%w: ordered delivery can't autoscale, worker min and max count must be equal", ErrValidation)
+	}
+
+	return nil
+}
+
+// Autoscaled reports whether the worker pool size is managed by the autoscaler.
+func (sc ServiceConfig) Autoscaled() bool {
+	return sc.WorkerMinCount < sc.WorkerMaxCount
+}
+
+// WorkerPoolStats is a snapshot of the worker pool usage.
+type WorkerPoolStats struct {
+	Workers   int
+	InFlight  int
+	Queued    int
+	FreeSlots int
+	Capacity  int
+	Ordered   bool
+}
+
+// IngestConfig holds the rules applied to messages when they are created.
+type IngestConfig struct {
+	// DefaultRegion is the ISO 3166 country used for numbers in local format
+	DefaultRegion string
+
+	// MaxSegments is the most SMS a message may be split into, 0 for no limit
+	MaxSegments int
+
+	// Transliterate replaces characters missing from GSM-7 so messages
+	// aren't sent as UCS-2, which fits less than half the characters
+	Transliterate bool
+
+	// CallbacksEnabled allows messages to carry a callback url, it requires
+	// a secret to sign the callbacks with
+	CallbacksEnabled bool
+}
@@ -0,0 +1,84 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     int
+		maxDelay int
+		attempts []int
+		want     []time.Duration
+	}{
+		{
+			name:     "Doubles",
+			base:     30,
+			maxDelay: 3600,
+			attempts: []int{1, 2, 3, 4},
+			want:     []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute},
+		},
+		{
+			name:     "Capped",
+			base:     30,
+			maxDelay: 100,
+			attempts: []int{2, 3, 4},
+			want:     []time.Duration{time.Minute, 100 * time.Second, 100 * time.Second},
+		},
+		{
+			name:     "BaseOverMax",
+			base:     600,
+			maxDelay: 60,
+			attempts: []int{1, 2},
+			want:     []time.Duration{time.Minute, time.Minute},
+		},
+		{
+			name:     "FirstAttemptAndBelow",
+			base:     30,
+			maxDelay: 600,
+			attempts: []int{-1, 0, 1},
+			want:     []time.Duration{30 * time.Second, 30 * time.Second, 30 * time.Second},
+		},
+		{
+			// The doubling stops at the cap, it doesn't overflow
+			name:     "ManyAttempts",
+			base:     1,
+			maxDelay: 86400,
+			attempts: []int{64, 1000, 1 << 30},
+			want:     []time.Duration{24 * time.Hour, 24 * time.Hour, 24 * time.Hour},
+		},
+		{
+			name:     "NoDelay",
+			base:     0,
+			maxDelay: 600,
+			attempts: []int{1, 5},
+			want:     []time.Duration{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, attempt := range tt.attempts {
+				assert.Equal(t, tt.want[i], backoff(tt.base, tt.maxDelay, attempt), "attempt %d", attempt)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	service := ServiceConfig{RetryBaseDelay: 60, RetryMaxDelay: 600}
+	webhook := WebhookConfig{RetryBaseDelay: 10, RetryMaxDelay: 60}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		assert.Equal(t, backoff(60, 600, attempt), service.RetryDelay(attempt))
+		assert.Equal(t, backoff(10, 60, attempt), webhook.RetryDelay(attempt))
+
+		// Always within the base and the cap
+		assert.GreaterOrEqual(t, service.RetryDelay(attempt), time.Minute)
+		assert.LessOrEqual(t, service.RetryDelay(attempt), 10*time.Minute)
+	}
+}
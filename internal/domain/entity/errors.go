@@ -0,0 +1,16 @@
+package entity
+
+import "errors"
+
+// ErrNotFound is returned by repositories when the requested record doesn't exist.
+var ErrNotFound = errors.New("record not found")
+
+// ErrValidation is wrapped by the errors of input the service refuses to take.
+var ErrValidation = errors.New("validation failed")
+
+// ErrConflict is returned when the record isn't in a state that allows the change.
+var ErrConflict = errors.New("conflict")
+
+// ErrCircuitOpen is returned instead of calling the notification provider
+// while its circuit breaker is open.
+var ErrCircuitOpen = errors.New("notification circuit is open")
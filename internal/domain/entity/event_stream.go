@@ -0,0 +1,32 @@
+package entity
+
+import "slices"
+
+// StreamEventTypes lists every event pushed to the live stream.
+var StreamEventTypes = append(slices.Clone(EventTypes),
+	EventMessageCreated, EventMessageProcessing, EventServiceStarted, EventServiceStopped, EventProviderAuthFailed)
+
+// StreamMessage is an event received from the live stream, Payload is its
+// JSON encoding as sent to the clients.
+type StreamMessage struct {
+	ID       string
+	Type     EventType
+	TenantID string
+	Payload  []byte
+}
+
+// StreamFilter selects the events a live stream client receives, every
+// event when empty. Service events aren't tied to a tenant and pass the
+// tenant filter.
+type StreamFilter struct {
+	TenantID string
+	Types    []EventType
+}
+
+// Matches tells whether the event passes the filter.
+func (f StreamFilter) Matches(message StreamMessage) bool {
+	if f.TenantID != "" && message.TenantID != "" && message.TenantID != f.TenantID {
+		return false
+	}
+	return len(f.Types) == 0 || slices.Contains(f.Types, message.Type)
+}
@@ -14,6 +14,14 @@ const (
 	StatusProcessing MessageStatus = "processing"
 	StatusSent       MessageStatus = "sent"
 	StatusFailed     MessageStatus = "failed"
+	StatusSuppressed MessageStatus = "suppressed"
+	StatusDelivered  MessageStatus = "delivered"
+	StatusCancelled  MessageStatus = "cancelled"
+)
+
+const (
+	DefaultTenantID = "default"
+	DefaultChannel  = "sms"
 )
 
 // Scan implements the Scanner interface for database reads.
@@ -35,13 +43,60 @@ func (ms *MessageStatus) Value() (driver.Value, error) {
 }
 
 type Message struct {
-	ID           uint64        `json:"id"            gorm:"primaryKey;column:id"`
-	PhoneNumber  string        `json:"phone_number"  gorm:"column:phone_number;type:varchar(20);not null"`
-	Content      string        `json:"content"       gorm:"column:content;type:text;not null"`
-	Status       MessageStatus `json:"status"        gorm:"column:status;type:varchar(20);default:pending;check:status IN ('pending', 'processing', 'sent', 'failed')"`
-	CreatedAt    time.Time     `json:"created_at"    gorm:"column:created_at;type:timestamptz;default:CURRENT_TIMESTAMP"`
-	SentAt       *time.Time    `json:"sent_at"       gorm:"column:sent_at;type:timestamptz"`
-	MessageID    *string       `json:"message_id"    gorm:"column:message_id;type:varchar(255)"`
-	ErrorMessage *string       `json:"error_message" gorm:"column:error_message;type:text"`
-	UpdatedAt    *time.Time    `json:"updated_at"    gorm:"column:updated_at;type:timestamptz"`
+	ID           uint64          `json:"id"            gorm:"primaryKey;column:id"`
+	PhoneNumber  string          `json:"phone_number"  gorm:"column:phone_number;type:text;not null;serializer:encrypted"`
+	Content      string          `json:"content"       gorm:"column:content;type:text;not null;serializer:encrypted"`
+	Status       MessageStatus   `json:"status"        gorm:"column:status;type:varchar(20);default:pending;check:status IN ('pending', 'processing', 'sent', 'failed', 'suppressed', 'delivered', 'cancelled')"`
+	CreatedAt    time.Time       `json:"created_at"    gorm:"column:created_at;type:timestamptz;default:CURRENT_TIMESTAMP"`
+	SentAt       *time.Time      `json:"sent_at"       gorm:"column:sent_at;type:timestamptz"`
+	MessageID    *string         `json:"message_id"    gorm:"column:message_id;type:varchar(255)"`
+	ErrorMessage *string         `json:"error_message" gorm:"column:error_message;type:text"`
+	ErrorClass   *ErrorClass     `json:"error_class"   gorm:"column:error_class;type:varchar(32)"`
+	Attempts     int             `json:"attempts"      gorm:"column:attempts;type:smallint;not null;default:0"`
+	UpdatedAt    *time.Time      `json:"updated_at"    gorm:"column:updated_at;type:timestamptz"`
+	OrderingKey  *string         `json:"ordering_key"  gorm:"column:ordering_key;type:varchar(64)"`
+	TenantID     string          `json:"tenant_id"     gorm:"column:tenant_id;type:varchar(64);not null;default:default"`
+	Channel      string          `json:"channel"       gorm:"column:channel;type:varchar(20);not null;default:sms"`
+	CountryCode  *string         `json:"country_code"  gorm:"column:country_code;type:varchar(2)"`
+	Segments     int             `json:"segments"      gorm:"column:segments;type:smallint;not null;default:1"`
+	Encoding     SMSEncoding     `json:"encoding"      gorm:"column:encoding;type:varchar(8);not null;default:GSM-7"`
+	Category     MessageCategory `json:"category"      gorm:"column:category;type:varchar(20);not null;default:transactional"`
+	Timezone     *string         `json:"timezone"      gorm:"column:timezone;type:varchar(64)"`
+	ScheduledAt  *time.Time      `json:"scheduled_at"  gorm:"column:scheduled_at;type:timestamptz"`
+	CampaignID   *uint64         `json:"campaign_id"   gorm:"column:campaign_id"`
+	CallbackURL  *string         `json:"callback_url"  gorm:"column:callback_url;type:text"`
+
+	// PhoneNumberHash is the keyed hash phone numbers are looked up by
+	// once they are encrypted, nil when encryption is disabled
+	PhoneNumberHash *string `json:"-" gorm:"column:phone_number_hash;type:char(64)"`
+}
+
+// MessageFilter selects messages, empty fields don't filter. From and To
+// bound the creation time, [From, To).
+type MessageFilter struct {
+	TenantID    string
+	PhoneNumber string
+	Statuses    []MessageStatus
+	From        time.Time
+	To          time.Time
+}
+
+// FinalStatuses are the statuses a message stays in once sending is over,
+// only a delivery report still turns sent into delivered or failed.
+var FinalStatuses = []MessageStatus{StatusSent, StatusDelivered, StatusFailed, StatusSuppressed, StatusCancelled}
+
+// DeliveryReport is the final outcome of a sent message reported by the provider.
+type DeliveryReport struct {
+	MessageID string
+	Delivered bool
+	Error     *string
+}
+
+// SequenceKey returns the key messages are kept in order by, the
+// ordering key when it is set and the phone number otherwise.
+func (m Message) SequenceKey() string {
+	if m.OrderingKey != nil && *m.OrderingKey != "" {
+		return *m.OrderingKey
+	}
+	return m.PhoneNumber
 }
@@ -0,0 +1,80 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrorClass tells what a failed send to the notification provider means
+// for the message.
+type ErrorClass string
+
+const (
+	// ErrorClassTransient is a network error, a timeout or a provider
+	// error that may not happen again
+	ErrorClassTransient ErrorClass = "transient"
+	// ErrorClassRateLimited is the provider asking to slow down
+	ErrorClassRateLimited ErrorClass = "rate_limited"
+	// ErrorClassInvalidRecipient is a phone number the provider can't send to
+	ErrorClassInvalidRecipient ErrorClass = "invalid_recipient"
+	// ErrorClassAuthFailure is the provider refusing our credentials
+	ErrorClassAuthFailure ErrorClass = "auth_failure"
+	// ErrorClassRejected is any other refusal of the message by the provider
+	ErrorClassRejected ErrorClass = "rejected"
+)
+
+// Retryable reports whether sending the message again later may succeed.
+// Auth failures are on our side rather than the message's, so they are
+// retried while someone fixes the credentials.
+func (ec ErrorClass) Retryable() bool {
+	switch ec {
+	case ErrorClassTransient, ErrorClassRateLimited, ErrorClassAuthFailure:
+		return true
+	default:
+		return false
+	}
+}
+
+// NotificationError is a send to the notification provider that failed.
+type NotificationError struct {
+	Class ErrorClass
+	// StatusCode is the HTTP status of the provider response, 0 when
+	// there was none
+	StatusCode int
+	// Detail is the message of the provider or the cause of the failure
+	Detail string
+	// RetryAfter is the delay the provider asked for, 0 when it didn't
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (ne *NotificationError) Error() string {
+	if ne.StatusCode == 0 {
+		return fmt.Sprintf("%s: %s", ne.Class, ne.Detail)
+	}
+	return fmt.Sprintf("%s: status %d: %s", ne.Class, ne.StatusCode, ne.Detail)
+}
+
+func (ne *NotificationError) Unwrap() error {
+	return ne.Err
+}
+
+// ErrorClassOf returns the class of a failed send, errors that weren't
+// classified by the provider are transient.
+func ErrorClassOf(err error) ErrorClass {
+	var notificationErr *NotificationError
+	if errors.As(err, &notificationErr) {
+		return notificationErr.Class
+	}
+	return ErrorClassTransient
+}
+
+// RetryAfterOf returns the delay the provider asked for, 0 when it didn't.
+func RetryAfterOf(err error) time.Duration {
+	var notificationErr *NotificationError
+	if errors.As(err, &notificationErr) {
+		return notificationErr.RetryAfter
+	}
+	return 0
+}
@@ -0,0 +1,16 @@
+package entity
+
+// PartitionConfig configures the maintenance of the monthly partitions of
+// the messages table.
+type PartitionConfig struct {
+	// MonthsAhead is how many months past the current one have a partition
+	// ready, inserts fail when no partition covers their created_at
+	MonthsAhead int
+
+	// RetentionMonths is how many months before the current one are kept,
+	// older partitions are detached and dropped. 0 keeps them all
+	RetentionMonths int
+
+	// Interval is the time between two runs in seconds
+	Interval int
+}
@@ -0,0 +1,66 @@
+package entity
+
+import "fmt"
+
+// QueueBackend is where the fetchers claim the pending messages from.
+type QueueBackend string
+
+const (
+	// QueuePostgres claims the oldest pending messages straight from the
+	// messages table on every fetch cycle
+	QueuePostgres QueueBackend = "postgres"
+	// QueueRedis hands the ids of the due messages out through a Redis
+	// stream consumer group, Postgres only claims the ids it is given
+	QueueRedis QueueBackend = "redis"
+)
+
+// QueueConfig configures the message queue.
+type QueueConfig struct {
+	Backend QueueBackend
+
+	// Stream and Group are the Redis stream and its consumer group,
+	// Consumer names this replica in the group
+	Stream   string
+	Group    string
+	Consumer string
+
+	// ReclaimIdle is the time in seconds an entry read by a consumer may
+	// stay unacked before another one reclaims it
+	ReclaimIdle int
+
+	// SweepInterval is the time in seconds between two sweeps enqueueing
+	// the due messages that aren't in the stream, SweepBatch the most
+	// messages a sweep enqueues
+	SweepInterval int
+	SweepBatch    int
+}
+
+// Validate reports settings the queue can't work with along with the
+// service config. Ordered delivery needs the Postgres queue, a stream
+// doesn't keep the messages of a sequence key in order. A message still
+// being sent must not be reclaimed, so the send deadline has to be shorter
+// than ReclaimIdle.
+func (qc QueueConfig) Validate(service ServiceConfig) error {
+	switch qc.Backend {
+	case QueuePostgres:
+		return nil
+	case QueueRedis:
+	default:
+		return fmt.Errorf("%w: queue backend must be %s or %s", ErrValidation, QueuePostgres, QueueRedis)
+	}
+
+	switch {
+	case service.OrderedDelivery:
+		return fmt.Errorf("%w: ordered delivery needs the %s queue backend", ErrValidation, QueuePostgres)
+	case qc.Stream == "" || qc.Group == "" || qc.Consumer == "":
+		return fmt.Errorf("%w: queue stream, group and consumer must be set", ErrValidation)
+	case qc.ReclaimIdle < 1:
+		return fmt.Errorf("%w: queue reclaim idle must be at least 1", ErrValidation)
+	case service.JobTimeout >= qc.ReclaimIdle:
+		return fmt.Errorf("%w: send timeout must be below the queue reclaim idle", ErrValidation)
+	case qc.SweepInterval < 1 || qc.SweepBatch < 1:
+		return fmt.Errorf("%w: queue sweep interval and batch must be at least 1", ErrValidation)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// MessageCategory tells what a message is for, quiet hours are set per category.
+type MessageCategory string
+
+const (
+	CategoryTransactional MessageCategory = "transactional"
+	CategoryMarketing     MessageCategory = "marketing"
+)
+
+// QuietHours is a daily window, in the recipient's local time, during which
+// messages of Category aren't sent for TenantID. StartTime and EndTime are
+// HH:MM, a window with StartTime after EndTime spans midnight.
+type QuietHours struct {
+	ID        uint64          `json:"id"         gorm:"primaryKey;column:id"`
+	TenantID  string          `json:"tenant_id"  gorm:"column:tenant_id;type:varchar(64);not null;default:default"`
+	Category  MessageCategory `json:"category"   gorm:"column:category;type:varchar(20);not null;default:marketing"`
+	StartTime string          `json:"start_time" gorm:"column:start_time;type:varchar(5);not null"`
+	EndTime   string          `json:"end_time"   gorm:"column:end_time;type:varchar(5);not null"`
+	CreatedAt time.Time       `json:"created_at" gorm:"column:created_at;type:timestamptz;default:CURRENT_TIMESTAMP"`
+	UpdatedAt *time.Time      `json:"updated_at" gorm:"column:updated_at;type:timestamptz"`
+}
+
+func (QuietHours) TableName() string {
+	return "quiet_hours"
+}
+
+// Validate checks that both bounds are HH:MM and the window isn't empty.
+func (q QuietHours) Validate() error {
+	start, err := minuteOfDay(q.StartTime)
+	if err != nil {
+		return err
+	}
+
+	end, err := minuteOfDay(q.EndTime)
+	if err != nil {
+		return err
+	}
+
+	if start == end {
+		return fmt.Errorf("%w: quiet hours start and end time must differ", ErrValidation)
+	}
+
+	return nil
+}
+
+// Contains reports whether t, in the recipient's location, is inside the window.
+func (q QuietHours) Contains(t time.Time) bool {
+	start, errStart := minuteOfDay(q.StartTime)
+	end, errEnd := minuteOfDay(q.EndTime)
+	if errStart != nil || errEnd != nil {
+		return false
+	}
+
+	now := t.Hour()*60 + t.Minute()
+	if start < end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+// NextAllowed returns the first time after t, in t's location, at which
+// the window is over. On the days the clocks change, that is when they go
+// forward past the end, or the first of the two times they read it when
+// they go back over it, the second one for a t in the repeated hour.
+func (q QuietHours) NextAllowed(t time.Time) time.Time {
+	end, err := minuteOfDay(q.EndTime)
+	if err != nil {
+		return t
+	}
+
+	candidates := append(wallClock(t, 0, end), wallClock(t, 1, end)...)
+	for _, next := range candidates {
+		if next.After(t) {
+			return next
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// wallClock returns the times of the day days after t's at which the wall
+// clock of t's location reads minute of day, in order. It reads it twice
+// when clocks go back over it, and never when they go forward over it, the
+// time they do is returned instead.
+func wallClock(t time.Time, days int, minute int) []time.Time {
+	at := time.Date(t.Year(), t.Month(), t.Day()+days, minute/60, minute%60, 0, 0, t.Location())
+
+	start, _ := at.ZoneBounds()
+	if start.IsZero() {
+		return []time.Time{at}
+	}
+	_, offset := at.Zone()
+	_, previous := start.Add(-time.Second).Zone()
+
+	// time.Date moves a skipped wall clock past the jump and takes the
+	// second pass of a repeated one
+	switch shift := time.Duration(offset-previous) * time.Second; {
+	case at.Hour()*60+at.Minute() != minute:
+		return []time.Time{start}
+	case shift < 0 && at.Add(shift).Before(start):
+		return []time.Time{at.Add(shift), at}
+	default:
+		return []time.Time{at}
+	}
+}
+
+func minuteOfDay(clock string) (int, error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not a HH:MM time", ErrValidation, clock)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
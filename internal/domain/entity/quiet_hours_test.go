@@ -0,0 +1,155 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuietHoursContains(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		window QuietHours
+		at     time.Time
+		want   bool
+	}{
+		{"DayBeforeStart", QuietHours{StartTime: "09:00", EndTime: "17:00"}, clock(8, 59), false},
+		{"DayStart", QuietHours{StartTime: "09:00", EndTime: "17:00"}, clock(9, 0), true},
+		{"DayBeforeEnd", QuietHours{StartTime: "09:00", EndTime: "17:00"}, clock(16, 59), true},
+		{"DayEnd", QuietHours{StartTime: "09:00", EndTime: "17:00"}, clock(17, 0), false},
+		{"WrapBeforeStart", QuietHours{StartTime: "22:00", EndTime: "08:00"}, clock(21, 59), false},
+		{"WrapStart", QuietHours{StartTime: "22:00", EndTime: "08:00"}, clock(22, 0), true},
+		{"WrapMidnight", QuietHours{StartTime: "22:00", EndTime: "08:00"}, clock(0, 0), true},
+		{"WrapBeforeEnd", QuietHours{StartTime: "22:00", EndTime: "08:00"}, clock(7, 59), true},
+		{"WrapEnd", QuietHours{StartTime: "22:00", EndTime: "08:00"}, clock(8, 0), false},
+		{"WrapMidday", QuietHours{StartTime: "22:00", EndTime: "08:00"}, clock(12, 0), false},
+		{"InvalidWindow", QuietHours{StartTime: "25:00", EndTime: "08:00"}, clock(23, 0), false},
+		{
+			// The wall clock counts, the hour repeated when clocks go back
+			// is inside the window on both passes
+			name:   "RepeatedHourSecondPass",
+			window: QuietHours{StartTime: "22:00", EndTime: "02:30"},
+			at:     time.Date(2026, time.October, 25, 1, 10, 0, 0, time.UTC).In(berlin),
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.window.Contains(tt.at))
+		})
+	}
+}
+
+func TestQuietHoursNextAllowed(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	night := QuietHours{StartTime: "22:00", EndTime: "08:00"}
+	tests := []struct {
+		name   string
+		window QuietHours
+		at     time.Time
+		want   time.Time
+	}{
+		{
+			name:   "BeforeMidnight",
+			window: night,
+			at:     time.Date(2026, time.May, 4, 23, 0, 0, 0, time.UTC),
+			want:   time.Date(2026, time.May, 5, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "AfterMidnight",
+			window: night,
+			at:     time.Date(2026, time.May, 5, 3, 15, 30, 0, time.UTC),
+			want:   time.Date(2026, time.May, 5, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "NewYear",
+			window: night,
+			at:     time.Date(2026, time.December, 31, 22, 30, 0, 0, time.UTC),
+			want:   time.Date(2027, time.January, 1, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "DayWindow",
+			window: QuietHours{StartTime: "12:00", EndTime: "14:00"},
+			at:     time.Date(2026, time.May, 5, 13, 0, 0, 0, time.UTC),
+			want:   time.Date(2026, time.May, 5, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "AtEnd",
+			window: night,
+			at:     time.Date(2026, time.May, 5, 8, 0, 0, 0, time.UTC),
+			want:   time.Date(2026, time.May, 6, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "InLocation",
+			window: night,
+			at:     time.Date(2026, time.May, 4, 23, 0, 0, 0, berlin),
+			want:   time.Date(2026, time.May, 5, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			// A 7 hours night
+			name:   "ClocksForwardDuringWindow",
+			window: night,
+			at:     time.Date(2026, time.March, 28, 23, 0, 0, 0, berlin),
+			want:   time.Date(2026, time.March, 29, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			// A 9 hours night
+			name:   "ClocksBackDuringWindow",
+			window: night,
+			at:     time.Date(2026, time.October, 24, 23, 0, 0, 0, berlin),
+			want:   time.Date(2026, time.October, 25, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			// 02:30 never comes, the window is over when 02:00 becomes 03:00
+			name:   "EndSkipped",
+			window: QuietHours{StartTime: "22:00", EndTime: "02:30"},
+			at:     time.Date(2026, time.March, 28, 23, 0, 0, 0, berlin),
+			want:   time.Date(2026, time.March, 29, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "EndRepeatedFirstPass",
+			window: QuietHours{StartTime: "22:00", EndTime: "02:30"},
+			at:     time.Date(2026, time.October, 24, 23, 0, 0, 0, berlin),
+			want:   time.Date(2026, time.October, 25, 0, 30, 0, 0, time.UTC),
+		},
+		{
+			name:   "EndRepeatedSecondPass",
+			window: QuietHours{StartTime: "22:00", EndTime: "02:30"},
+			at:     time.Date(2026, time.October, 25, 1, 10, 0, 0, time.UTC).In(berlin),
+			want:   time.Date(2026, time.October, 25, 1, 30, 0, 0, time.UTC),
+		},
+		{
+			name:   "InvalidEnd",
+			window: QuietHours{StartTime: "22:00", EndTime: "8am"},
+			at:     time.Date(2026, time.May, 4, 23, 0, 0, 0, time.UTC),
+			want:   time.Date(2026, time.May, 4, 23, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.window.NextAllowed(tt.at)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want.In(tt.at.Location()), got)
+			assert.Equal(t, tt.at.Location(), got.Location())
+
+			// Over right then, not a minute before
+			if tt.window.Validate() == nil {
+				assert.False(t, tt.window.Contains(got), "still quiet at %s", got)
+				assert.True(t, tt.window.Contains(got.Add(-time.Minute)), "already over at %s", got.Add(-time.Minute))
+			}
+		})
+	}
+}
+
+// clock returns a UTC time of day.
+func clock(hour int, minute int) time.Time {
+	return time.Date(2026, time.May, 5, hour, minute, 0, 0, time.UTC)
+}
@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetentionMode is what happens to the messages past their retention.
+type RetentionMode string
+
+const (
+	// RetentionDelete removes them for good
+	RetentionDelete RetentionMode = "delete"
+	// RetentionArchive moves them to the messages_archive table
+	RetentionArchive RetentionMode = "archive"
+	// RetentionFile writes them to gzip compressed NDJSON files before removing them
+	RetentionFile RetentionMode = "file"
+)
+
+// RetentionRule keeps the messages in Status for Days after their creation.
+type RetentionRule struct {
+	Status MessageStatus
+	Days   int
+}
+
+// ParseRetentionPolicy reads a policy such as "sent=90,failed=180", only
+// final statuses can be pruned.
+func ParseRetentionPolicy(policy string) ([]RetentionRule, error) {
+	var rules []RetentionRule
+	for _, entry := range strings.Split(policy, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		status, days, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: retention rule %q isn't status=days", ErrValidation, entry)
+		}
+
+		rule := RetentionRule{Status: MessageStatus(strings.TrimSpace(status))}
+		if !slices.Contains(FinalStatuses, rule.Status) {
+			return nil, fmt.Errorf("%w: messages in status %q can't be pruned", ErrValidation, rule.Status)
+		}
+
+		var err error
+		if rule.Days, err = strconv.Atoi(strings.TrimSpace(days)); err != nil || rule.Days <= 0 {
+			return nil, fmt.Errorf("%w: retention of %q must be a positive number of days", ErrValidation, rule.Status)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// RetentionConfig configures the pruning of old messages.
+type RetentionConfig struct {
+	// Rules are the retention per status, statuses without a rule are kept forever
+	Rules []RetentionRule
+
+	// Mode is what happens to the pruned messages
+	Mode RetentionMode
+
+	// Interval is the time between two runs in seconds
+	Interval int
+
+	// Batch is the most messages pruned per statement, small batches keep
+	// locks and index churn short
+	Batch int
+
+	// ArchiveDir is where the files are written in file mode
+	ArchiveDir string
+}
+
+// RetentionReport is the outcome of one run of the retention job.
+type RetentionReport struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Mode       RetentionMode
+	Pruned     map[MessageStatus]int64
+	Files      []string
+	Error      string
+}
+
+// Total returns the number of messages pruned over every status.
+func (r RetentionReport) Total() int64 {
+	var total int64
+	for _, pruned := range r.Pruned {
+		total += pruned
+	}
+	return total
+}
+
+// Valid tells whether the mode is a known one.
+func (m RetentionMode) Valid() bool {
+	return m == RetentionDelete || m == RetentionArchive || m == RetentionFile
+}
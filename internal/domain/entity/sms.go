@@ -0,0 +1,9 @@
+package entity
+
+// SMSEncoding is the character encoding a message is sent with.
+type SMSEncoding string
+
+const (
+	EncodingGSM7 SMSEncoding = "GSM-7"
+	EncodingUCS2 SMSEncoding = "UCS-2"
+)
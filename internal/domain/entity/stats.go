@@ -0,0 +1,52 @@
+package entity
+
+import "time"
+
+// StatsFilter restricts the statistics to a time range, [From, To), and
+// to one tenant when TenantID is set.
+type StatsFilter struct {
+	TenantID string
+	From     time.Time
+	To       time.Time
+}
+
+// StatsGranularity is the width of a throughput bucket.
+type StatsGranularity string
+
+const (
+	GranularityMinute StatsGranularity = "minute"
+	GranularityHour   StatsGranularity = "hour"
+)
+
+// ThroughputBucket is the number of messages sent during the bucket
+// starting at Start, buckets without any message are left out.
+type ThroughputBucket struct {
+	Start time.Time
+	Sent  int64
+}
+
+// LatencyStats are percentiles of the time from creation to sending in
+// seconds, over Samples sent messages.
+type LatencyStats struct {
+	Samples int64
+	P50     float64
+	P95     float64
+}
+
+// FailureReason counts the failed messages whose error starts with Reason.
+type FailureReason struct {
+	Reason string
+	Count  int64
+}
+
+// MessageStats is the breakdown of the messages matching Filter. Counts
+// and failure reasons are over the messages created in the range,
+// throughput and latency over the messages sent in it.
+type MessageStats struct {
+	Filter            StatsFilter
+	Counts            map[MessageStatus]int64
+	ThroughputPerMin  []ThroughputBucket
+	ThroughputPerHour []ThroughputBucket
+	Latency           LatencyStats
+	FailureReasons    []FailureReason
+}
@@ -0,0 +1,35 @@
+package entity
+
+import "time"
+
+// SuppressionSource tells how a recipient ended up on the suppression list.
+type SuppressionSource string
+
+const (
+	SuppressionSourceAdmin   SuppressionSource = "admin"
+	SuppressionSourceKeyword SuppressionSource = "keyword"
+)
+
+// Suppression is an opted-out recipient, nothing is sent to
+// PhoneNumber on Channel for TenantID while it exists.
+type Suppression struct {
+	ID          uint64            `json:"id"           gorm:"primaryKey;column:id"`
+	PhoneNumber string            `json:"phone_number" gorm:"column:phone_number;type:text;not null;serializer:encrypted"`
+	Channel     string            `json:"channel"      gorm:"column:channel;type:varchar(20);not null;default:sms"`
+	TenantID    string            `json:"tenant_id"    gorm:"column:tenant_id;type:varchar(64);not null;default:default"`
+	Reason      *string           `json:"reason"       gorm:"column:reason;type:text"`
+	Source      SuppressionSource `json:"source"       gorm:"column:source;type:varchar(20);not null;default:admin"`
+	CreatedAt   time.Time         `json:"created_at"   gorm:"column:created_at;type:timestamptz;default:CURRENT_TIMESTAMP"`
+
+	// PhoneNumberHash is the keyed hash phone numbers are looked up by
+	// once they are encrypted, nil when encryption is disabled
+	PhoneNumberHash *string `json:"-" gorm:"column:phone_number_hash;type:char(64)"`
+}
+
+// InboundMessage is a message received from a recipient through the provider.
+type InboundMessage struct {
+	From     string
+	Content  string
+	Channel  string
+	TenantID string
+}
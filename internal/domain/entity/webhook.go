@@ -0,0 +1,122 @@
+package entity
+
+import (
+	"slices"
+	"time"
+)
+
+// EventType is the kind of message lifecycle event sent to webhooks.
+type EventType string
+
+const (
+	EventMessageSent       EventType = "message.sent"
+	EventMessageFailed     EventType = "message.failed"
+	EventMessageDelivered  EventType = "message.delivered"
+	EventMessageSuppressed EventType = "message.suppressed"
+)
+
+// Events only pushed to the live stream, webhooks don't receive them.
+const (
+	EventMessageCreated    EventType = "message.created"
+	EventMessageProcessing EventType = "message.processing"
+	EventServiceStarted    EventType = "service.started"
+	EventServiceStopped    EventType = "service.stopped"
+	// EventProviderAuthFailed alerts that the notification provider
+	// refuses our credentials
+	EventProviderAuthFailed EventType = "provider.auth_failed"
+)
+
+// EventTypes lists every event a subscription can receive.
+var EventTypes = []EventType{EventMessageSent, EventMessageFailed, EventMessageDelivered, EventMessageSuppressed}
+
+// Subscribable tells whether webhooks can receive events of this type.
+func (t EventType) Subscribable() bool {
+	return slices.Contains(EventTypes, t)
+}
+
+// Event is a change of a message status, fanned out to the webhook
+// subscriptions of the message tenant and to the live stream. Service
+// events have an empty Message.
+type Event struct {
+	ID         string
+	Type       EventType
+	OccurredAt time.Time
+	Message    Message
+
+	// Final is set on the event of the status processing the message ended
+	// with, sent, suppressed or failed. It is the one posted to the callback
+	// url of the message, delivery reports only come after it
+	Final bool
+}
+
+// WebhookSubscription receives the events of EventTypes of its tenant on URL,
+// every request is signed with Secret.
+type WebhookSubscription struct {
+	ID         uint64      `json:"id"          gorm:"primaryKey;column:id"`
+	TenantID   string      `json:"tenant_id"   gorm:"column:tenant_id;type:varchar(64);not null;default:default"`
+	URL        string      `json:"url"         gorm:"column:url;type:text;not null"`
+	Secret     string      `json:"-"           gorm:"column:secret;type:varchar(255);not null"`
+	EventTypes []EventType `json:"event_types" gorm:"column:event_types;type:jsonb;serializer:json;not null"`
+	CreatedAt  time.Time   `json:"created_at"  gorm:"column:created_at;type:timestamptz;default:CURRENT_TIMESTAMP"`
+}
+
+// WebhookDeliveryStatus represents the state of one event delivery.
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending    WebhookDeliveryStatus = "pending"
+	DeliveryDelivering WebhookDeliveryStatus = "delivering"
+	DeliverySucceeded  WebhookDeliveryStatus = "succeeded"
+	DeliveryFailed     WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event queued for one subscription, or for the
+// callback url of a message when SubscriptionID is nil. It is retried with
+// backoff until the endpoint answers with a 2xx or attempts run out.
+// Payload holds the message and is encrypted like it.
+type WebhookDelivery struct {
+	ID             uint64                `json:"id"               gorm:"primaryKey;column:id"`
+	SubscriptionID *uint64               `json:"subscription_id"  gorm:"column:subscription_id"`
+	URL            string                `json:"url"              gorm:"column:url;type:text;not null"`
+	EventID        string                `json:"event_id"         gorm:"column:event_id;type:varchar(36);not null"`
+	EventType      EventType             `json:"event_type"       gorm:"column:event_type;type:varchar(64);not null"`
+	Payload        string                `json:"payload"          gorm:"column:payload;type:text;not null;serializer:encrypted"`
+	Status         WebhookDeliveryStatus `json:"status"           gorm:"column:status;type:varchar(20);not null;default:pending"`
+	Attempts       int                   `json:"attempts"         gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"  gorm:"column:next_attempt_at;type:timestamptz;default:CURRENT_TIMESTAMP"`
+	LastStatusCode *int                  `json:"last_status_code" gorm:"column:last_status_code"`
+	LastError      *string               `json:"last_error"       gorm:"column:last_error;type:text"`
+	CreatedAt      time.Time             `json:"created_at"       gorm:"column:created_at;type:timestamptz;default:CURRENT_TIMESTAMP"`
+	UpdatedAt      *time.Time            `json:"updated_at"       gorm:"column:updated_at;type:timestamptz"`
+	DeliveredAt    *time.Time            `json:"delivered_at"     gorm:"column:delivered_at;type:timestamptz"`
+}
+
+// WebhookConfig configures the delivery of events to webhook subscriptions.
+type WebhookConfig struct {
+	// DispatchInterval is the time between two polls of the delivery queue in seconds
+	DispatchInterval int
+
+	// DispatchBatch is the most deliveries sent per poll
+	DispatchBatch int
+
+	// MaxAttempts is the number of attempts before a delivery is given up
+	MaxAttempts int
+
+	// RetryBaseDelay is the delay before the first retry in seconds, doubled on every attempt
+	RetryBaseDelay int
+
+	// RetryMaxDelay caps the delay between two attempts in seconds
+	RetryMaxDelay int
+
+	// Lease is how long a delivery stays claimed in seconds, after that it
+	// is picked up again in case the instance sending it died
+	Lease int
+
+	// CallbackSecret signs the deliveries to message callback urls
+	CallbackSecret string
+}
+
+// RetryDelay returns the backoff before the attempt following attempt.
+func (c WebhookConfig) RetryDelay(attempt int) time.Duration {
+	return backoff(c.RetryBaseDelay, c.RetryMaxDelay, attempt)
+}
@@ -3,12 +3,69 @@ package interfaces
 import "net/http"
 
 type MessageController interface {
+	Create(w http.ResponseWriter, r *http.Request)
 	Start(w http.ResponseWriter, r *http.Request)
 	Stop(w http.ResponseWriter, r *http.Request)
 	Status(w http.ResponseWriter, r *http.Request)
+	GetConfig(w http.ResponseWriter, r *http.Request)
+	UpdateConfig(w http.ResponseWriter, r *http.Request)
 	GetSentMessagesWithPagination(w http.ResponseWriter, r *http.Request)
+	Requeue(w http.ResponseWriter, r *http.Request)
+	Export(w http.ResponseWriter, r *http.Request)
+}
+
+type SuppressionController interface {
+	List(w http.ResponseWriter, r *http.Request)
+	Create(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+}
+
+type QuietHoursController interface {
+	List(w http.ResponseWriter, r *http.Request)
+	Set(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+}
+
+type CampaignController interface {
+	List(w http.ResponseWriter, r *http.Request)
+	Create(w http.ResponseWriter, r *http.Request)
+	Get(w http.ResponseWriter, r *http.Request)
+	AddRecipients(w http.ResponseWriter, r *http.Request)
+	AddSegment(w http.ResponseWriter, r *http.Request)
+	Start(w http.ResponseWriter, r *http.Request)
+	Pause(w http.ResponseWriter, r *http.Request)
+	Cancel(w http.ResponseWriter, r *http.Request)
+}
+
+type WebhookController interface {
+	List(w http.ResponseWriter, r *http.Request)
+	Create(w http.ResponseWriter, r *http.Request)
+	Get(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	ListDeliveries(w http.ResponseWriter, r *http.Request)
+}
+
+type RetentionController interface {
+	Get(w http.ResponseWriter, r *http.Request)
+}
+
+type StatsController interface {
+	Get(w http.ResponseWriter, r *http.Request)
+}
+
+type EventStreamController interface {
+	Stream(w http.ResponseWriter, r *http.Request)
+}
+
+type InboundController interface {
+	Receive(w http.ResponseWriter, r *http.Request)
+	DeliveryReport(w http.ResponseWriter, r *http.Request)
 }
 
 type HealthController interface {
 	HealthCheck(w http.ResponseWriter, r *http.Request)
 }
+
+type AuditController interface {
+	List(w http.ResponseWriter, r *http.Request)
+}
@@ -8,10 +8,71 @@ import (
 )
 
 type MessageRepository interface {
+	Create(c context.Context, message *entity.Message) error
 	Update(c context.Context, id uint64, message entity.Message) error
 	UpdateSelective(ctx context.Context, id uint64, updates map[string]any) error
+	UpdateSentByMessageID(c context.Context, messageID string, updates map[string]any) (entity.Message, error)
 	GetPending(c context.Context, batch int) ([]entity.Message, error)
-	GetSentWithPagination(c context.Context, page int) ([]entity.Message, error)
+	GetPendingOrdered(c context.Context, batch int) ([]entity.Message, error)
+	ClaimByIDs(c context.Context, ids []uint64) ([]entity.Message, error)
+	ReclaimByIDs(c context.Context, ids []uint64, stuckBefore time.Time) ([]entity.Message, error)
+	ListDueIDs(c context.Context, afterID uint64, limit int) ([]uint64, error)
+	Release(c context.Context, ids []uint64) (int64, error)
+	Requeue(c context.Context, id uint64) (entity.Message, error)
+	ResetStuck(c context.Context, before time.Time) ([]uint64, error)
+	CountPending(c context.Context) (int64, error)
+	GetSentWithPagination(c context.Context, filter entity.MessageFilter, page int) ([]entity.Message, error)
+	Export(c context.Context, filter entity.MessageFilter, fn func(entity.Message) error) error
+}
+
+type SuppressionRepository interface {
+	Create(c context.Context, suppression *entity.Suppression) error
+	Delete(c context.Context, id uint64) error
+	List(c context.Context, tenantID string, page int) ([]entity.Suppression, error)
+	IsSuppressed(c context.Context, phoneNumber string, channel string, tenantID string) (bool, error)
+}
+
+type QuietHoursRepository interface {
+	Upsert(c context.Context, quietHours *entity.QuietHours) error
+	Delete(c context.Context, id uint64) error
+	List(c context.Context, tenantID string) ([]entity.QuietHours, error)
+}
+
+type CampaignRepository interface {
+	Create(c context.Context, campaign *entity.Campaign) error
+	Get(c context.Context, id uint64) (entity.Campaign, error)
+	List(c context.Context, tenantID string, page int) ([]entity.Campaign, error)
+	ListDue(c context.Context) ([]entity.Campaign, error)
+	UpdateStatus(c context.Context, id uint64, from entity.CampaignStatus, to entity.CampaignStatus) error
+	AddRecipients(c context.Context, recipients []entity.CampaignRecipient) (int64, error)
+	AddSegment(c context.Context, campaign entity.Campaign, segment entity.CampaignSegment) (int64, error)
+	ExpandRecipients(c context.Context, campaign entity.Campaign, chunk int) (int, error)
+	CancelPendingMessages(c context.Context, id uint64) (int64, error)
+	Complete(c context.Context, id uint64) (bool, error)
+	Stats(c context.Context, id uint64) (entity.CampaignStats, error)
+}
+
+type WebhookRepository interface {
+	CreateSubscription(c context.Context, subscription *entity.WebhookSubscription) error
+	GetSubscription(c context.Context, id uint64) (entity.WebhookSubscription, error)
+	ListSubscriptions(c context.Context, tenantID string) ([]entity.WebhookSubscription, error)
+	ListSubscribers(c context.Context, tenantID string, eventType entity.EventType) ([]entity.WebhookSubscription, error)
+	DeleteSubscription(c context.Context, id uint64) error
+	CreateDeliveries(c context.Context, deliveries []entity.WebhookDelivery) error
+	ClaimDeliveries(c context.Context, batch int, leaseSeconds int) ([]entity.WebhookDelivery, error)
+	UpdateDelivery(c context.Context, id uint64, updates map[string]any) error
+	ListDeliveries(c context.Context, subscriptionID uint64, page int) ([]entity.WebhookDelivery, error)
+}
+
+type StatsRepository interface {
+	CountByStatus(c context.Context, filter entity.StatsFilter) (map[entity.MessageStatus]int64, error)
+	Throughput(
+		c context.Context,
+		filter entity.StatsFilter,
+		granularity entity.StatsGranularity,
+	) ([]entity.ThroughputBucket, error)
+	Latency(c context.Context, filter entity.StatsFilter) (entity.LatencyStats, error)
+	FailureReasons(c context.Context, filter entity.StatsFilter) ([]entity.FailureReason, error)
 }
 
 type CacheRepository interface {
@@ -19,6 +80,67 @@ type CacheRepository interface {
 	Get(key string) ([]byte, error)
 }
 
+type WebhookSender interface {
+	Send(c context.Context, delivery entity.WebhookDelivery, secret string) (int, error)
+}
+
+type RetentionRepository interface {
+	DeleteExpired(c context.Context, status entity.MessageStatus, before time.Time, batch int) (int64, error)
+	ArchiveExpired(c context.Context, status entity.MessageStatus, before time.Time, batch int) (int64, error)
+	TakeExpired(
+		c context.Context,
+		status entity.MessageStatus,
+		before time.Time,
+		batch int,
+		fn func([]entity.Message) error,
+	) (int64, error)
+}
+
+type AuditRepository interface {
+	Create(c context.Context, record *entity.AuditRecord) error
+	List(c context.Context, filter entity.AuditFilter, page int) ([]entity.AuditRecord, error)
+	Latest(c context.Context, action entity.AuditAction) (entity.AuditRecord, error)
+}
+
+type EncryptionRepository interface {
+	ReencryptMessages(c context.Context, after uint64, batch int) (uint64, int, error)
+	ReencryptSuppressions(c context.Context, after uint64, batch int) (uint64, int, error)
+	ReencryptCampaignRecipients(c context.Context, after uint64, batch int) (uint64, int, error)
+	ReencryptWebhookDeliveries(c context.Context, after uint64, batch int) (uint64, int, error)
+}
+
+type PartitionRepository interface {
+	CreatePartitions(c context.Context, monthsAhead int) ([]string, error)
+	DropPartitions(c context.Context, keepMonths int) ([]string, error)
+}
+
+type EventStream interface {
+	Publish(c context.Context, payload []byte) error
+	Subscribe(c context.Context) (<-chan []byte, error)
+}
+
+// MessageQueue hands the pending messages out to the fetchers of every
+// replica, Postgres stays the source of truth of their status.
+type MessageQueue interface {
+	// Enqueue offers messages that are due to the fetchers
+	Enqueue(c context.Context, ids ...uint64) error
+	// Claim moves up to batch due messages to processing and returns them
+	Claim(c context.Context, batch int) ([]entity.Message, error)
+	// Ack releases the claimed messages from the queue, once their status
+	// has been updated
+	Ack(c context.Context, ids ...uint64) error
+}
+
 type NotificationService interface {
 	SendNotification(c context.Context, message entity.Message) (string, error)
 }
+
+type CircuitBreaker interface {
+	Stats(c context.Context) entity.CircuitBreakerStats
+}
+
+// NotificationCircuitBreaker is a NotificationService guarded by a circuit breaker.
+type NotificationCircuitBreaker interface {
+	NotificationService
+	CircuitBreaker
+}
@@ -7,8 +7,85 @@ import (
 )
 
 type MessageUsecase interface {
+	CreateMessage(c context.Context, message entity.Message) (entity.Message, error)
 	StartAutomatedSending(c context.Context) error
 	StopAutomatedSending(c context.Context) error
 	GetAutomatedSendingStatus(c context.Context) (bool, error)
-	GetSentMessagesWithPagination(c context.Context, page int) ([]entity.Message, error)
+	GetWorkerPoolStats(c context.Context) (entity.WorkerPoolStats, error)
+	GetCircuitBreakerStats(c context.Context) (entity.CircuitBreakerStats, error)
+	GetServiceConfig(c context.Context) (entity.ServiceConfig, error)
+	UpdateServiceConfig(c context.Context, config entity.ServiceConfig) (entity.ServiceConfig, error)
+	GetSentMessagesWithPagination(c context.Context, filter entity.MessageFilter, page int) ([]entity.Message, error)
+	ExportMessages(c context.Context, filter entity.MessageFilter, fn func(entity.Message) error) error
+	HandleDeliveryReport(c context.Context, report entity.DeliveryReport) error
+	RequeueMessage(c context.Context, id uint64) (entity.Message, error)
+}
+
+type SuppressionUsecase interface {
+	AddSuppression(c context.Context, suppression entity.Suppression) (entity.Suppression, error)
+	RemoveSuppression(c context.Context, id uint64) error
+	ListSuppressions(c context.Context, tenantID string, page int) ([]entity.Suppression, error)
+	HandleInboundMessage(c context.Context, inbound entity.InboundMessage) error
+}
+
+type QuietHoursUsecase interface {
+	SetQuietHours(c context.Context, quietHours entity.QuietHours) (entity.QuietHours, error)
+	RemoveQuietHours(c context.Context, id uint64) error
+	ListQuietHours(c context.Context, tenantID string) ([]entity.QuietHours, error)
+}
+
+type RetentionUsecase interface {
+	GetRetention(c context.Context) (entity.RetentionConfig, *entity.RetentionReport, error)
+	StartJob(c context.Context)
+}
+
+type AuditUsecase interface {
+	Record(c context.Context, action entity.AuditAction, parameters map[string]any)
+	RecordAPIKeys(c context.Context, keys []entity.APIKey)
+	ListAuditRecords(c context.Context, filter entity.AuditFilter, page int) ([]entity.AuditRecord, error)
+}
+
+type EncryptionUsecase interface {
+	StartReencryption(c context.Context)
+}
+
+type PartitionUsecase interface {
+	StartMaintenance(c context.Context)
+}
+
+type StatsUsecase interface {
+	GetStats(c context.Context, filter entity.StatsFilter) (entity.MessageStats, error)
+}
+
+type CampaignUsecase interface {
+	CreateCampaign(c context.Context, campaign entity.Campaign) (entity.Campaign, error)
+	AddRecipients(c context.Context, id uint64, phoneNumbers []string) (int64, []string, error)
+	AddSegment(c context.Context, id uint64, segment entity.CampaignSegment) (int64, error)
+	GetCampaign(c context.Context, id uint64) (entity.Campaign, entity.CampaignStats, error)
+	ListCampaigns(c context.Context, tenantID string, page int) ([]entity.Campaign, error)
+	StartCampaign(c context.Context, id uint64) (entity.Campaign, error)
+	PauseCampaign(c context.Context, id uint64) (entity.Campaign, error)
+	CancelCampaign(c context.Context, id uint64) (entity.Campaign, error)
+	StartExpander(c context.Context)
+}
+
+// EventPublisher hands message lifecycle events over to their consumers.
+type EventPublisher interface {
+	Publish(c context.Context, event entity.Event) error
+}
+
+type WebhookUsecase interface {
+	EventPublisher
+	CreateSubscription(c context.Context, subscription entity.WebhookSubscription) (entity.WebhookSubscription, error)
+	GetSubscription(c context.Context, id uint64) (entity.WebhookSubscription, error)
+	ListSubscriptions(c context.Context, tenantID string) ([]entity.WebhookSubscription, error)
+	DeleteSubscription(c context.Context, id uint64) error
+	ListDeliveries(c context.Context, subscriptionID uint64, page int) ([]entity.WebhookDelivery, error)
+	StartDispatcher(c context.Context)
+}
+
+type EventStreamUsecase interface {
+	EventPublisher
+	Subscribe(c context.Context, filter entity.StreamFilter) (<-chan entity.StreamMessage, func())
+	Start(c context.Context) error
 }
@@ -0,0 +1,322 @@
+// Package fakeprovider simulates the notification provider for local runs,
+// integration and load tests. It implements the webhook contract, a POST of
+// {to, content} answered by {message, messageId}, with configurable
+// latency, failures and delivery reports, and records every request.
+package fakeprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Outcome is how the provider answered a request.
+type Outcome string
+
+const (
+	OutcomeAccepted         Outcome = "accepted"
+	OutcomeUnauthorized     Outcome = "unauthorized"
+	OutcomeServerError      Outcome = "server_error"
+	OutcomeRateLimited      Outcome = "rate_limited"
+	OutcomeInvalidRecipient Outcome = "invalid_recipient"
+	OutcomeMalformed        Outcome = "malformed"
+	OutcomeBadRequest       Outcome = "bad_request"
+)
+
+// Config configures the simulated provider. Rates are probabilities between
+// 0 and 1, drawn in the order of the fields, a request gets at most one
+// failure.
+type Config struct {
+	// Latency is added to every answer, plus a random part up to Jitter
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// ServerErrorRate answers 500
+	ServerErrorRate float64
+	// RateLimitRate answers 429 with a Retry-After of RetryAfter seconds
+	RateLimitRate float64
+	RetryAfter    int
+	// InvalidRecipientRate answers 400 with an invalid phone number message
+	InvalidRecipientRate float64
+	// MalformedRate answers 200 with a body that isn't JSON
+	MalformedRate float64
+
+	// AuthKey is the bearer token requests must carry, any is accepted
+	// when empty
+	AuthKey string
+
+	// Seed makes the outcomes, latencies and message ids of a sequence of
+	// requests reproducible
+	Seed uint64
+
+	// DLRURL receives a delivery report for every accepted message after
+	// DLRDelay, no reports are sent when empty. DLRKey is sent as the
+	// bearer token, the INBOUND_API_KEY of the service
+	DLRURL         string
+	DLRKey         string
+	DLRDelay       time.Duration
+	DLRFailureRate float64
+}
+
+// Request is a request received by the provider along with its answer.
+type Request struct {
+	ID         int       `json:"id"`
+	ReceivedAt time.Time `json:"received_at"`
+	To         string    `json:"to"`
+	Content    string    `json:"content"`
+	Outcome    Outcome   `json:"outcome"`
+	StatusCode int       `json:"status_code"`
+	MessageID  string    `json:"message_id,omitempty"`
+	// DLRStatus is the delivery report sent for the message, empty until
+	// it is sent. DLRError is why sending it failed
+	DLRStatus string `json:"dlr_status,omitempty"`
+	DLRError  string `json:"dlr_error,omitempty"`
+}
+
+// Provider is the http.Handler of the simulated provider:
+//   - POST / sends a message
+//   - GET /requests lists the received requests
+//   - DELETE /requests forgets them
+type Provider struct {
+	config Config
+	client *http.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	rand     *rand.Rand
+	ids      *rand.ChaCha8
+	requests []Request
+	// nextID is the id of the next request, firstID the one of requests[0]
+	nextID  int
+	firstID int
+}
+
+func New(config Config) *Provider {
+	var seed [32]byte
+	binary.LittleEndian.PutUint64(seed[:], config.Seed)
+	source := rand.NewChaCha8(seed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Provider{
+		config:  config,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		ctx:     ctx,
+		cancel:  cancel,
+		rand:    rand.New(source),
+		ids:     rand.NewChaCha8(seed),
+		nextID:  1,
+		firstID: 1,
+	}
+}
+
+func (p *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/requests" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, p.Requests())
+	case r.URL.Path == "/requests" && r.Method == http.MethodDelete:
+		p.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost:
+		p.send(w, r)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// Requests returns the requests received so far, in the order they came.
+func (p *Provider) Requests() []Request {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	requests := make([]Request, len(p.requests))
+	copy(requests, p.requests)
+	return requests
+}
+
+// Reset forgets the received requests, the random sequence goes on.
+func (p *Provider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests = nil
+	p.firstID = p.nextID
+}
+
+// Close stops the pending delivery reports and waits for the ones being sent.
+func (p *Provider) Close() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *Provider) send(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		To      string `json:"to"`
+		Content string `json:"content"`
+	}
+	decodeErr := json.NewDecoder(r.Body).Decode(&body)
+	authorized := p.config.AuthKey == "" ||
+		strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") == p.config.AuthKey
+
+	// Everything random is drawn at once so the sequence only depends on
+	// the order of the requests
+	p.mu.Lock()
+	delay := p.config.Latency
+	if p.config.Jitter > 0 {
+		delay += time.Duration(p.rand.Int64N(int64(p.config.Jitter)))
+	}
+	outcome := p.outcome(authorized, decodeErr == nil && body.To != "")
+	request := Request{
+		ID:         p.nextID,
+		ReceivedAt: time.Now(),
+		To:         body.To,
+		Content:    body.Content,
+		Outcome:    outcome,
+	}
+	var delivered bool
+	if outcome == OutcomeAccepted {
+		id, _ := uuid.NewRandomFromReader(p.ids)
+		request.MessageID = id.String()
+		delivered = p.rand.Float64() >= p.config.DLRFailureRate
+	}
+	p.requests = append(p.requests, request)
+	p.nextID++
+	p.mu.Unlock()
+
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+		return
+	}
+
+	statusCode := p.answer(w, outcome, request.MessageID)
+	p.update(request.ID, func(recorded *Request) { recorded.StatusCode = statusCode })
+
+	if outcome == OutcomeAccepted && p.config.DLRURL != "" {
+		p.wg.Add(1)
+		go p.sendDLR(request.ID, request.MessageID, delivered)
+	}
+}
+
+// outcome draws the answer to a request, must be called with p.mu held.
+func (p *Provider) outcome(authorized bool, valid bool) Outcome {
+	switch {
+	case !authorized:
+		return OutcomeUnauthorized
+	case !valid:
+		return OutcomeBadRequest
+	}
+
+	draw := p.rand.Float64()
+	for _, failure := range []struct {
+		rate    float64
+		outcome Outcome
+	}{
+		{p.config.ServerErrorRate, OutcomeServerError},
+		{p.config.RateLimitRate, OutcomeRateLimited},
+		{p.config.InvalidRecipientRate, OutcomeInvalidRecipient},
+		{p.config.MalformedRate, OutcomeMalformed},
+	} {
+		if draw < failure.rate {
+			return failure.outcome
+		}
+		draw -= failure.rate
+	}
+
+	return OutcomeAccepted
+}
+
+func (p *Provider) answer(w http.ResponseWriter, outcome Outcome, messageID string) int {
+	switch outcome {
+	case OutcomeUnauthorized:
+		return writeJSON(w, http.StatusUnauthorized, response{Message: "Unauthorized"})
+	case OutcomeBadRequest:
+		return writeJSON(w, http.StatusBadRequest, response{Message: "Invalid request body"})
+	case OutcomeServerError:
+		return writeJSON(w, http.StatusInternalServerError, response{Message: "Internal error"})
+	case OutcomeRateLimited:
+		w.Header().Set("Retry-After", strconv.Itoa(p.config.RetryAfter))
+		return writeJSON(w, http.StatusTooManyRequests, response{Message: "Too many requests"})
+	case OutcomeInvalidRecipient:
+		return writeJSON(w, http.StatusBadRequest, response{Message: "Invalid phone number"})
+	case OutcomeMalformed:
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"message": "Accep`))
+		return http.StatusOK
+	default:
+		return writeJSON(w, http.StatusAccepted, response{Message: "Accepted", MessageID: messageID})
+	}
+}
+
+// sendDLR posts the delivery report of an accepted message to DLRURL.
+func (p *Provider) sendDLR(requestID int, messageID string, delivered bool) {
+	defer p.wg.Done()
+
+	select {
+	case <-time.After(p.config.DLRDelay):
+	case <-p.ctx.Done():
+		return
+	}
+
+	report := map[string]any{"message_id": messageID, "status": "delivered"}
+	if !delivered {
+		report["status"] = "undelivered"
+		report["error"] = "absent subscriber"
+	}
+	body, _ := json.Marshal(report)
+
+	request, err := http.NewRequestWithContext(p.ctx, http.MethodPost, p.config.DLRURL, bytes.NewReader(body))
+	if err != nil {
+		p.update(requestID, func(recorded *Request) { recorded.DLRError = err.Error() })
+		return
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if p.config.DLRKey != "" {
+		request.Header.Set("Authorization", "Bearer "+p.config.DLRKey)
+	}
+
+	resp, err := p.client.Do(request)
+	if err != nil {
+		p.update(requestID, func(recorded *Request) { recorded.DLRError = err.Error() })
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		p.update(requestID, func(recorded *Request) { recorded.DLRError = "refused with status " + resp.Status })
+		return
+	}
+
+	p.update(requestID, func(recorded *Request) { recorded.DLRStatus = report["status"].(string) })
+}
+
+// update changes the recorded request id, unless it was reset since.
+func (p *Provider) update(id int, fn func(*Request)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if index := id - p.firstID; index >= 0 && index < len(p.requests) {
+		fn(&p.requests[index])
+	}
+}
+
+type response struct {
+	Message   string `json:"message"`
+	MessageID string `json:"messageId,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body any) int {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+	return statusCode
+}
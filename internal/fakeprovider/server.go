@@ -0,0 +1,25 @@
+package fakeprovider
+
+import "net/http/httptest"
+
+// Server is a Provider listening on a local port, for tests running in
+// the same process. Point WEBHOOK_URL at URL.
+type Server struct {
+	*Provider
+	*httptest.Server
+}
+
+// NewServer starts a simulated provider on a random local port.
+func NewServer(config Config) *Server {
+	provider := New(config)
+	return &Server{
+		Provider: provider,
+		Server:   httptest.NewServer(provider),
+	}
+}
+
+// Close shuts the server down, then stops the pending delivery reports.
+func (s *Server) Close() {
+	s.Server.Close()
+	s.Provider.Close()
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/constant"
+	"gorm.io/gorm"
+)
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) interfaces.AuditRepository {
+	return &auditRepository{
+		db: db,
+	}
+}
+
+// Create appends record to the audit log, which refuses any later change.
+func (r *auditRepository) Create(ctx context.Context, record *entity.AuditRecord) error {
+	if err := r.db.WithContext(ctx).Create(record).Error; err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+
+	return nil
+}
+
+// List returns a page of the audit records matching filter, newest first.
+func (r *auditRepository) List(ctx context.Context, filter entity.AuditFilter, page int) ([]entity.AuditRecord, error) {
+	if page <= 0 {
+		return nil, errors.New("page must be greater than 0")
+	}
+
+	query := r.db.WithContext(ctx)
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	var records []entity.AuditRecord
+
+	err := query.
+		Offset((page - 1) * constant.DefaultPageSize).
+		Limit(constant.DefaultPageSize).
+		Order("id DESC").
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit records: %w", err)
+	}
+
+	return records, nil
+}
+
+// Latest returns the last record of action.
+func (r *auditRepository) Latest(ctx context.Context, action entity.AuditAction) (entity.AuditRecord, error) {
+	var record entity.AuditRecord
+
+	err := r.db.WithContext(ctx).
+		Where("action = ?", action).
+		Order("id DESC").
+		First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.AuditRecord{}, fmt.Errorf("audit record %s: %w", action, entity.ErrNotFound)
+	}
+	if err != nil {
+		return entity.AuditRecord{}, fmt.Errorf("failed to load audit record %s: %w", action, err)
+	}
+
+	return record, nil
+}
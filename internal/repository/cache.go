@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"errors"
 	"time"
 
+	"github.com/craftaholic/insider/internal/domain/entity"
 	"github.com/craftaholic/insider/internal/domain/interfaces"
 	"github.com/go-redis/redis"
 )
@@ -17,8 +19,13 @@ func NewCacheRepository(redisClient *redis.Client) interfaces.CacheRepository {
 	}
 }
 
+// Get returns the value of key, entity.ErrNotFound when it isn't set.
 func (cr *CacheRepository) Get(key string) ([]byte, error) {
-	return cr.client.Get(key).Bytes()
+	value, err := cr.client.Get(key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, entity.ErrNotFound
+	}
+	return value, err
 }
 
 func (cr *CacheRepository) Set(key string, value []byte, ttl time.Duration) error {
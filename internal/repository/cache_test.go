@@ -0,0 +1,12 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/craftaholic/insider/internal/repository"
+	"github.com/craftaholic/insider/internal/repository/repotest"
+)
+
+func TestCacheRepository(t *testing.T) {
+	repotest.TestCacheRepository(t, repository.NewCacheRepository(testRedis(t)))
+}
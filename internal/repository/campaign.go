@@ -0,0 +1,340 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/constant"
+	"github.com/craftaholic/insider/internal/shared/crypto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type campaignRepository struct {
+	db      *gorm.DB
+	keyring *crypto.Keyring
+}
+
+func NewCampaignRepository(db *gorm.DB, keyring *crypto.Keyring) interfaces.CampaignRepository {
+	return &campaignRepository{
+		db:      db,
+		keyring: keyring,
+	}
+}
+
+func (r *campaignRepository) Create(ctx context.Context, campaign *entity.Campaign) error {
+	if err := r.db.WithContext(ctx).Create(campaign).Error; err != nil {
+		return fmt.Errorf("failed to create campaign: %w", err)
+	}
+
+	return nil
+}
+
+func (r *campaignRepository) Get(ctx context.Context, id uint64) (entity.Campaign, error) {
+	var campaign entity.Campaign
+
+	err := r.db.WithContext(ctx).First(&campaign, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.Campaign{}, fmt.Errorf("campaign with id %d: %w", id, entity.ErrNotFound)
+	}
+	if err != nil {
+		return entity.Campaign{}, fmt.Errorf("failed to get campaign with id %d: %w", id, err)
+	}
+
+	return campaign, nil
+}
+
+func (r *campaignRepository) List(ctx context.Context, tenantID string, page int) ([]entity.Campaign, error) {
+	if page <= 0 {
+		return nil, errors.New("page must be greater than 0")
+	}
+
+	offset := (page - 1) * constant.DefaultPageSize
+
+	query := r.db.WithContext(ctx)
+	if tenantID != "" {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+
+	var campaigns []entity.Campaign
+
+	err := query.
+		Offset(offset).
+		Limit(constant.DefaultPageSize).
+		Order("created_at DESC").
+		Find(&campaigns).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return campaigns, nil
+}
+
+// ListDue returns the running campaigns whose schedule has come.
+func (r *campaignRepository) ListDue(ctx context.Context) ([]entity.Campaign, error) {
+	var campaigns []entity.Campaign
+
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND (scheduled_at IS NULL OR scheduled_at <= ?)", entity.CampaignRunning, time.Now()).
+		Order("id").
+		Find(&campaigns).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return campaigns, nil
+}
+
+// UpdateStatus moves the campaign from one status to another, it fails with
+// entity.ErrConflict when the campaign isn't in the from status anymore.
+func (r *campaignRepository) UpdateStatus(
+	ctx context.Context,
+	id uint64,
+	from entity.CampaignStatus,
+	to entity.CampaignStatus,
+) error {
+	result := r.db.WithContext(ctx).
+		Model(&entity.Campaign{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(map[string]any{
+			"status":     to,
+			"updated_at": time.Now(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update campaign with id %d: %w", id, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("campaign with id %d is not %s anymore: %w", id, from, entity.ErrConflict)
+	}
+
+	return nil
+}
+
+// AddRecipients stores the recipients of a campaign, a phone number already
+// in the campaign is skipped. It returns how many were added.
+func (r *campaignRepository) AddRecipients(
+	ctx context.Context,
+	recipients []entity.CampaignRecipient,
+) (int64, error) {
+	if len(recipients) == 0 {
+		return 0, nil
+	}
+
+	for i := range recipients {
+		recipients[i].PhoneNumberHash = phoneNumberHash(r.keyring, recipients[i].PhoneNumber)
+	}
+
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(recipients, constant.CampaignInsertBatch)
+
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to add campaign recipients: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// AddSegment adds the recipients of the messages of the campaign tenant
+// matching segment to the campaign, each with the country code and timezone
+// of its latest message. A phone number already in the campaign is skipped.
+// It returns how many were added.
+func (r *campaignRepository) AddSegment(
+	ctx context.Context,
+	campaign entity.Campaign,
+	segment entity.CampaignSegment,
+) (int64, error) {
+	statuses := []entity.MessageStatus{entity.StatusSent, entity.StatusDelivered}
+	if segment.Delivered {
+		statuses = []entity.MessageStatus{entity.StatusDelivered}
+	}
+
+	// Phone numbers are compared by their hash once encrypted, the same way
+	// the unique index of campaign_recipients does
+	audience := r.db.
+		Model(&entity.Message{}).
+		Select(`DISTINCT ON (COALESCE(phone_number_hash, phone_number))
+			?::bigint, phone_number, country_code, timezone, phone_number_hash`, campaign.ID).
+		Where("tenant_id = ? AND status IN ?", campaign.TenantID, statuses).
+		Order("COALESCE(phone_number_hash, phone_number), id DESC")
+
+	if segment.CountryCode != nil {
+		audience = audience.Where("country_code = ?", *segment.CountryCode)
+	}
+	if segment.Category != nil {
+		audience = audience.Where("category = ?", *segment.Category)
+	}
+	if segment.Since != nil {
+		audience = audience.Where("created_at >= ?", *segment.Since)
+	}
+
+	result := r.db.WithContext(ctx).Exec(`
+		INSERT INTO campaign_recipients (campaign_id, phone_number, country_code, timezone, phone_number_hash)
+		?
+		ON CONFLICT DO NOTHING`, audience)
+
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to add segment to campaign %d: %w", campaign.ID, result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// ExpandRecipients creates the messages of at most chunk recipients that
+// haven't been expanded yet and returns how many were. Recipients locked by
+// a concurrent expansion are skipped.
+func (r *campaignRepository) ExpandRecipients(
+	ctx context.Context,
+	campaign entity.Campaign,
+	chunk int,
+) (int, error) {
+	expanded := 0
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var recipients []entity.CampaignRecipient
+
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("campaign_id = ? AND expanded_at IS NULL", campaign.ID).
+			Order("id").
+			Limit(chunk).
+			Find(&recipients).Error
+		if err != nil || len(recipients) == 0 {
+			return err
+		}
+
+		messages := make([]entity.Message, len(recipients))
+		ids := make([]uint64, len(recipients))
+		for i, recipient := range recipients {
+			messages[i] = campaign.MessageFor(recipient)
+			messages[i].PhoneNumberHash = phoneNumberHash(r.keyring, recipient.PhoneNumber)
+			ids[i] = recipient.ID
+		}
+
+		if err = tx.CreateInBatches(messages, constant.CampaignInsertBatch).Error; err != nil {
+			return err
+		}
+
+		err = tx.Model(&entity.CampaignRecipient{}).
+			Where("id IN ?", ids).
+			Update("expanded_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		expanded = len(recipients)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to expand recipients of campaign %d: %w", campaign.ID, err)
+	}
+
+	return expanded, nil
+}
+
+// CancelPendingMessages cancels the messages of the campaign that haven't been claimed.
+func (r *campaignRepository) CancelPendingMessages(ctx context.Context, id uint64) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.Message{}).
+		Where("campaign_id = ? AND status = ?", id, entity.StatusPending).
+		Updates(map[string]any{
+			"status":     entity.StatusCancelled,
+			"updated_at": time.Now(),
+		})
+
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to cancel messages of campaign %d: %w", id, result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// Complete marks a running campaign completed once every recipient has been
+// expanded and no message is left to send. It reports whether it did.
+func (r *campaignRepository) Complete(ctx context.Context, id uint64) (bool, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		UPDATE campaigns
+		SET status = ?, completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+		  AND status = ?
+		  AND NOT EXISTS (
+		      SELECT 1 FROM campaign_recipients r
+		      WHERE r.campaign_id = campaigns.id AND r.expanded_at IS NULL
+		  )
+		  AND NOT EXISTS (
+		      SELECT 1 FROM messages m
+		      WHERE m.campaign_id = campaigns.id AND m.status IN (?, ?)
+		  )`,
+		entity.CampaignCompleted, id, entity.CampaignRunning, entity.StatusPending, entity.StatusProcessing)
+
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to complete campaign %d: %w", id, result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// Stats counts the recipients of the campaign and its messages by status.
+func (r *campaignRepository) Stats(ctx context.Context, id uint64) (entity.CampaignStats, error) {
+	var recipients struct {
+		Total    int64
+		Expanded int64
+	}
+
+	err := r.db.WithContext(ctx).
+		Model(&entity.CampaignRecipient{}).
+		Select("COUNT(*) AS total, COUNT(expanded_at) AS expanded").
+		Where("campaign_id = ?", id).
+		Scan(&recipients).Error
+	if err != nil {
+		return entity.CampaignStats{}, fmt.Errorf("failed to count recipients of campaign %d: %w", id, err)
+	}
+
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+
+	err = r.db.WithContext(ctx).
+		Model(&entity.Message{}).
+		Select("status, COUNT(*) AS count").
+		Where("campaign_id = ?", id).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return entity.CampaignStats{}, fmt.Errorf("failed to count messages of campaign %d: %w", id, err)
+	}
+
+	stats := entity.CampaignStats{
+		Recipients: recipients.Total,
+		Expanded:   recipients.Expanded,
+	}
+	for _, row := range rows {
+		switch entity.MessageStatus(row.Status) {
+		case entity.StatusPending:
+			stats.Pending = row.Count
+		case entity.StatusProcessing:
+			stats.Processing = row.Count
+		case entity.StatusSent:
+			stats.Sent = row.Count
+		case entity.StatusDelivered:
+			stats.Delivered = row.Count
+		case entity.StatusFailed:
+			stats.Failed = row.Count
+		case entity.StatusSuppressed:
+			stats.Suppressed = row.Count
+		case entity.StatusCancelled:
+			stats.Cancelled = row.Count
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,157 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCampaignRepositoryAddSegment(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	require.NoError(t, db.Exec("TRUNCATE messages, campaigns CASCADE").Error)
+
+	messages := repository.NewMessageRepository(db, nil)
+	campaigns := repository.NewCampaignRepository(db, nil)
+
+	send := func(tenantID, phoneNumber, countryCode string, category entity.MessageCategory, status entity.MessageStatus) {
+		t.Helper()
+		require.NoError(t, messages.Create(ctx, &entity.Message{
+			PhoneNumber: phoneNumber,
+			Content:     "Hello",
+			Status:      status,
+			TenantID:    tenantID,
+			Channel:     entity.DefaultChannel,
+			CountryCode: &countryCode,
+			Category:    category,
+		}))
+	}
+
+	send("acme", "+905551111111", "TR", entity.CategoryMarketing, entity.StatusDelivered)
+	send("acme", "+905551111111", "TR", entity.CategoryMarketing, entity.StatusSent)
+	send("acme", "+905551111112", "TR", entity.CategoryTransactional, entity.StatusSent)
+	send("acme", "+905551111113", "TR", entity.CategoryMarketing, entity.StatusFailed)
+	send("other", "+905551111114", "TR", entity.CategoryMarketing, entity.StatusDelivered)
+	time.Sleep(10 * time.Millisecond)
+	since := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	send("acme", "+4915111111111", "DE", entity.CategoryMarketing, entity.StatusDelivered)
+
+	countryCode, category := "TR", entity.CategoryMarketing
+	tests := []struct {
+		name    string
+		segment entity.CampaignSegment
+		want    []string
+	}{
+		{
+			name:    "Everyone",
+			segment: entity.CampaignSegment{},
+			want:    []string{"+4915111111111", "+905551111111", "+905551111112"},
+		},
+		{
+			name:    "Country",
+			segment: entity.CampaignSegment{CountryCode: &countryCode},
+			want:    []string{"+905551111111", "+905551111112"},
+		},
+		{
+			name:    "CountryAndCategory",
+			segment: entity.CampaignSegment{CountryCode: &countryCode, Category: &category},
+			want:    []string{"+905551111111"},
+		},
+		{
+			name:    "Delivered",
+			segment: entity.CampaignSegment{Delivered: true},
+			want:    []string{"+4915111111111", "+905551111111"},
+		},
+		{
+			name:    "Since",
+			segment: entity.CampaignSegment{Since: &since},
+			want:    []string{"+4915111111111"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			campaign := entity.Campaign{TenantID: "acme", Name: t.Name(), Content: "Spring sale", Status: entity.CampaignDraft}
+			require.NoError(t, campaigns.Create(ctx, &campaign))
+
+			added, err := campaigns.AddSegment(ctx, campaign, tt.segment)
+			require.NoError(t, err)
+			assert.EqualValues(t, len(tt.want), added)
+
+			// Already in the audience
+			added, err = campaigns.AddSegment(ctx, campaign, tt.segment)
+			require.NoError(t, err)
+			assert.Zero(t, added)
+
+			var recipients []entity.CampaignRecipient
+			require.NoError(t, db.Where("campaign_id = ?", campaign.ID).Order("phone_number").Find(&recipients).Error)
+			phoneNumbers := make([]string, len(recipients))
+			for i, recipient := range recipients {
+				phoneNumbers[i] = recipient.PhoneNumber
+				require.NotNil(t, recipient.CountryCode)
+			}
+			assert.Equal(t, tt.want, phoneNumbers)
+		})
+	}
+}
+
+func TestCampaignRepositoryComplete(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	require.NoError(t, db.Exec("TRUNCATE messages, campaigns CASCADE").Error)
+
+	campaigns := repository.NewCampaignRepository(db, nil)
+	campaign := entity.Campaign{Name: t.Name(), Content: "Spring sale", Status: entity.CampaignRunning}
+	require.NoError(t, campaigns.Create(ctx, &campaign))
+
+	added, err := campaigns.AddRecipients(ctx, []entity.CampaignRecipient{
+		{CampaignID: campaign.ID, PhoneNumber: "+905551111111"},
+		{CampaignID: campaign.ID, PhoneNumber: "+905551111112"},
+		{CampaignID: campaign.ID, PhoneNumber: "+905551111111"},
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, added)
+
+	// Recipients left to expand
+	expanded, err := campaigns.ExpandRecipients(ctx, campaign, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, expanded)
+	completed, err := campaigns.Complete(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.False(t, completed)
+
+	// Messages left to send
+	expanded, err = campaigns.ExpandRecipients(ctx, campaign, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, expanded)
+	completed, err = campaigns.Complete(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.False(t, completed)
+
+	stats, err := campaigns.Stats(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.CampaignStats{Recipients: 2, Expanded: 2, Pending: 2}, stats)
+
+	require.NoError(t, db.Model(&entity.Message{}).
+		Where("campaign_id = ?", campaign.ID).
+		Update("status", entity.StatusSent).Error)
+	completed, err = campaigns.Complete(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.True(t, completed)
+
+	stored, err := campaigns.Get(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.CampaignCompleted, stored.Status)
+	assert.NotNil(t, stored.CompletedAt)
+
+	// Only once
+	completed, err = campaigns.Complete(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.False(t, completed)
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+)
+
+// NotificationCircuitBreaker wraps a NotificationService and stops calling
+// it once too many of the latest requests failed. After OpenDuration a few
+// probe requests decide whether the circuit closes or opens again.
+type NotificationCircuitBreaker struct {
+	next   interfaces.NotificationService
+	config entity.CircuitBreakerConfig
+	now    func() time.Time
+
+	mu    sync.Mutex
+	state entity.CircuitState
+	// generation changes with every state change, the outcome of a request
+	// let through in an earlier state is ignored
+	generation uint64
+	// window holds the outcome of the latest requests, true for a failure
+	window         []bool
+	windowNext     int
+	requests       int
+	failures       int
+	openedAt       time.Time
+	probes         int
+	probeSuccesses int
+}
+
+func NewNotificationCircuitBreaker(
+	next interfaces.NotificationService,
+	config entity.CircuitBreakerConfig,
+) interfaces.NotificationCircuitBreaker {
+	return &NotificationCircuitBreaker{
+		next:   next,
+		config: config,
+		now:    time.Now,
+		state:  entity.CircuitClosed,
+		window: make([]bool, max(1, config.WindowSize)),
+	}
+}
+
+// SendNotification sends message through the wrapped service, or returns
+// entity.ErrCircuitOpen without calling it while the circuit is open.
+func (cb *NotificationCircuitBreaker) SendNotification(c context.Context, message entity.Message) (string, error) {
+	generation, err := cb.acquire(c)
+	if err != nil {
+		return "", err
+	}
+
+	messageID, err := cb.next.SendNotification(c, message)
+	cb.release(c, generation, err)
+	return messageID, err
+}
+
+// Stats returns a snapshot of the circuit breaker.
+func (cb *NotificationCircuitBreaker) Stats(c context.Context) entity.CircuitBreakerStats {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	stats := entity.CircuitBreakerStats{
+		Enabled: cb.config.Enabled,
+		State:   entity.CircuitClosed,
+	}
+	if !cb.config.Enabled {
+		return stats
+	}
+
+	cb.advance(c)
+	stats.State = cb.state
+	stats.Requests = cb.requests
+	stats.Failures = cb.failures
+	if cb.requests > 0 {
+		stats.FailureRate = float64(cb.failures) / float64(cb.requests) * 100
+	}
+	if cb.state != entity.CircuitClosed {
+		openedAt, retryAt := cb.openedAt, cb.retryAt()
+		stats.OpenedAt, stats.RetryAt = &openedAt, &retryAt
+	}
+
+	return stats
+}
+
+// acquire lets a request through and returns the generation it belongs to.
+func (cb *NotificationCircuitBreaker) acquire(c context.Context) (uint64, error) {
+	if !cb.config.Enabled {
+		return 0, nil
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.advance(c)
+	switch cb.state {
+	case entity.CircuitClosed:
+		return cb.generation, nil
+	case entity.CircuitHalfOpen:
+		if cb.probes < cb.config.HalfOpenProbes {
+			cb.probes++
+			return cb.generation, nil
+		}
+		return 0, fmt.Errorf("%w, waiting for the probes", entity.ErrCircuitOpen)
+	default:
+		return 0, fmt.Errorf("%w until %s", entity.ErrCircuitOpen, cb.retryAt().Format(time.RFC3339))
+	}
+}
+
+// release records the outcome of a request let through by acquire.
+func (cb *NotificationCircuitBreaker) release(c context.Context, generation uint64, err error) {
+	if !cb.config.Enabled {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if generation != cb.generation {
+		return
+	}
+
+	// The service stopping says nothing about the provider
+	if errors.Is(c.Err(), context.Canceled) {
+		if cb.state == entity.CircuitHalfOpen {
+			cb.probes--
+		}
+		return
+	}
+
+	// A refused message says nothing about the provider health either
+	failed := err != nil && entity.ErrorClassOf(err).Retryable()
+	switch cb.state {
+	case entity.CircuitClosed:
+		cb.record(failed)
+		if cb.requests >= cb.config.MinRequests && cb.failures*100 >= cb.config.FailureRate*cb.requests {
+			cb.open(c)
+		}
+	case entity.CircuitHalfOpen:
+		if failed {
+			cb.open(c)
+			return
+		}
+		cb.probeSuccesses++
+		if cb.probeSuccesses >= cb.config.HalfOpenProbes {
+			cb.close(c)
+		}
+	}
+}
+
+// record adds an outcome to the window, must be called with cb.mu held.
+func (cb *NotificationCircuitBreaker) record(failed bool) {
+	if cb.requests == len(cb.window) {
+		if cb.window[cb.windowNext] {
+			cb.failures--
+		}
+	} else {
+		cb.requests++
+	}
+
+	cb.window[cb.windowNext] = failed
+	if failed {
+		cb.failures++
+	}
+	cb.windowNext = (cb.windowNext + 1) % len(cb.window)
+}
+
+// advance moves an open circuit to half open once OpenDuration is over,
+// must be called with cb.mu held.
+func (cb *NotificationCircuitBreaker) advance(c context.Context) {
+	if cb.state != entity.CircuitOpen || cb.now().Before(cb.retryAt()) {
+		return
+	}
+
+	cb.state = entity.CircuitHalfOpen
+	cb.generation++
+	cb.probes, cb.probeSuccesses = 0, 0
+	log.FromCtx(c).Info("Notification circuit is half open, probing the provider",
+		"probes", cb.config.HalfOpenProbes)
+}
+
+// open must be called with cb.mu held.
+func (cb *NotificationCircuitBreaker) open(c context.Context) {
+	log.FromCtx(c).Warn("Notification circuit opened, messages are released until the provider recovers",
+		"from", cb.state, "failures", cb.failures, "requests", cb.requests, "open_duration", cb.config.OpenDuration)
+
+	cb.state = entity.CircuitOpen
+	cb.generation++
+	cb.openedAt = cb.now()
+}
+
+// close must be called with cb.mu held.
+func (cb *NotificationCircuitBreaker) close(c context.Context) {
+	cb.state = entity.CircuitClosed
+	cb.generation++
+	cb.requests, cb.failures, cb.windowNext = 0, 0, 0
+	clear(cb.window)
+	log.FromCtx(c).Info("Notification circuit closed, the provider recovered")
+}
+
+func (cb *NotificationCircuitBreaker) retryAt() time.Time {
+	return cb.openedAt.Add(time.Duration(cb.config.OpenDuration) * time.Second)
+}
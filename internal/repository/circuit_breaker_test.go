@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// The circuit breaker logs its state changes through the base logger
+	if os.Getenv("LOG_LEVEL") == "" {
+		_ = os.Setenv("LOG_LEVEL", "error")
+	}
+	log.Init()
+
+	os.Exit(m.Run())
+}
+
+// outcome is the answer of the provider to one request.
+type outcome int
+
+const (
+	succeeded outcome = iota
+	failed
+	rejected
+)
+
+func (o outcome) err() error {
+	switch o {
+	case failed:
+		return &entity.NotificationError{Class: entity.ErrorClassTransient, StatusCode: 503, Detail: "unavailable"}
+	case rejected:
+		return &entity.NotificationError{Class: entity.ErrorClassRejected, StatusCode: 400, Detail: "bad number"}
+	default:
+		return nil
+	}
+}
+
+// scriptedNotification answers the requests with the outcome at the head
+// of the script.
+type scriptedNotification struct {
+	script []outcome
+	calls  int
+}
+
+func (s *scriptedNotification) SendNotification(context.Context, entity.Message) (string, error) {
+	o := s.script[0]
+	s.script = s.script[1:]
+	s.calls++
+	return "provider-message-id", o.err()
+}
+
+// newTestCircuitBreaker opens after 2 failures out of the 4 latest requests,
+// for 30 seconds, and closes after 2 successful probes. Its clock only moves
+// with the returned function.
+func newTestCircuitBreaker(next *scriptedNotification) (*NotificationCircuitBreaker, func(time.Duration)) {
+	breaker := NewNotificationCircuitBreaker(next, entity.CircuitBreakerConfig{
+		Enabled:        true,
+		WindowSize:     4,
+		MinRequests:    4,
+		FailureRate:    50,
+		OpenDuration:   30,
+		HalfOpenProbes: 2,
+	}).(*NotificationCircuitBreaker)
+
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return now }
+	return breaker, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	type step struct {
+		wait    time.Duration
+		outcome outcome
+		// refused means the request is expected not to reach the provider
+		refused bool
+		want    entity.CircuitState
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "OpensOnFailureRate",
+			steps: []step{
+				{outcome: succeeded, want: entity.CircuitClosed},
+				{outcome: failed, want: entity.CircuitClosed},
+				{outcome: succeeded, want: entity.CircuitClosed},
+				{outcome: failed, want: entity.CircuitOpen},
+				{refused: true, want: entity.CircuitOpen},
+				{wait: 29 * time.Second, refused: true, want: entity.CircuitOpen},
+			},
+		},
+		{
+			name: "WaitsForMinRequests",
+			steps: []step{
+				{outcome: failed, want: entity.CircuitClosed},
+				{outcome: failed, want: entity.CircuitClosed},
+				{outcome: failed, want: entity.CircuitClosed},
+				{outcome: failed, want: entity.CircuitOpen},
+			},
+		},
+		{
+			name: "RejectedMessagesDontCount",
+			steps: []step{
+				{outcome: rejected, want: entity.CircuitClosed},
+				{outcome: rejected, want: entity.CircuitClosed},
+				{outcome: rejected, want: entity.CircuitClosed},
+				{outcome: rejected, want: entity.CircuitClosed},
+				{outcome: failed, want: entity.CircuitClosed},
+			},
+		},
+		{
+			name: "SlidingWindow",
+			steps: []step{
+				{outcome: failed, want: entity.CircuitClosed},
+				{outcome: succeeded, want: entity.CircuitClosed},
+				{outcome: succeeded, want: entity.CircuitClosed},
+				{outcome: succeeded, want: entity.CircuitClosed},
+				// The first failure has left the window
+				{outcome: succeeded, want: entity.CircuitClosed},
+				{outcome: failed, want: entity.CircuitClosed},
+				{outcome: failed, want: entity.CircuitOpen},
+			},
+		},
+		{
+			name: "HalfOpenCloses",
+			steps: []step{
+				{outcome: failed}, {outcome: failed}, {outcome: failed},
+				{outcome: failed, want: entity.CircuitOpen},
+				{wait: 30 * time.Second, outcome: succeeded, want: entity.CircuitHalfOpen},
+				{outcome: succeeded, want: entity.CircuitClosed},
+				// The window starts over once closed
+				{outcome: failed, want: entity.CircuitClosed},
+			},
+		},
+		{
+			name: "HalfOpenReopens",
+			steps: []step{
+				{outcome: failed}, {outcome: failed}, {outcome: failed},
+				{outcome: failed, want: entity.CircuitOpen},
+				{wait: 30 * time.Second, outcome: succeeded, want: entity.CircuitHalfOpen},
+				{outcome: failed, want: entity.CircuitOpen},
+				{wait: 29 * time.Second, refused: true, want: entity.CircuitOpen},
+				{wait: time.Second, outcome: succeeded, want: entity.CircuitHalfOpen},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &scriptedNotification{}
+			breaker, wait := newTestCircuitBreaker(provider)
+
+			for i, s := range tt.steps {
+				wait(s.wait)
+				if !s.refused {
+					provider.script = append(provider.script, s.outcome)
+				}
+				calls := provider.calls
+
+				_, err := breaker.SendNotification(ctx, entity.Message{ID: uint64(i + 1)})
+				if s.refused {
+					require.ErrorIs(t, err, entity.ErrCircuitOpen, "step %d", i)
+					assert.Equal(t, calls, provider.calls, "step %d reached the provider", i)
+				} else {
+					assert.NotErrorIs(t, err, entity.ErrCircuitOpen, "step %d", i)
+					assert.Equal(t, calls+1, provider.calls, "step %d didn't reach the provider", i)
+				}
+
+				if s.want != "" {
+					assert.Equal(t, s.want, breaker.Stats(ctx).State, "step %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerStats(t *testing.T) {
+	ctx := context.Background()
+	provider := &scriptedNotification{script: []outcome{succeeded, failed, failed, failed}}
+	breaker, wait := newTestCircuitBreaker(provider)
+
+	for range 3 {
+		_, _ = breaker.SendNotification(ctx, entity.Message{})
+	}
+	stats := breaker.Stats(ctx)
+	assert.Equal(t, entity.CircuitClosed, stats.State)
+	assert.Equal(t, 3, stats.Requests)
+	assert.Equal(t, 2, stats.Failures)
+	assert.InDelta(t, 66.67, stats.FailureRate, 0.01)
+	assert.Nil(t, stats.RetryAt)
+
+	_, _ = breaker.SendNotification(ctx, entity.Message{})
+	openedAt := breaker.now()
+	wait(10 * time.Second)
+
+	stats = breaker.Stats(ctx)
+	assert.Equal(t, entity.CircuitOpen, stats.State)
+	require.NotNil(t, stats.OpenedAt)
+	require.NotNil(t, stats.RetryAt)
+	assert.Equal(t, openedAt, *stats.OpenedAt)
+	assert.Equal(t, openedAt.Add(30*time.Second), *stats.RetryAt)
+}
+
+func TestCircuitBreakerProbeLimit(t *testing.T) {
+	ctx := context.Background()
+	breaker, wait := newTestCircuitBreaker(&scriptedNotification{})
+	openCircuit(t, breaker)
+	wait(30 * time.Second)
+
+	// Only HalfOpenProbes requests are let through at a time
+	first, err := breaker.acquire(ctx)
+	require.NoError(t, err)
+	second, err := breaker.acquire(ctx)
+	require.NoError(t, err)
+	_, err = breaker.acquire(ctx)
+	require.ErrorIs(t, err, entity.ErrCircuitOpen)
+
+	// A probe cut short by the service stopping gives its slot back
+	stopped, stop := context.WithCancel(ctx)
+	stop()
+	breaker.release(stopped, first, context.Canceled)
+	third, err := breaker.acquire(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entity.CircuitHalfOpen, breaker.Stats(ctx).State)
+
+	breaker.release(ctx, second, nil)
+	assert.Equal(t, entity.CircuitHalfOpen, breaker.Stats(ctx).State)
+	breaker.release(ctx, third, nil)
+	assert.Equal(t, entity.CircuitClosed, breaker.Stats(ctx).State)
+}
+
+func TestCircuitBreakerIgnoresStaleResults(t *testing.T) {
+	ctx := context.Background()
+	breaker, wait := newTestCircuitBreaker(&scriptedNotification{})
+
+	// A slow request let through while closed answers after the circuit
+	// opened, then after it went half open
+	slow, err := breaker.acquire(ctx)
+	require.NoError(t, err)
+	slower, err := breaker.acquire(ctx)
+	require.NoError(t, err)
+
+	openCircuit(t, breaker)
+	breaker.release(ctx, slow, outcome(failed).err())
+	stats := breaker.Stats(ctx)
+	assert.Equal(t, 4, stats.Requests)
+	assert.Equal(t, 4, stats.Failures)
+
+	wait(30 * time.Second)
+	probe, err := breaker.acquire(ctx)
+	require.NoError(t, err)
+
+	// Neither its success counts as a probe nor its failure reopens the circuit
+	breaker.release(ctx, slower, nil)
+	breaker.release(ctx, slower, outcome(failed).err())
+	assert.Equal(t, entity.CircuitHalfOpen, breaker.Stats(ctx).State)
+
+	// One probe of the two succeeded, the stale success didn't count
+	breaker.release(ctx, probe, nil)
+	assert.Equal(t, entity.CircuitHalfOpen, breaker.Stats(ctx).State)
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	ctx := context.Background()
+	provider := &scriptedNotification{script: []outcome{failed, failed, failed, failed, failed}}
+	breaker := NewNotificationCircuitBreaker(provider, entity.CircuitBreakerConfig{})
+
+	for range 5 {
+		_, err := breaker.SendNotification(ctx, entity.Message{})
+		require.NotErrorIs(t, err, entity.ErrCircuitOpen)
+	}
+	assert.Equal(t, 5, provider.calls)
+	assert.Equal(t, entity.CircuitBreakerStats{State: entity.CircuitClosed}, breaker.Stats(ctx))
+}
+
+// openCircuit fails 4 requests through breaker, which must be closed.
+func openCircuit(t *testing.T, breaker *NotificationCircuitBreaker) {
+	t.Helper()
+
+	provider := breaker.next.(*scriptedNotification)
+	provider.script = append(provider.script, failed, failed, failed, failed)
+	for range 4 {
+		_, _ = breaker.SendNotification(context.Background(), entity.Message{})
+	}
+	require.Equal(t, entity.CircuitOpen, breaker.Stats(context.Background()).State)
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/crypto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// EncryptedSerializer is the name of the gorm serializer sealing string
+// fields with the keyring, `gorm:"serializer:encrypted"`.
+const EncryptedSerializer = "encrypted"
+
+// RegisterEncryption makes the encrypted serializer use keyring, it has to
+// run before the first query. A nil keyring stores plaintext.
+func RegisterEncryption(keyring *crypto.Keyring) {
+	schema.RegisterSerializer(EncryptedSerializer, encryptedSerializer{keyring: keyring})
+}
+
+type encryptedSerializer struct {
+	keyring *crypto.Keyring
+}
+
+// Scan opens the value read from the database, plaintext ones are kept as they are.
+func (s encryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue any) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+		return nil
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("cannot decrypt %T into %s", dbValue, field.Name)
+	}
+
+	plaintext, err := s.keyring.Decrypt(value)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", field.Name, err)
+	}
+
+	field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	return nil
+}
+
+// Value seals the field before it is written.
+func (s encryptedSerializer) Value(_ context.Context, field *schema.Field, _ reflect.Value, fieldValue any) (any, error) {
+	value, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot encrypt %T of %s", fieldValue, field.Name)
+	}
+
+	sealed, err := s.keyring.Encrypt(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt %s: %w", field.Name, err)
+	}
+	return sealed, nil
+}
+
+// phoneNumberHash returns the lookup hash of phoneNumber, nil when
+// encryption is disabled and the phone number is stored in plaintext.
+func phoneNumberHash(keyring *crypto.Keyring, phoneNumber string) *string {
+	if !keyring.Enabled() {
+		return nil
+	}
+	hash := keyring.Hash(phoneNumber)
+	return &hash
+}
+
+// phoneNumberCondition matches the messages sent to phoneNumber, through
+// the hash for encrypted ones and the plaintext for the ones written
+// before encryption was enabled.
+func phoneNumberCondition(keyring *crypto.Keyring, phoneNumber string) clause.Expression {
+	if hash := phoneNumberHash(keyring, phoneNumber); hash != nil {
+		return clause.Expr{
+			SQL:  "(phone_number_hash = ? OR phone_number = ?)",
+			Vars: []any{*hash, phoneNumber},
+		}
+	}
+	return clause.Expr{SQL: "phone_number = ?", Vars: []any{phoneNumber}}
+}
+
+// phoneNumberKeyCondition matches the rows of phoneNumber on the
+// COALESCE(phone_number_hash, phone_number) key the unique indexes of
+// suppressions and campaign recipients are built on, plaintext rows
+// written before encryption was enabled included.
+func phoneNumberKeyCondition(keyring *crypto.Keyring, phoneNumber string) clause.Expression {
+	if hash := phoneNumberHash(keyring, phoneNumber); hash != nil {
+		return clause.Expr{
+			SQL:  "COALESCE(phone_number_hash, phone_number) IN (?, ?)",
+			Vars: []any{*hash, phoneNumber},
+		}
+	}
+	return clause.Expr{SQL: "COALESCE(phone_number_hash, phone_number) = ?", Vars: []any{phoneNumber}}
+}
+
+type encryptionRepository struct {
+	db      *gorm.DB
+	keyring *crypto.Keyring
+}
+
+func NewEncryptionRepository(db *gorm.DB, keyring *crypto.Keyring) interfaces.EncryptionRepository {
+	return &encryptionRepository{
+		db:      db,
+		keyring: keyring,
+	}
+}
+
+// sealedTable is a table with columns written by the encrypted serializer.
+type sealedTable struct {
+	name string
+	// columns are sealed, the first one is the phone number of hashed tables
+	columns []string
+	// hashed tables look phone numbers up by phone_number_hash
+	hashed bool
+	// partitioned tables are updated by id and created_at, the partition key
+	partitioned bool
+}
+
+var (
+	sealedMessages = sealedTable{
+		name:        "messages",
+		columns:     []string{"phone_number", "content"},
+		hashed:      true,
+		partitioned: true,
+	}
+	sealedSuppressions = sealedTable{
+		name:    "suppressions",
+		columns: []string{"phone_number"},
+		hashed:  true,
+	}
+	sealedCampaignRecipients = sealedTable{
+		name:    "campaign_recipients",
+		columns: []string{"phone_number"},
+		hashed:  true,
+	}
+	sealedWebhookDeliveries = sealedTable{
+		name:    "webhook_deliveries",
+		columns: []string{"payload"},
+	}
+)
+
+// ReencryptMessages brings up to batch messages with an id above after
+// that are in plaintext or sealed with an older key under the primary key,
+// and fills their phone number hash. It returns the last id it rewrote,
+// to continue from, and how many it did. Rows locked by another replica
+// are skipped.
+func (r *encryptionRepository) ReencryptMessages(ctx context.Context, after uint64, batch int) (uint64, int, error) {
+	return r.reencrypt(ctx, sealedMessages, after, batch)
+}
+
+// ReencryptSuppressions is ReencryptMessages for the suppressions.
+func (r *encryptionRepository) ReencryptSuppressions(ctx context.Context, after uint64, batch int) (uint64, int, error) {
+	return r.reencrypt(ctx, sealedSuppressions, after, batch)
+}
+
+// ReencryptCampaignRecipients is ReencryptMessages for the campaign recipients.
+func (r *encryptionRepository) ReencryptCampaignRecipients(
+	ctx context.Context,
+	after uint64,
+	batch int,
+) (uint64, int, error) {
+	return r.reencrypt(ctx, sealedCampaignRecipients, after, batch)
+}
+
+// ReencryptWebhookDeliveries is ReencryptMessages for the webhook delivery
+// payloads, which have no phone number hash.
+func (r *encryptionRepository) ReencryptWebhookDeliveries(
+	ctx context.Context,
+	after uint64,
+	batch int,
+) (uint64, int, error) {
+	return r.reencrypt(ctx, sealedWebhookDeliveries, after, batch)
+}
+
+func (r *encryptionRepository) reencrypt(
+	ctx context.Context,
+	table sealedTable,
+	after uint64,
+	batch int,
+) (uint64, int, error) {
+	if !r.keyring.Enabled() {
+		return after, 0, nil
+	}
+
+	current := crypto.Prefix + r.keyring.PrimaryKeyID() + ":%"
+
+	conditions := make([]string, 0, len(table.columns)+1)
+	vars := []any{after}
+	for _, column := range table.columns {
+		conditions = append(conditions, column+" NOT LIKE ?")
+		vars = append(vars, current)
+	}
+	if table.hashed {
+		conditions = append(conditions, "phone_number_hash IS NULL")
+	}
+	vars = append(vars, batch)
+
+	query := fmt.Sprintf(`
+		SELECT id, created_at, %s
+		FROM %s
+		WHERE id > ? AND (%s)
+		ORDER BY id
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED`, strings.Join(table.columns, ", "), table.name, strings.Join(conditions, " OR "))
+
+	// Rows as stored, the values bypass the serializer
+	var rows []map[string]any
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(query, vars...).Scan(&rows).Error; err != nil {
+			return fmt.Errorf("failed to select %s to reencrypt: %w", table.name, err)
+		}
+
+		for _, row := range rows {
+			updates := make(map[string]any, len(table.columns)+1)
+			for _, column := range table.columns {
+				value, _ := row[column].(string)
+
+				var err error
+				if updates[column], _, err = r.keyring.Reencrypt(value); err != nil {
+					return fmt.Errorf("%s %v: %w", table.name, row["id"], err)
+				}
+			}
+
+			if table.hashed {
+				phoneNumber, err := r.keyring.Decrypt(row[table.columns[0]].(string))
+				if err != nil {
+					return fmt.Errorf("%s %v: %w", table.name, row["id"], err)
+				}
+				updates["phone_number_hash"] = r.keyring.Hash(phoneNumber)
+			}
+
+			// The table rather than the model, the values are already sealed
+			update := tx.Table(table.name).Where("id = ?", row["id"])
+			if table.partitioned {
+				update = update.Where("created_at = ?", row["created_at"])
+			}
+			if err := update.Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to reencrypt %s %v: %w", table.name, row["id"], err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil || len(rows) == 0 {
+		return after, 0, err
+	}
+
+	last, ok := rows[len(rows)-1]["id"].(int64)
+	if !ok {
+		return after, 0, fmt.Errorf("unexpected id of %s: %T", table.name, rows[len(rows)-1]["id"])
+	}
+	return uint64(last), len(rows), nil
+}
@@ -0,0 +1,188 @@
+package repository_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/repository"
+	"github.com/craftaholic/insider/internal/shared/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newKey(t *testing.T) string {
+	t.Helper()
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func newKeyring(t *testing.T, keys string, hashKey string) *crypto.Keyring {
+	t.Helper()
+
+	keyring, err := crypto.LoadKeyring(keys, "", hashKey)
+	require.NoError(t, err)
+	return keyring
+}
+
+// useKeyring makes the encrypted serializer seal with keyring until the
+// end of the test.
+func useKeyring(t *testing.T, keyring *crypto.Keyring) {
+	t.Helper()
+
+	repository.RegisterEncryption(keyring)
+	t.Cleanup(func() { repository.RegisterEncryption(nil) })
+}
+
+// storedRow is a row as stored, before the serializer opens it.
+type storedRow struct {
+	ID              uint64
+	PhoneNumber     string
+	Content         string
+	PhoneNumberHash *string
+}
+
+func storedRows(t *testing.T, db *gorm.DB, query string) []storedRow {
+	t.Helper()
+
+	var rows []storedRow
+	require.NoError(t, db.Raw(query).Scan(&rows).Error)
+	return rows
+}
+
+func TestEncryptedSerializer(t *testing.T) {
+	db := testDB(t)
+	require.NoError(t, db.Exec("TRUNCATE messages, campaigns, suppressions CASCADE").Error)
+	ctx := context.Background()
+
+	keyring := newKeyring(t, "primary:"+newKey(t), newKey(t))
+	useKeyring(t, keyring)
+
+	t.Run("Message", func(t *testing.T) {
+		messages := repository.NewMessageRepository(db, keyring)
+		message := entity.Message{PhoneNumber: "+905551112233", Content: "Your code is 1234"}
+		require.NoError(t, messages.Create(ctx, &message))
+
+		stored := storedRows(t, db, "SELECT id, phone_number, content, phone_number_hash FROM messages")
+		require.Len(t, stored, 1)
+		assert.Equal(t, "primary", crypto.KeyID(stored[0].PhoneNumber))
+		assert.Equal(t, "primary", crypto.KeyID(stored[0].Content))
+		require.NotNil(t, stored[0].PhoneNumberHash)
+		assert.Equal(t, keyring.Hash("+905551112233"), *stored[0].PhoneNumberHash)
+
+		// Read back opened, looked up by the hash
+		var exported []entity.Message
+		err := messages.Export(ctx, entity.MessageFilter{PhoneNumber: "+905551112233"}, func(message entity.Message) error {
+			exported = append(exported, message)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, exported, 1)
+		assert.Equal(t, "+905551112233", exported[0].PhoneNumber)
+		assert.Equal(t, "Your code is 1234", exported[0].Content)
+	})
+
+	t.Run("Suppression", func(t *testing.T) {
+		suppressions := repository.NewSuppressionRepository(db, keyring)
+		suppression := entity.Suppression{PhoneNumber: "+905551112233", Channel: "sms", TenantID: entity.DefaultTenantID}
+		require.NoError(t, suppressions.Create(ctx, &suppression))
+
+		stored := storedRows(t, db, "SELECT id, phone_number, phone_number_hash FROM suppressions")
+		require.Len(t, stored, 1)
+		assert.True(t, crypto.IsEncrypted(stored[0].PhoneNumber))
+		require.NotNil(t, stored[0].PhoneNumberHash)
+
+		suppressed, err := suppressions.IsSuppressed(ctx, "+905551112233", "sms", entity.DefaultTenantID)
+		require.NoError(t, err)
+		assert.True(t, suppressed)
+
+		// Suppressing again loads the existing one, the hash keeps it unique
+		again := entity.Suppression{PhoneNumber: "+905551112233", Channel: "sms", TenantID: entity.DefaultTenantID}
+		require.NoError(t, suppressions.Create(ctx, &again))
+		assert.Equal(t, suppression.ID, again.ID)
+		assert.Equal(t, "+905551112233", again.PhoneNumber)
+	})
+}
+
+func TestReencryptMessages(t *testing.T) {
+	db := testDB(t)
+	require.NoError(t, db.Exec("TRUNCATE messages, campaigns, suppressions CASCADE").Error)
+	ctx := context.Background()
+
+	oldKey, hashKey := newKey(t), newKey(t)
+	before := newKeyring(t, "old:"+oldKey, hashKey)
+	rotated := newKeyring(t, "new:"+newKey(t)+",old:"+oldKey, hashKey)
+
+	// One message from before encryption was enabled, one sealed before the rotation
+	plaintext := entity.Message{PhoneNumber: "+905551112233", Content: "written in plaintext"}
+	require.NoError(t, repository.NewMessageRepository(db, nil).Create(ctx, &plaintext))
+	suppression := entity.Suppression{PhoneNumber: "+905551112233", Channel: "sms", TenantID: entity.DefaultTenantID}
+	require.NoError(t, repository.NewSuppressionRepository(db, nil).Create(ctx, &suppression))
+
+	useKeyring(t, before)
+	sealed := entity.Message{PhoneNumber: "+905551112244", Content: "sealed with the old key"}
+	require.NoError(t, repository.NewMessageRepository(db, before).Create(ctx, &sealed))
+
+	useKeyring(t, rotated)
+	encryption := repository.NewEncryptionRepository(db, rotated)
+
+	// One message per batch, continuing after the last one
+	var (
+		after uint64
+		total int
+	)
+	for {
+		next, rewritten, err := encryption.ReencryptMessages(ctx, after, 1)
+		require.NoError(t, err)
+		if rewritten == 0 {
+			break
+		}
+		assert.Equal(t, 1, rewritten)
+		assert.Greater(t, next, after)
+		after, total = next, total+rewritten
+	}
+	assert.Equal(t, 2, total)
+
+	want := map[uint64]entity.Message{plaintext.ID: plaintext, sealed.ID: sealed}
+	for _, row := range storedRows(t, db, "SELECT id, phone_number, content, phone_number_hash FROM messages") {
+		assert.Equal(t, "new", crypto.KeyID(row.PhoneNumber))
+		assert.Equal(t, "new", crypto.KeyID(row.Content))
+
+		phoneNumber, err := rotated.Decrypt(row.PhoneNumber)
+		require.NoError(t, err)
+		assert.Equal(t, want[row.ID].PhoneNumber, phoneNumber)
+		content, err := rotated.Decrypt(row.Content)
+		require.NoError(t, err)
+		assert.Equal(t, want[row.ID].Content, content)
+
+		require.NotNil(t, row.PhoneNumberHash)
+		assert.Equal(t, rotated.Hash(want[row.ID].PhoneNumber), *row.PhoneNumberHash)
+	}
+
+	// Nothing is left to rewrite
+	_, rewritten, err := encryption.ReencryptMessages(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Zero(t, rewritten)
+
+	// The other tables go the same way
+	_, rewritten, err = encryption.ReencryptSuppressions(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, rewritten)
+
+	stored := storedRows(t, db, "SELECT id, phone_number, phone_number_hash FROM suppressions")
+	require.Len(t, stored, 1)
+	assert.Equal(t, "new", crypto.KeyID(stored[0].PhoneNumber))
+	require.NotNil(t, stored[0].PhoneNumberHash)
+	assert.Equal(t, rotated.Hash("+905551112233"), *stored[0].PhoneNumberHash)
+
+	suppressed, err := repository.NewSuppressionRepository(db, rotated).
+		IsSuppressed(ctx, "+905551112233", "sms", entity.DefaultTenantID)
+	require.NoError(t, err)
+	assert.True(t, suppressed)
+}
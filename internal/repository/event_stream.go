@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/go-redis/redis"
+)
+
+type eventStream struct {
+	client  *redis.Client
+	channel string
+}
+
+// NewEventStream creates the live event stream over a Redis pub/sub
+// channel, so every replica receives the events of the others.
+func NewEventStream(redisClient *redis.Client, channel string) interfaces.EventStream {
+	return &eventStream{
+		client:  redisClient,
+		channel: channel,
+	}
+}
+
+func (s *eventStream) Publish(_ context.Context, payload []byte) error {
+	if err := s.client.Publish(s.channel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish to the event stream: %w", err)
+	}
+	return nil
+}
+
+// Subscribe receives the events published by every replica until c is
+// done. The connection is re-established by the client when it drops,
+// events published in the meantime are lost.
+func (s *eventStream) Subscribe(c context.Context) (<-chan []byte, error) {
+	pubsub := s.client.Subscribe(s.channel)
+	if _, err := pubsub.Receive(); err != nil {
+		_ = pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to the event stream: %w", err)
+	}
+
+	payloads := make(chan []byte)
+	go func() {
+		defer close(payloads)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-c.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				select {
+				case payloads <- []byte(message.Payload):
+				case <-c.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return payloads, nil
+}
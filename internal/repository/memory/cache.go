@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+)
+
+type cacheEntry struct {
+	value []byte
+	// expiresAt is zero for entries without a ttl
+	expiresAt time.Time
+}
+
+type cacheRepository struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// NewCacheRepository returns a cache keeping the values in memory, expired
+// entries are dropped when they are read.
+func NewCacheRepository() interfaces.CacheRepository {
+	return &cacheRepository{
+		entries: map[string]cacheEntry{},
+	}
+}
+
+// Get returns the value of key, entity.ErrNotFound when it isn't set.
+func (c *cacheRepository) Get(key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, entity.ErrNotFound
+	}
+	if !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, entity.ErrNotFound
+	}
+
+	return append([]byte(nil), entry.value...), nil
+}
+
+// Set stores value under key for ttl, forever when ttl is 0 like redis.
+func (c *cacheRepository) Set(key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := cacheEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	c.entries[key] = entry
+	return nil
+}
@@ -0,0 +1,12 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/craftaholic/insider/internal/repository/memory"
+	"github.com/craftaholic/insider/internal/repository/repotest"
+)
+
+func TestCacheRepository(t *testing.T) {
+	repotest.TestCacheRepository(t, memory.NewCacheRepository())
+}
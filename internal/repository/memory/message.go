@@ -0,0 +1,624 @@
+// Package memory holds in-memory implementations of the repositories, to
+// test without Postgres and Redis. They behave like the gorm and redis
+// ones, which the repotest suite checks.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/constant"
+	"github.com/craftaholic/insider/internal/shared/crypto"
+)
+
+// Campaigns holds the statuses of the campaigns messages belong to, the
+// messages of a campaign are only claimed while it is running.
+type Campaigns struct {
+	mu       sync.RWMutex
+	statuses map[uint64]entity.CampaignStatus
+}
+
+func NewCampaigns() *Campaigns {
+	return &Campaigns{statuses: map[uint64]entity.CampaignStatus{}}
+}
+
+// Set records the status of campaign id.
+func (c *Campaigns) Set(id uint64, status entity.CampaignStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.statuses[id] = status
+}
+
+func (c *Campaigns) running(id uint64) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.statuses[id] == entity.CampaignRunning
+}
+
+type messageRepository struct {
+	keyring   *crypto.Keyring
+	campaigns *Campaigns
+
+	// mu makes every call atomic, a claim reads and moves the pending
+	// messages at once like get_unsent_messages does under its row locks
+	mu       sync.Mutex
+	messages map[uint64]*entity.Message
+	nextID   uint64
+}
+
+// NewMessageRepository returns a message repository keeping the messages
+// in memory. The keyring only computes the phone number hashes, messages
+// are kept in plaintext. Messages of a campaign are never claimed when
+// campaigns is nil.
+func NewMessageRepository(keyring *crypto.Keyring, campaigns *Campaigns) interfaces.MessageRepository {
+	return &messageRepository{
+		keyring:   keyring,
+		campaigns: campaigns,
+		messages:  map[uint64]*entity.Message{},
+		nextID:    1,
+	}
+}
+
+func (r *messageRepository) Create(_ context.Context, message *entity.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Defaults of the messages table
+	if message.Status == "" {
+		message.Status = entity.StatusPending
+	}
+	if message.CreatedAt.IsZero() {
+		message.CreatedAt = time.Now()
+	}
+	if message.TenantID == "" {
+		message.TenantID = entity.DefaultTenantID
+	}
+	if message.Channel == "" {
+		message.Channel = entity.DefaultChannel
+	}
+	if message.Segments == 0 {
+		message.Segments = 1
+	}
+	if message.Encoding == "" {
+		message.Encoding = entity.EncodingGSM7
+	}
+	if message.Category == "" {
+		message.Category = entity.CategoryTransactional
+	}
+
+	message.ID = r.nextID
+	message.PhoneNumberHash = phoneNumberHash(r.keyring, message.PhoneNumber)
+	r.nextID++
+
+	stored := *message
+	r.messages[message.ID] = &stored
+	return nil
+}
+
+func (r *messageRepository) UpdateSelective(_ context.Context, id uint64, updates map[string]any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	message, ok := r.messages[id]
+	if !ok {
+		return fmt.Errorf("message with id %d not found", id)
+	}
+
+	updated := *message
+	if err := applyUpdates(&updated, updates); err != nil {
+		return fmt.Errorf("failed to update message with id %d: %w", id, err)
+	}
+
+	*message = updated
+	return nil
+}
+
+// UpdateSentByMessageID updates the sent message the notification service
+// returned messageID for and returns it, delivery reports only apply to sent messages.
+func (r *messageRepository) UpdateSentByMessageID(
+	_ context.Context,
+	messageID string,
+	updates map[string]any,
+) (entity.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []*entity.Message
+	for _, message := range r.sorted() {
+		if message.Status == entity.StatusSent && message.MessageID != nil && *message.MessageID == messageID {
+			matched = append(matched, message)
+		}
+	}
+
+	if len(matched) == 0 {
+		return entity.Message{}, fmt.Errorf("sent message %s: %w", messageID, entity.ErrNotFound)
+	}
+
+	for _, message := range matched {
+		updated := *message
+		if err := applyUpdates(&updated, updates); err != nil {
+			return entity.Message{}, fmt.Errorf("failed to update message %s: %w", messageID, err)
+		}
+		*message = updated
+	}
+
+	return *matched[0], nil
+}
+
+// Update sets the non-zero fields of message, like gorm updating from a struct.
+func (r *messageRepository) Update(_ context.Context, id uint64, message entity.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.messages[id]
+	if !ok {
+		return fmt.Errorf("message with id %d not found", id)
+	}
+
+	src := reflect.ValueOf(message)
+	dst := reflect.ValueOf(stored).Elem()
+	for _, field := range messageColumns {
+		if field.name == "id" || src.Field(field.index).IsZero() {
+			continue
+		}
+		dst.Field(field.index).Set(src.Field(field.index))
+	}
+
+	now := time.Now()
+	stored.UpdatedAt = &now
+	return nil
+}
+
+func (r *messageRepository) GetPending(_ context.Context, batch int) ([]entity.Message, error) {
+	if batch <= 0 {
+		return nil, errors.New("batch size must be greater than 0")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var claimed []*entity.Message
+	for _, message := range r.sorted() {
+		if len(claimed) == batch {
+			break
+		}
+		if r.due(message, now) {
+			claimed = append(claimed, message)
+		}
+	}
+
+	return claim(claimed, now), nil
+}
+
+// GetPendingOrdered claims at most one message per sequence key, the oldest
+// pending one, and none for keys that still have a message in processing.
+func (r *messageRepository) GetPendingOrdered(_ context.Context, batch int) ([]entity.Message, error) {
+	if batch <= 0 {
+		return nil, errors.New("batch size must be greater than 0")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Keys with a message in processing, or an older pending one whether
+	// it is due or not, are held back. Messages are grouped by the key the
+	// worker shards use, get_unsent_messages_ordered groups encrypted phone
+	// numbers by their hash which makes the same groups
+	held := map[string]bool{}
+	for _, message := range r.messages {
+		if message.Status == entity.StatusProcessing {
+			held[message.SequenceKey()] = true
+		}
+	}
+
+	now := time.Now()
+	var claimed []*entity.Message
+	for _, message := range r.sorted() {
+		if message.Status != entity.StatusPending {
+			continue
+		}
+
+		key := message.SequenceKey()
+		if len(claimed) < batch && !held[key] && r.due(message, now) {
+			claimed = append(claimed, message)
+		}
+		held[key] = true
+	}
+
+	return claim(claimed, now), nil
+}
+
+// ClaimByIDs moves the messages of ids that GetPending would claim to
+// processing and returns them, the others are left as they are.
+func (r *messageRepository) ClaimByIDs(_ context.Context, ids []uint64) ([]entity.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var claimed []*entity.Message
+	for _, message := range r.sorted() {
+		if slices.Contains(ids, message.ID) && r.due(message, now) {
+			claimed = append(claimed, message)
+		}
+	}
+
+	return claim(claimed, now), nil
+}
+
+// ReclaimByIDs claims the messages of ids like ClaimByIDs, along with the
+// ones processing since stuckBefore.
+func (r *messageRepository) ReclaimByIDs(
+	_ context.Context,
+	ids []uint64,
+	stuckBefore time.Time,
+) ([]entity.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var claimed []*entity.Message
+	for _, message := range r.sorted() {
+		if !slices.Contains(ids, message.ID) {
+			continue
+		}
+
+		stuck := message.Status == entity.StatusProcessing &&
+			message.UpdatedAt != nil && message.UpdatedAt.Before(stuckBefore)
+		if stuck || r.due(message, now) {
+			claimed = append(claimed, message)
+		}
+	}
+
+	return claim(claimed, now), nil
+}
+
+// ListDueIDs returns the ids above afterID of the messages GetPending
+// would claim, in id order.
+func (r *messageRepository) ListDueIDs(_ context.Context, afterID uint64, limit int) ([]uint64, error) {
+	if limit <= 0 {
+		return nil, errors.New("limit must be greater than 0")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var ids []uint64
+	for id, message := range r.messages {
+		if id > afterID && r.due(message, now) {
+			ids = append(ids, id)
+		}
+	}
+
+	slices.Sort(ids)
+	return ids[:min(limit, len(ids))], nil
+}
+
+// Release puts the messages of ids that are processing back to pending and
+// returns how many were, the others are left as they are.
+func (r *messageRepository) Release(_ context.Context, ids []uint64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var released int64
+	for _, id := range ids {
+		message, ok := r.messages[id]
+		if !ok || message.Status != entity.StatusProcessing {
+			continue
+		}
+
+		updatedAt := now
+		message.Status = entity.StatusPending
+		message.UpdatedAt = &updatedAt
+		released++
+	}
+
+	return released, nil
+}
+
+// Requeue puts the failed message of id back to pending with its attempts
+// and error cleared, and returns it.
+func (r *messageRepository) Requeue(_ context.Context, id uint64) (entity.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	message, ok := r.messages[id]
+	if !ok || message.Status != entity.StatusFailed {
+		return entity.Message{}, fmt.Errorf("failed message %d: %w", id, entity.ErrNotFound)
+	}
+
+	updatedAt := time.Now()
+	message.Status = entity.StatusPending
+	message.Attempts = 0
+	message.ScheduledAt = nil
+	message.ErrorMessage = nil
+	message.ErrorClass = nil
+	message.UpdatedAt = &updatedAt
+	return *message, nil
+}
+
+// ResetStuck puts the messages processing since before back to pending and
+// returns their ids.
+func (r *messageRepository) ResetStuck(_ context.Context, before time.Time) ([]uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var ids []uint64
+	for _, message := range r.sorted() {
+		if message.Status != entity.StatusProcessing || message.UpdatedAt == nil || !message.UpdatedAt.Before(before) {
+			continue
+		}
+
+		updatedAt := now
+		message.Status = entity.StatusPending
+		message.UpdatedAt = &updatedAt
+		ids = append(ids, message.ID)
+	}
+
+	return ids, nil
+}
+
+// CountPending counts the pending messages that are due, deferred ones and
+// ones of campaigns that aren't running aren't part of the backlog.
+func (r *messageRepository) CountPending(_ context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var count int64
+	for _, message := range r.messages {
+		if r.due(message, now) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *messageRepository) GetSentWithPagination(
+	_ context.Context,
+	filter entity.MessageFilter,
+	page int,
+) ([]entity.Message, error) {
+	if page <= 0 {
+		return nil, errors.New("page must be greater than 0")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	filter.Statuses = []entity.MessageStatus{entity.StatusSent, entity.StatusDelivered}
+	var messages []entity.Message
+	for _, message := range r.sorted() {
+		if r.matches(message, filter) {
+			messages = append(messages, *message)
+		}
+	}
+
+	// sent_at DESC, Postgres puts NULLs first
+	slices.SortStableFunc(messages, func(a, b entity.Message) int {
+		switch {
+		case a.SentAt == nil && b.SentAt == nil:
+			return 0
+		case a.SentAt == nil:
+			return -1
+		case b.SentAt == nil:
+			return 1
+		default:
+			return b.SentAt.Compare(*a.SentAt)
+		}
+	})
+
+	offset := (page - 1) * constant.DefaultPageSize
+	if offset >= len(messages) {
+		return nil, nil
+	}
+	return messages[offset:min(offset+constant.DefaultPageSize, len(messages))], nil
+}
+
+// Export calls fn with every message matching filter, in id order. The
+// matching messages are copied first so fn may use the repository. An
+// error of fn stops the export and is returned.
+func (r *messageRepository) Export(
+	_ context.Context,
+	filter entity.MessageFilter,
+	fn func(entity.Message) error,
+) error {
+	r.mu.Lock()
+	var messages []entity.Message
+	for _, message := range r.messages {
+		if r.matches(message, filter) {
+			messages = append(messages, *message)
+		}
+	}
+	r.mu.Unlock()
+
+	slices.SortFunc(messages, func(a, b entity.Message) int { return cmp.Compare(a.ID, b.ID) })
+	for _, message := range messages {
+		if err := fn(message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sorted returns the messages in claiming order, created_at then id.
+func (r *messageRepository) sorted() []*entity.Message {
+	messages := make([]*entity.Message, 0, len(r.messages))
+	for _, message := range r.messages {
+		messages = append(messages, message)
+	}
+
+	slices.SortFunc(messages, func(a, b *entity.Message) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return messages
+}
+
+// due reports whether message is pending, not deferred past now and not
+// part of a campaign that isn't running.
+func (r *messageRepository) due(message *entity.Message, now time.Time) bool {
+	return message.Status == entity.StatusPending &&
+		(message.ScheduledAt == nil || !message.ScheduledAt.After(now)) &&
+		(message.CampaignID == nil || r.campaigns.running(*message.CampaignID))
+}
+
+func (r *messageRepository) matches(message *entity.Message, filter entity.MessageFilter) bool {
+	if filter.TenantID != "" && message.TenantID != filter.TenantID {
+		return false
+	}
+	if filter.PhoneNumber != "" && message.PhoneNumber != filter.PhoneNumber {
+		hash := phoneNumberHash(r.keyring, filter.PhoneNumber)
+		if hash == nil || message.PhoneNumberHash == nil || *message.PhoneNumberHash != *hash {
+			return false
+		}
+	}
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, message.Status) {
+		return false
+	}
+	if !filter.From.IsZero() && message.CreatedAt.Before(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && !message.CreatedAt.Before(filter.To) {
+		return false
+	}
+	return true
+}
+
+// claim moves messages to processing and returns copies of them.
+func claim(messages []*entity.Message, now time.Time) []entity.Message {
+	claimed := make([]entity.Message, 0, len(messages))
+	for _, message := range messages {
+		updatedAt := now
+		message.Status = entity.StatusProcessing
+		message.UpdatedAt = &updatedAt
+		claimed = append(claimed, *message)
+	}
+	return claimed
+}
+
+func phoneNumberHash(keyring *crypto.Keyring, phoneNumber string) *string {
+	if !keyring.Enabled() {
+		return nil
+	}
+	hash := keyring.Hash(phoneNumber)
+	return &hash
+}
+
+type messageColumn struct {
+	name  string
+	index int
+}
+
+// messageColumns are the fields of entity.Message by the column name of
+// their gorm tag, the names UpdateSelective is given.
+var messageColumns = func() []messageColumn {
+	var columns []messageColumn
+	messageType := reflect.TypeFor[entity.Message]()
+	for i := range messageType.NumField() {
+		for _, setting := range strings.Split(messageType.Field(i).Tag.Get("gorm"), ";") {
+			if name, ok := strings.CutPrefix(setting, "column:"); ok {
+				columns = append(columns, messageColumn{name: name, index: i})
+			}
+		}
+	}
+	return columns
+}()
+
+// applyUpdates sets the columns of updates on message, converting the
+// values to the field types the way the database driver would. updated_at
+// is set when updates lacks it, like gorm does.
+func applyUpdates(message *entity.Message, updates map[string]any) error {
+	target := reflect.ValueOf(message).Elem()
+	for column, value := range updates {
+		index := slices.IndexFunc(messageColumns, func(c messageColumn) bool { return c.name == column })
+		if index < 0 {
+			return fmt.Errorf("unknown column %q", column)
+		}
+
+		field := target.Field(messageColumns[index].index)
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("column %q: %w", column, err)
+		}
+	}
+
+	if _, ok := updates["updated_at"]; !ok {
+		now := time.Now()
+		message.UpdatedAt = &now
+	}
+	return nil
+}
+
+func setField(field reflect.Value, value any) error {
+	if value == nil {
+		field.SetZero()
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			field.SetZero()
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	target := field.Type()
+	if target.Kind() == reflect.Pointer {
+		target = target.Elem()
+	}
+	if !assignable(v.Type(), target) {
+		return fmt.Errorf("cannot set %T to a %s", value, field.Type())
+	}
+
+	converted := reflect.New(target).Elem()
+	converted.Set(v.Convert(target))
+	if field.Kind() == reflect.Pointer {
+		field.Set(converted.Addr())
+	} else {
+		field.Set(converted)
+	}
+	return nil
+}
+
+// assignable reports whether a value of type from may be stored in a field
+// of type to, strings into string types and integers into integers.
+func assignable(from reflect.Type, to reflect.Type) bool {
+	if !from.ConvertibleTo(to) {
+		return false
+	}
+	return from.Kind() == to.Kind() || integer(from) && integer(to)
+}
+
+func integer(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
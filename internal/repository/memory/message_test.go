@@ -0,0 +1,25 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/repository/memory"
+	"github.com/craftaholic/insider/internal/repository/repotest"
+)
+
+func TestMessageRepository(t *testing.T) {
+	repotest.TestMessageRepository(t, func(*testing.T) repotest.MessageStore {
+		campaigns := memory.NewCampaigns()
+		var nextCampaignID uint64
+
+		return repotest.MessageStore{
+			Repository: memory.NewMessageRepository(nil, campaigns),
+			CreateCampaign: func(_ *testing.T, status entity.CampaignStatus) uint64 {
+				nextCampaignID++
+				campaigns.Set(nextCampaignID, status)
+				return nextCampaignID
+			},
+		}
+	})
+}
@@ -4,22 +4,37 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/craftaholic/insider/internal/domain/entity"
 	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/constant"
+	"github.com/craftaholic/insider/internal/shared/crypto"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type messageRepository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	keyring *crypto.Keyring
 }
 
-func NewMessageRepository(db *gorm.DB) interfaces.MessageRepository {
+func NewMessageRepository(db *gorm.DB, keyring *crypto.Keyring) interfaces.MessageRepository {
 	return &messageRepository{
-		db: db,
+		db:      db,
+		keyring: keyring,
 	}
 }
 
+func (r *messageRepository) Create(ctx context.Context, message *entity.Message) error {
+	message.PhoneNumberHash = phoneNumberHash(r.keyring, message.PhoneNumber)
+	if err := r.db.WithContext(ctx).Create(message).Error; err != nil {
+		return fmt.Errorf("failed to create message: %w", err)
+	}
+
+	return nil
+}
+
 func (r *messageRepository) UpdateSelective(ctx context.Context, id uint64, updates map[string]any) error {
 	result := r.db.WithContext(ctx).
 		Model(&entity.Message{}).
@@ -37,6 +52,32 @@ func (r *messageRepository) UpdateSelective(ctx context.Context, id uint64, upda
 	return nil
 }
 
+// UpdateSentByMessageID updates the sent message the notification service
+// returned messageID for and returns it, delivery reports only apply to sent messages.
+func (r *messageRepository) UpdateSentByMessageID(
+	ctx context.Context,
+	messageID string,
+	updates map[string]any,
+) (entity.Message, error) {
+	var messages []entity.Message
+
+	result := r.db.WithContext(ctx).
+		Model(&messages).
+		Clauses(clause.Returning{}).
+		Where("message_id = ? AND status = ?", messageID, entity.StatusSent).
+		Updates(updates)
+
+	if result.Error != nil {
+		return entity.Message{}, fmt.Errorf("failed to update message %s: %w", messageID, result.Error)
+	}
+
+	if len(messages) == 0 {
+		return entity.Message{}, fmt.Errorf("sent message %s: %w", messageID, entity.ErrNotFound)
+	}
+
+	return messages[0], nil
+}
+
 func (r *messageRepository) Update(ctx context.Context, id uint64, message entity.Message) error {
 	// Update the message with the given ID
 	result := r.db.WithContext(ctx).
@@ -75,20 +116,236 @@ func (r *messageRepository) GetPending(ctx context.Context, batch int) ([]entity
 	return messages, nil
 }
 
-func (r *messageRepository) GetSentWithPagination(ctx context.Context, page int) ([]entity.Message, error) {
+// GetPendingOrdered claims at most one message per sequence key, the oldest
+// pending one, and none for keys that still have a message in processing.
+func (r *messageRepository) GetPendingOrdered(ctx context.Context, batch int) ([]entity.Message, error) {
+	if batch <= 0 {
+		return nil, errors.New("batch size must be greater than 0")
+	}
+
+	var messages []entity.Message
+
+	err := r.db.WithContext(ctx).
+		Raw("SELECT * FROM get_unsent_messages_ordered(?)", batch).
+		Find(&messages).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// CountPending counts the pending messages that are due, deferred ones and
+// ones of campaigns that aren't running aren't part of the backlog.
+func (r *messageRepository) CountPending(ctx context.Context) (int64, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).
+		Model(&entity.Message{}).
+		Scopes(r.dueScope).
+		Count(&count).Error
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ClaimByIDs moves the messages of ids that GetPending would claim to
+// processing and returns them, the others are left as they are. The
+// status condition is checked again once the rows are locked, so
+// concurrent claims of the same id never both get it.
+func (r *messageRepository) ClaimByIDs(ctx context.Context, ids []uint64) ([]entity.Message, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var messages []entity.Message
+
+	err := r.db.WithContext(ctx).
+		Model(&messages).
+		Clauses(clause.Returning{}).
+		Where("id IN ?", ids).
+		Scopes(r.dueScope).
+		Updates(map[string]any{
+			"status":     entity.StatusProcessing,
+			"updated_at": time.Now(),
+		}).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// ReclaimByIDs claims the messages of ids like ClaimByIDs, along with the
+// ones processing since stuckBefore, which the replica that claimed them
+// never finished.
+func (r *messageRepository) ReclaimByIDs(
+	ctx context.Context,
+	ids []uint64,
+	stuckBefore time.Time,
+) ([]entity.Message, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var messages []entity.Message
+
+	err := r.db.WithContext(ctx).
+		Model(&messages).
+		Clauses(clause.Returning{}).
+		Where("id IN ?", ids).
+		Where(r.dueCondition().
+			Or("status = ? AND updated_at < ?", entity.StatusProcessing, stuckBefore)).
+		Updates(map[string]any{
+			"status":     entity.StatusProcessing,
+			"updated_at": time.Now(),
+		}).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to reclaim messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// ListDueIDs returns the ids above afterID of the messages GetPending
+// would claim, in id order.
+func (r *messageRepository) ListDueIDs(ctx context.Context, afterID uint64, limit int) ([]uint64, error) {
+	if limit <= 0 {
+		return nil, errors.New("limit must be greater than 0")
+	}
+
+	var ids []uint64
+
+	err := r.db.WithContext(ctx).
+		Model(&entity.Message{}).
+		Scopes(r.dueScope).
+		Where("id > ?", afterID).
+		Order("id").
+		Limit(limit).
+		Pluck("id", &ids).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due messages: %w", err)
+	}
+
+	return ids, nil
+}
+
+// Release puts the messages of ids that are processing back to pending in
+// one update and returns how many were, the others are left as they are.
+func (r *messageRepository) Release(ctx context.Context, ids []uint64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	result := r.db.WithContext(ctx).
+		Model(&entity.Message{}).
+		Where("id IN ? AND status = ?", ids, entity.StatusProcessing).
+		Updates(map[string]any{
+			"status":     entity.StatusPending,
+			"updated_at": time.Now(),
+		})
+
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to release messages: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// Requeue puts the failed message of id back to pending with its attempts
+// and error cleared, and returns it.
+func (r *messageRepository) Requeue(ctx context.Context, id uint64) (entity.Message, error) {
+	var messages []entity.Message
+
+	result := r.db.WithContext(ctx).
+		Model(&messages).
+		Clauses(clause.Returning{}).
+		Where("id = ? AND status = ?", id, entity.StatusFailed).
+		Updates(map[string]any{
+			"status":        entity.StatusPending,
+			"attempts":      0,
+			"scheduled_at":  nil,
+			"error_message": nil,
+			"error_class":   nil,
+			"updated_at":    time.Now(),
+		})
+
+	if result.Error != nil {
+		return entity.Message{}, fmt.Errorf("failed to requeue message with id %d: %w", id, result.Error)
+	}
+
+	if len(messages) == 0 {
+		return entity.Message{}, fmt.Errorf("failed message %d: %w", id, entity.ErrNotFound)
+	}
+
+	return messages[0], nil
+}
+
+// ResetStuck puts the messages processing since before back to pending and
+// returns their ids, their replica died or failed to update them.
+func (r *messageRepository) ResetStuck(ctx context.Context, before time.Time) ([]uint64, error) {
+	var messages []entity.Message
+
+	err := r.db.WithContext(ctx).
+		Model(&messages).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("status = ? AND updated_at < ?", entity.StatusProcessing, before).
+		Updates(map[string]any{
+			"status":     entity.StatusPending,
+			"updated_at": time.Now(),
+		}).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to reset stuck messages: %w", err)
+	}
+
+	ids := make([]uint64, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return ids, nil
+}
+
+// dueScope selects the pending messages that are due, of no campaign or a
+// running one, the ones get_unsent_messages claims.
+func (r *messageRepository) dueScope(db *gorm.DB) *gorm.DB {
+	return db.Where(r.dueCondition())
+}
+
+// dueCondition is the condition of dueScope as a group, to be combined
+// with other conditions.
+func (r *messageRepository) dueCondition() *gorm.DB {
+	return r.db.
+		Where("status = ? AND (scheduled_at IS NULL OR scheduled_at <= ?)", entity.StatusPending, time.Now()).
+		Where("campaign_id IS NULL OR campaign_id IN (?)",
+			r.db.Model(&entity.Campaign{}).Select("id").Where("status = ?", entity.CampaignRunning))
+}
+
+func (r *messageRepository) GetSentWithPagination(
+	ctx context.Context,
+	filter entity.MessageFilter,
+	page int,
+) ([]entity.Message, error) {
 	if page <= 0 {
 		return nil, errors.New("page must be greater than 0")
 	}
 
-	const pageSize = 20
-	offset := (page - 1) * pageSize
+	offset := (page - 1) * constant.DefaultPageSize
 
 	var messages []entity.Message
 
+	filter.Statuses = []entity.MessageStatus{entity.StatusSent, entity.StatusDelivered}
 	err := r.db.WithContext(ctx).
-		Where("status = ?", entity.StatusSent).
+		Scopes(messageFilterScope(r.keyring, filter)).
 		Offset(offset).
-		Limit(pageSize).
+		Limit(constant.DefaultPageSize).
 		Order("sent_at DESC").
 		Find(&messages).Error
 
@@ -98,3 +355,60 @@ func (r *messageRepository) GetSentWithPagination(ctx context.Context, page int)
 
 	return messages, nil
 }
+
+// Export calls fn with every message matching filter, in id order. Rows are
+// read from the query as fn consumes them rather than loaded at once, so
+// the export doesn't grow with the number of messages. An error of fn
+// stops the export and is returned.
+func (r *messageRepository) Export(
+	ctx context.Context,
+	filter entity.MessageFilter,
+	fn func(entity.Message) error,
+) error {
+	rows, err := r.db.WithContext(ctx).
+		Model(&entity.Message{}).
+		Scopes(messageFilterScope(r.keyring, filter)).
+		Order("id").
+		Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query messages to export: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var message entity.Message
+		if err = r.db.ScanRows(rows, &message); err != nil {
+			return fmt.Errorf("failed to read exported message: %w", err)
+		}
+
+		if err = fn(message); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to read messages to export: %w", err)
+	}
+	return nil
+}
+
+func messageFilterScope(keyring *crypto.Keyring, filter entity.MessageFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.TenantID != "" {
+			db = db.Where("tenant_id = ?", filter.TenantID)
+		}
+		if filter.PhoneNumber != "" {
+			db = db.Where(phoneNumberCondition(keyring, filter.PhoneNumber))
+		}
+		if len(filter.Statuses) > 0 {
+			db = db.Where("status IN ?", filter.Statuses)
+		}
+		if !filter.From.IsZero() {
+			db = db.Where("created_at >= ?", filter.From)
+		}
+		if !filter.To.IsZero() {
+			db = db.Where("created_at < ?", filter.To)
+		}
+		return db
+	}
+}
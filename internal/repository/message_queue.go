@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+)
+
+type postgresMessageQueue struct {
+	messageRepository interfaces.MessageRepository
+	ordered           bool
+}
+
+// NewPostgresMessageQueue claims the oldest pending messages straight from
+// the messages table with get_unsent_messages, or get_unsent_messages_ordered
+// when ordered. Every pending message is in the table already, so
+// enqueueing and acking have nothing to do.
+func NewPostgresMessageQueue(messageRepository interfaces.MessageRepository, ordered bool) interfaces.MessageQueue {
+	return &postgresMessageQueue{
+		messageRepository: messageRepository,
+		ordered:           ordered,
+	}
+}
+
+func (q *postgresMessageQueue) Enqueue(_ context.Context, _ ...uint64) error {
+	return nil
+}
+
+func (q *postgresMessageQueue) Claim(c context.Context, batch int) ([]entity.Message, error) {
+	if q.ordered {
+		return q.messageRepository.GetPendingOrdered(c, batch)
+	}
+	return q.messageRepository.GetPending(c, batch)
+}
+
+func (q *postgresMessageQueue) Ack(_ context.Context, _ ...uint64) error {
+	return nil
+}
@@ -0,0 +1,53 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/repository"
+	"github.com/craftaholic/insider/internal/repository/repotest"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlog "gorm.io/gorm/logger"
+)
+
+// testDB connects to the database of TEST_DATABASE_DSN, which must have
+// the schema of build/init.sql. Its messages, campaigns and suppressions
+// are wiped by the tests, never point it at a database in use.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN isn't set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: gormlog.Default.LogMode(gormlog.Error),
+	})
+	require.NoError(t, err)
+	repository.RegisterEncryption(nil)
+
+	return db
+}
+
+func TestMessageRepository(t *testing.T) {
+	db := testDB(t)
+
+	repotest.TestMessageRepository(t, func(t *testing.T) repotest.MessageStore {
+		require.NoError(t, db.Exec("TRUNCATE messages, campaigns CASCADE").Error)
+
+		campaignRepository := repository.NewCampaignRepository(db, nil)
+		return repotest.MessageStore{
+			Repository: repository.NewMessageRepository(db, nil),
+			CreateCampaign: func(t *testing.T, status entity.CampaignStatus) uint64 {
+				campaign := entity.Campaign{Name: t.Name(), Content: t.Name(), Status: status}
+				require.NoError(t, campaignRepository.Create(context.Background(), &campaign))
+				return campaign.ID
+			},
+		}
+	})
+}
@@ -3,7 +3,10 @@ package repository
 import (
 	"context"
 	"encoding/json"
-	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/craftaholic/insider/internal/domain/entity"
 	"github.com/craftaholic/insider/internal/domain/interfaces"
@@ -51,26 +54,99 @@ func (ns *NotificationService) SendNotification(c context.Context, message entit
 		return "", err
 	}
 
+	// The context carries the per message deadline and the stop
+	// signal, it aborts the request and any pending retry
 	response, webhookErr := ns.client.R().
+		SetContext(c).
 		SetHeader("Authorization", "Bearer "+ns.apiKey).
 		SetHeader("Content-Type", "application/json").
 		SetBody(body).
 		Post(ns.endPoint)
 	if webhookErr != nil {
-		logger.Error("Error sending notification", "error", err)
-		return "", webhookErr
+		logger.Error("Error sending notification", "error", webhookErr)
+		// The caller tells a stop from a timeout by the context
+		if c.Err() != nil {
+			return "", webhookErr
+		}
+		return "", &entity.NotificationError{
+			Class:  entity.ErrorClassTransient,
+			Detail: webhookErr.Error(),
+			Err:    webhookErr,
+		}
 	}
 
 	var notificationResponse NotificationResponse
-	err = json.Unmarshal(response.Body(), &notificationResponse)
-	if err != nil {
+	parseErr := json.Unmarshal(response.Body(), &notificationResponse)
+
+	if err = classifyResponse(response, notificationResponse, parseErr); err != nil {
 		logger.Error("Error sending notification", "error", err)
+		return "", err
 	}
 
-	if notificationResponse.Message != "Accepted" {
-		logger.Error("Error sending notification", "response for webhook", notificationResponse.Message)
-		return "", errors.New("Error sending notification " + "response for webhook " + notificationResponse.Message)
+	return notificationResponse.MessageID, nil
+}
+
+// classifyResponse returns the error of a provider response, nil when the
+// message was accepted. resty has already retried 429 and 5xx responses.
+func classifyResponse(response *resty.Response, body NotificationResponse, parseErr error) error {
+	status := response.StatusCode()
+	detail := body.Message
+	switch {
+	case parseErr != nil && status < http.StatusBadRequest:
+		detail = "unreadable response: " + parseErr.Error()
+	case detail == "":
+		detail = http.StatusText(status)
 	}
 
-	return notificationResponse.MessageID, nil
+	notificationErr := &entity.NotificationError{StatusCode: status, Detail: detail}
+	switch {
+	case status == http.StatusTooManyRequests:
+		notificationErr.Class = entity.ErrorClassRateLimited
+		notificationErr.RetryAfter = parseRetryAfter(response.Header().Get("Retry-After"))
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		notificationErr.Class = entity.ErrorClassAuthFailure
+	case status == http.StatusRequestTimeout || status >= http.StatusInternalServerError:
+		notificationErr.Class = entity.ErrorClassTransient
+	case status >= http.StatusBadRequest:
+		notificationErr.Class = rejectionClass(body.Message)
+	case parseErr != nil:
+		// Accepted or not, the provider can't be trusted with this answer
+		notificationErr.Class = entity.ErrorClassTransient
+	case body.Message != "Accepted":
+		notificationErr.Class = rejectionClass(body.Message)
+	default:
+		return nil
+	}
+
+	return notificationErr
+}
+
+// rejectionClass tells a refused recipient from any other refusal by the
+// message of the provider.
+func rejectionClass(message string) entity.ErrorClass {
+	message = strings.ToLower(message)
+	for _, word := range []string{"recipient", "phone", "number", "msisdn"} {
+		if strings.Contains(message, word) {
+			return entity.ErrorClassInvalidRecipient
+		}
+	}
+	return entity.ErrorClassRejected
+}
+
+// parseRetryAfter reads a Retry-After header, either seconds or an HTTP
+// date, 0 when it is missing or invalid.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return max(0, time.Until(at))
+	}
+
+	return 0
 }
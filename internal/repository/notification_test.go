@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		body       string
+		wantID     string
+		wantClass  entity.ErrorClass
+		wantDelay  time.Duration
+		wantDetail string
+	}{
+		{
+			name:   "Accepted",
+			status: http.StatusAccepted,
+			body:   `{"message":"Accepted","messageId":"67f2f8a8-ea58-4ed0-a6f9-ff217df4d849"}`,
+			wantID: "67f2f8a8-ea58-4ed0-a6f9-ff217df4d849",
+		},
+		{
+			name:       "RateLimited",
+			status:     http.StatusTooManyRequests,
+			retryAfter: "7",
+			body:       `{"message":"Slow down"}`,
+			wantClass:  entity.ErrorClassRateLimited,
+			wantDelay:  7 * time.Second,
+			wantDetail: "Slow down",
+		},
+		{
+			name:       "RateLimitedInvalidRetryAfter",
+			status:     http.StatusTooManyRequests,
+			retryAfter: "soon",
+			wantClass:  entity.ErrorClassRateLimited,
+			wantDetail: "Too Many Requests",
+		},
+		{
+			name:       "Unauthorized",
+			status:     http.StatusUnauthorized,
+			body:       `{"message":"Invalid token"}`,
+			wantClass:  entity.ErrorClassAuthFailure,
+			wantDetail: "Invalid token",
+		},
+		{
+			name:       "Forbidden",
+			status:     http.StatusForbidden,
+			wantClass:  entity.ErrorClassAuthFailure,
+			wantDetail: "Forbidden",
+		},
+		{
+			name:       "RequestTimeout",
+			status:     http.StatusRequestTimeout,
+			wantClass:  entity.ErrorClassTransient,
+			wantDetail: "Request Timeout",
+		},
+		{
+			name:       "ServerError",
+			status:     http.StatusInternalServerError,
+			body:       `<html>upstream crashed</html>`,
+			wantClass:  entity.ErrorClassTransient,
+			wantDetail: "Internal Server Error",
+		},
+		{
+			name:       "Unavailable",
+			status:     http.StatusServiceUnavailable,
+			body:       `{"message":"Maintenance"}`,
+			wantClass:  entity.ErrorClassTransient,
+			wantDetail: "Maintenance",
+		},
+		{
+			name:       "InvalidRecipient",
+			status:     http.StatusBadRequest,
+			body:       `{"message":"Invalid phone number"}`,
+			wantClass:  entity.ErrorClassInvalidRecipient,
+			wantDetail: "Invalid phone number",
+		},
+		{
+			name:       "Rejected",
+			status:     http.StatusUnprocessableEntity,
+			body:       `{"message":"Content too long"}`,
+			wantClass:  entity.ErrorClassRejected,
+			wantDetail: "Content too long",
+		},
+		{
+			name:       "RejectedUnparseable",
+			status:     http.StatusNotFound,
+			body:       `not json`,
+			wantClass:  entity.ErrorClassRejected,
+			wantDetail: "Not Found",
+		},
+		{
+			name:       "UnparseableSuccess",
+			status:     http.StatusAccepted,
+			body:       `not json`,
+			wantClass:  entity.ErrorClassTransient,
+			wantDetail: "unreadable response: invalid character 'o' in literal null (expecting 'u')",
+		},
+		{
+			name:       "NotAccepted",
+			status:     http.StatusOK,
+			body:       `{"message":"Queued for review","messageId":"67f2f8a8"}`,
+			wantClass:  entity.ErrorClassRejected,
+			wantDetail: "Queued for review",
+		},
+		{
+			name:       "NotAcceptedRecipient",
+			status:     http.StatusOK,
+			body:       `{"message":"Recipient opted out"}`,
+			wantClass:  entity.ErrorClassInvalidRecipient,
+			wantDetail: "Recipient opted out",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			notification := NewNotificationService(resty.New(), "key", server.URL)
+			messageID, err := notification.SendNotification(context.Background(), entity.Message{
+				PhoneNumber: "+905551111111",
+				Content:     "Your code is 1234",
+			})
+
+			if tt.wantClass == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantID, messageID)
+				return
+			}
+
+			var notificationErr *entity.NotificationError
+			require.True(t, errors.As(err, &notificationErr), "unexpected error %v", err)
+			assert.Empty(t, messageID)
+			assert.Equal(t, tt.wantClass, notificationErr.Class)
+			assert.Equal(t, tt.status, notificationErr.StatusCode)
+			assert.Equal(t, tt.wantDelay, notificationErr.RetryAfter)
+			assert.Equal(t, tt.wantDetail, notificationErr.Detail)
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "Missing", value: "", want: 0},
+		{name: "Seconds", value: "120", want: 2 * time.Minute},
+		{name: "Zero", value: "0", want: 0},
+		{name: "Negative", value: "-5", want: 0},
+		{name: "Invalid", value: "later", want: 0},
+		{name: "PastDate", value: "Sun, 01 Mar 2020 12:00:00 GMT", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseRetryAfter(tt.value))
+		})
+	}
+
+	// A date is relative to now
+	at := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	assert.InDelta(t, time.Hour, parseRetryAfter(at), float64(2*time.Second))
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"gorm.io/gorm"
+)
+
+type partitionRepository struct {
+	db *gorm.DB
+}
+
+func NewPartitionRepository(db *gorm.DB) interfaces.PartitionRepository {
+	return &partitionRepository{
+		db: db,
+	}
+}
+
+// CreatePartitions makes sure the current month and the monthsAhead next
+// ones have a partition, it returns the partitions it created.
+func (r *partitionRepository) CreatePartitions(ctx context.Context, monthsAhead int) ([]string, error) {
+	var created []string
+
+	err := r.db.WithContext(ctx).
+		Raw("SELECT create_message_partitions(?)", monthsAhead).
+		Scan(&created).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to create message partitions: %w", err)
+	}
+
+	return created, nil
+}
+
+// DropPartitions detaches and drops the partitions older than keepMonths
+// months, it returns the partitions it dropped.
+func (r *partitionRepository) DropPartitions(ctx context.Context, keepMonths int) ([]string, error) {
+	var dropped []string
+
+	err := r.db.WithContext(ctx).
+		Raw("SELECT drop_message_partitions(?)", keepMonths).
+		Scan(&dropped).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to drop message partitions: %w", err)
+	}
+
+	return dropped, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type quietHoursRepository struct {
+	db *gorm.DB
+}
+
+func NewQuietHoursRepository(db *gorm.DB) interfaces.QuietHoursRepository {
+	return &quietHoursRepository{
+		db: db,
+	}
+}
+
+// Upsert creates the quiet hours of the tenant and category, or replaces
+// the window when they already exist.
+func (r *quietHoursRepository) Upsert(ctx context.Context, quietHours *entity.QuietHours) error {
+	now := time.Now()
+	quietHours.UpdatedAt = &now
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "category"}},
+			DoUpdates: clause.AssignmentColumns([]string{"start_time", "end_time", "updated_at"}),
+		}).
+		Create(quietHours).Error
+	if err != nil {
+		return fmt.Errorf("failed to save quiet hours: %w", err)
+	}
+
+	// The id isn't returned when the row already existed
+	err = r.db.WithContext(ctx).
+		Where("tenant_id = ? AND category = ?", quietHours.TenantID, quietHours.Category).
+		First(quietHours).Error
+	if err != nil {
+		return fmt.Errorf("failed to load saved quiet hours: %w", err)
+	}
+
+	return nil
+}
+
+func (r *quietHoursRepository) Delete(ctx context.Context, id uint64) error {
+	result := r.db.WithContext(ctx).
+		Delete(&entity.QuietHours{}, id)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete quiet hours with id %d: %w", id, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("quiet hours with id %d: %w", id, entity.ErrNotFound)
+	}
+
+	return nil
+}
+
+// List returns the quiet hours of tenantID, of every tenant when it is empty.
+func (r *quietHoursRepository) List(ctx context.Context, tenantID string) ([]entity.QuietHours, error) {
+	query := r.db.WithContext(ctx)
+	if tenantID != "" {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+
+	var quietHours []entity.QuietHours
+
+	err := query.
+		Order("tenant_id, category").
+		Find(&quietHours).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return quietHours, nil
+}
@@ -0,0 +1,398 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/constant"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/go-redis/redis"
+)
+
+// redisQueueField is the field of a stream entry holding the message id.
+const redisQueueField = "id"
+
+type redisMessageQueue struct {
+	client            *redis.Client
+	messageRepository interfaces.MessageRepository
+	config            entity.QueueConfig
+
+	groupReady atomic.Bool
+
+	mu sync.Mutex
+	// entries holds the stream entries of the claimed messages until they
+	// are acked
+	entries map[uint64][]string
+	// sweepCursor is the message id the next sweep of this replica starts after
+	sweepCursor uint64
+}
+
+// NewRedisMessageQueue hands the ids of the due messages out through a
+// Redis stream consumer group. Claiming an entry still goes through
+// Postgres, which only claims the messages that are due, so a stale or
+// duplicate entry never sends a message twice. Entries are acked once the
+// status of their message has been updated, the ones left unacked by a
+// replica that died are reclaimed after config.ReclaimIdle along with
+// their message when that replica had claimed it.
+//
+// Messages that become due without being enqueued, campaign messages and
+// retries among others, are enqueued by a sweep of Postgres that one
+// replica runs every config.SweepInterval.
+func NewRedisMessageQueue(
+	client *redis.Client,
+	messageRepository interfaces.MessageRepository,
+	config entity.QueueConfig,
+) interfaces.MessageQueue {
+	return &redisMessageQueue{
+		client:            client,
+		messageRepository: messageRepository,
+		config:            config,
+		entries:           map[uint64][]string{},
+	}
+}
+
+// Enqueue adds an entry for every message of ids that isn't in the stream
+// yet, a marker key per message keeps the sweeps from adding it twice.
+func (q *redisMessageQueue) Enqueue(_ context.Context, ids ...uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	markers := make([]*redis.BoolCmd, len(ids))
+	_, err := q.client.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			markers[i] = pipe.SetNX(q.markerKey(id), q.config.Consumer, constant.QueueMarkerTTL)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark enqueued messages: %w", err)
+	}
+
+	var added []string
+	_, err = q.client.Pipelined(func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			if !markers[i].Val() {
+				continue
+			}
+			pipe.XAdd(&redis.XAddArgs{
+				Stream: q.config.Stream,
+				Values: map[string]any{redisQueueField: id},
+			})
+			added = append(added, q.markerKey(id))
+		}
+		return nil
+	})
+	if err != nil {
+		// Without their entry the markers would hold the messages back
+		// from the sweeps until they expire
+		if len(added) > 0 {
+			_ = q.client.Del(added...).Err()
+		}
+		return fmt.Errorf("failed to enqueue messages: %w", err)
+	}
+
+	return nil
+}
+
+// Claim reclaims the entries other consumers left unacked for too long,
+// reads new ones to fill batch and claims their messages in Postgres. The
+// entries of messages that can't be claimed, already sent, claimed through
+// another entry or not due anymore, are dropped.
+func (q *redisMessageQueue) Claim(c context.Context, batch int) ([]entity.Message, error) {
+	if batch <= 0 {
+		return nil, errors.New("batch size must be greater than 0")
+	}
+
+	if err := q.ensureGroup(); err != nil {
+		return nil, err
+	}
+	q.sweep(c)
+
+	reclaimed, err := q.reclaim(batch)
+	if err != nil {
+		return nil, err
+	}
+	var read []redis.XMessage
+	if len(reclaimed) < batch {
+		read, err = q.read(batch - len(reclaimed))
+		if err != nil {
+			// The reclaimed entries are reclaimed again later
+			return nil, err
+		}
+	}
+
+	// The consumer a reclaimed entry comes from may have claimed its message
+	// and stopped before acking it, the message is taken over once it has
+	// been processing for as long as the entry was idle
+	stuckBefore := time.Now().Add(-time.Duration(q.config.ReclaimIdle) * time.Second)
+	messages, err := q.claimEntries(c, reclaimed, func(c context.Context, ids []uint64) ([]entity.Message, error) {
+		return q.messageRepository.ReclaimByIDs(c, ids, stuckBefore)
+	})
+	if err != nil {
+		// Left unacked, the entries are reclaimed after ReclaimIdle
+		return nil, err
+	}
+
+	claimed, err := q.claimEntries(c, read, q.messageRepository.ClaimByIDs)
+	if err != nil {
+		log.FromCtx(c).Warn("Failed to claim the messages of new stream entries", "entries", len(read), "error", err)
+		return messages, nil
+	}
+
+	return append(messages, claimed...), nil
+}
+
+// claimEntries claims the messages of entries with claim and keeps their
+// entries until they are acked, the entries of the messages claim refuses
+// are dropped.
+func (q *redisMessageQueue) claimEntries(
+	c context.Context,
+	entries []redis.XMessage,
+	claim func(context.Context, []uint64) ([]entity.Message, error),
+) ([]entity.Message, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	var (
+		ids      []uint64
+		entryIDs = map[uint64][]string{}
+		dropped  []string
+	)
+	for _, entry := range entries {
+		id, parseErr := strconv.ParseUint(fmt.Sprint(entry.Values[redisQueueField]), 10, 64)
+		if parseErr != nil {
+			dropped = append(dropped, entry.ID)
+			continue
+		}
+		if _, ok := entryIDs[id]; !ok {
+			ids = append(ids, id)
+		}
+		entryIDs[id] = append(entryIDs[id], entry.ID)
+	}
+
+	messages, err := claim(c, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// The markers go with the claim so released messages can be enqueued again
+	markers := make([]string, 0, len(ids))
+	q.mu.Lock()
+	for _, message := range messages {
+		q.entries[message.ID] = append(q.entries[message.ID], entryIDs[message.ID]...)
+		delete(entryIDs, message.ID)
+		markers = append(markers, q.markerKey(message.ID))
+	}
+	q.mu.Unlock()
+
+	for id, unclaimed := range entryIDs {
+		dropped = append(dropped, unclaimed...)
+		markers = append(markers, q.markerKey(id))
+	}
+
+	if err = q.remove(dropped, markers); err != nil {
+		log.FromCtx(c).Warn("Failed to drop stream entries", "entries", len(dropped), "error", err)
+	}
+
+	return messages, nil
+}
+
+// Ack acks and deletes the entries the messages of ids were claimed through.
+func (q *redisMessageQueue) Ack(_ context.Context, ids ...uint64) error {
+	var entryIDs []string
+	q.mu.Lock()
+	for _, id := range ids {
+		entryIDs = append(entryIDs, q.entries[id]...)
+		delete(q.entries, id)
+	}
+	q.mu.Unlock()
+
+	if err := q.remove(entryIDs, nil); err != nil {
+		return fmt.Errorf("failed to ack messages: %w", err)
+	}
+	return nil
+}
+
+// ensureGroup creates the consumer group, and the stream along with it,
+// the first time. The group starts at the beginning of the stream so
+// entries added before it existed are read.
+func (q *redisMessageQueue) ensureGroup() error {
+	if q.groupReady.Load() {
+		return nil
+	}
+
+	err := q.client.XGroupCreateMkStream(q.config.Stream, q.config.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("failed to create consumer group %s: %w", q.config.Group, err)
+	}
+
+	q.groupReady.Store(true)
+	return nil
+}
+
+// sweep enqueues the due messages missing from the stream, one page of
+// config.SweepBatch after the other. The sweep key lets a single replica
+// sweep per config.SweepInterval.
+func (q *redisMessageQueue) sweep(c context.Context) {
+	logger := log.FromCtx(c).WithFields("action", "Sweep message queue")
+
+	interval := time.Duration(q.config.SweepInterval) * time.Second
+	acquired, err := q.client.SetNX(q.sweepKey(), q.config.Consumer, interval).Result()
+	if err != nil {
+		logger.Warn("Failed to acquire the sweep", "error", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	q.mu.Lock()
+	cursor := q.sweepCursor
+	q.mu.Unlock()
+
+	ids, err := q.messageRepository.ListDueIDs(c, cursor, q.config.SweepBatch)
+	if err != nil {
+		logger.Error("Failed to list due messages", "error", err)
+		return
+	}
+
+	// A short page is the end of the backlog, the next sweep starts over
+	next := uint64(0)
+	if len(ids) == q.config.SweepBatch {
+		next = ids[len(ids)-1]
+	}
+	q.mu.Lock()
+	q.sweepCursor = next
+	q.mu.Unlock()
+
+	if err = q.Enqueue(c, ids...); err != nil {
+		logger.Error("Failed to enqueue due messages", "error", err)
+		return
+	}
+
+	logger.Debug("Message queue swept", "due", len(ids), "after", cursor)
+}
+
+// reclaim takes over the entries other consumers read more than
+// config.ReclaimIdle ago without acking them, they most likely stopped.
+func (q *redisMessageQueue) reclaim(count int) ([]redis.XMessage, error) {
+	idle := time.Duration(q.config.ReclaimIdle) * time.Second
+	reply, err := q.client.Do(
+		"xautoclaim", q.config.Stream, q.config.Group, q.config.Consumer,
+		idle.Milliseconds(), "0-0", "count", count,
+	).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reclaim stream entries: %w", err)
+	}
+
+	entries, err := parseAutoClaim(reply)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reclaim stream entries: %w", err)
+	}
+	return entries, nil
+}
+
+// read reads up to count entries no consumer of the group has read yet,
+// without blocking.
+func (q *redisMessageQueue) read(count int) ([]redis.XMessage, error) {
+	streams, err := q.client.XReadGroup(&redis.XReadGroupArgs{
+		Group:    q.config.Group,
+		Consumer: q.config.Consumer,
+		Streams:  []string{q.config.Stream, ">"},
+		Count:    int64(count),
+		Block:    -1,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the message stream: %w", err)
+	}
+
+	var entries []redis.XMessage
+	for _, stream := range streams {
+		entries = append(entries, stream.Messages...)
+	}
+	return entries, nil
+}
+
+// remove acks and deletes entryIDs and deletes the marker keys, in one
+// transaction. Acked entries are deleted so the stream only holds the
+// messages waiting to be sent.
+func (q *redisMessageQueue) remove(entryIDs []string, markers []string) error {
+	if len(entryIDs) == 0 && len(markers) == 0 {
+		return nil
+	}
+
+	_, err := q.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		if len(entryIDs) > 0 {
+			pipe.XAck(q.config.Stream, q.config.Group, entryIDs...)
+			pipe.XDel(q.config.Stream, entryIDs...)
+		}
+		if len(markers) > 0 {
+			pipe.Del(markers...)
+		}
+		return nil
+	})
+	return err
+}
+
+func (q *redisMessageQueue) markerKey(id uint64) string {
+	return q.config.Stream + ":queued:" + strconv.FormatUint(id, 10)
+}
+
+func (q *redisMessageQueue) sweepKey() string {
+	return q.config.Stream + ":sweep"
+}
+
+// parseAutoClaim reads the entries of an XAUTOCLAIM reply,
+// [next cursor, [[id, [field, value, ...]], ...], deleted ids]. Entries
+// deleted from the stream while pending come without fields before
+// Redis 7, they are returned without values.
+func parseAutoClaim(reply any) ([]redis.XMessage, error) {
+	parts, ok := reply.([]any)
+	if !ok || len(parts) < 2 {
+		return nil, fmt.Errorf("unexpected XAUTOCLAIM reply %T", reply)
+	}
+
+	list, ok := parts[1].([]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected XAUTOCLAIM entries %T", parts[1])
+	}
+
+	entries := make([]redis.XMessage, 0, len(list))
+	for _, item := range list {
+		fields, ok := item.([]any)
+		if !ok || len(fields) == 0 {
+			return nil, fmt.Errorf("unexpected XAUTOCLAIM entry %T", item)
+		}
+
+		id, ok := fields[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected XAUTOCLAIM entry id %T", fields[0])
+		}
+
+		entry := redis.XMessage{ID: id, Values: map[string]any{}}
+		if len(fields) > 1 {
+			values, _ := fields[1].([]any)
+			for i := 0; i+1 < len(values); i += 2 {
+				if key, ok := values[i].(string); ok {
+					entry.Values[key] = values[i+1]
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,204 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/repository"
+	"github.com/craftaholic/insider/internal/repository/memory"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRedis connects to the redis of TEST_REDIS_ADDR, host:port.
+func testRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR isn't set")
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { _ = client.Close() })
+	require.NoError(t, client.Ping().Err())
+	return client
+}
+
+// newRedisQueue returns a queue over a stream of its own, along with a
+// config for more consumers of the same stream.
+func newRedisQueue(
+	t *testing.T,
+	client *redis.Client,
+	messages interfaces.MessageRepository,
+) (interfaces.MessageQueue, entity.QueueConfig) {
+	t.Helper()
+
+	stream := fmt.Sprintf("test:%s:%d", t.Name(), time.Now().UnixNano())
+	t.Cleanup(func() {
+		keys, _ := client.Keys(stream + "*").Result()
+		if len(keys) > 0 {
+			_ = client.Del(keys...).Err()
+		}
+	})
+
+	config := entity.QueueConfig{
+		Backend:       entity.QueueRedis,
+		Stream:        stream,
+		Group:         "senders",
+		Consumer:      "first",
+		ReclaimIdle:   1,
+		SweepInterval: 3600,
+		SweepBatch:    100,
+	}
+	return repository.NewRedisMessageQueue(client, messages, config), config
+}
+
+func createPending(t *testing.T, messages interfaces.MessageRepository, count int) []uint64 {
+	t.Helper()
+
+	ids := make([]uint64, 0, count)
+	for i := range count {
+		message := entity.Message{PhoneNumber: fmt.Sprintf("+9055500%05d", i), Content: "queued"}
+		require.NoError(t, messages.Create(context.Background(), &message))
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+func claimedIDs(messages []entity.Message) []uint64 {
+	ids := make([]uint64, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+func TestRedisMessageQueue(t *testing.T) {
+	client := testRedis(t)
+	ctx := context.Background()
+
+	t.Run("EnqueueClaimAck", func(t *testing.T) {
+		messages := memory.NewMessageRepository(nil, nil)
+		queue, config := newRedisQueue(t, client, messages)
+
+		// The first claim sweeps the messages created before the queue
+		swept := createPending(t, messages, 2)
+		claimed, err := queue.Claim(ctx, 10)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, swept, claimedIDs(claimed))
+
+		// Enqueueing twice adds a single entry
+		ids := createPending(t, messages, 3)
+		require.NoError(t, queue.Enqueue(ctx, ids...))
+		require.NoError(t, queue.Enqueue(ctx, ids...))
+
+		claimed, err = queue.Claim(ctx, 2)
+		require.NoError(t, err)
+		assert.Len(t, claimed, 2)
+		more, err := queue.Claim(ctx, 2)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, ids, claimedIDs(append(claimed, more...)))
+
+		require.NoError(t, queue.Ack(ctx, append(swept, ids...)...))
+		length, err := client.XLen(config.Stream).Result()
+		require.NoError(t, err)
+		assert.Zero(t, length)
+	})
+
+	t.Run("DropsUnclaimable", func(t *testing.T) {
+		messages := memory.NewMessageRepository(nil, nil)
+		queue, config := newRedisQueue(t, client, messages)
+		_, err := queue.Claim(ctx, 1)
+		require.NoError(t, err)
+
+		ids := createPending(t, messages, 2)
+		require.NoError(t, messages.UpdateSelective(ctx, ids[0], map[string]any{"status": entity.StatusSent}))
+		require.NoError(t, queue.Enqueue(ctx, ids...))
+
+		claimed, err := queue.Claim(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{ids[1]}, claimedIDs(claimed))
+
+		// The entry of the sent message is gone, the claimed one waits for its ack
+		length, err := client.XLen(config.Stream).Result()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), length)
+	})
+
+	t.Run("Reclaim", func(t *testing.T) {
+		messages := memory.NewMessageRepository(nil, nil)
+		queue, config := newRedisQueue(t, client, messages)
+		_, err := queue.Claim(ctx, 1)
+		require.NoError(t, err)
+
+		ids := createPending(t, messages, 1)
+		require.NoError(t, queue.Enqueue(ctx, ids...))
+
+		// The first consumer reads the entry and stops before claiming it
+		_, err = client.XReadGroup(&redis.XReadGroupArgs{
+			Group:    config.Group,
+			Consumer: config.Consumer,
+			Streams:  []string{config.Stream, ">"},
+			Count:    1,
+			Block:    -1,
+		}).Result()
+		require.NoError(t, err)
+
+		config.Consumer = "second"
+		second := repository.NewRedisMessageQueue(client, messages, config)
+
+		claimed, err := second.Claim(ctx, 10)
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+
+		time.Sleep(time.Duration(config.ReclaimIdle)*time.Second + 100*time.Millisecond)
+
+		claimed, err = second.Claim(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, ids, claimedIDs(claimed))
+	})
+
+	t.Run("ReclaimClaimed", func(t *testing.T) {
+		messages := memory.NewMessageRepository(nil, nil)
+		queue, config := newRedisQueue(t, client, messages)
+		_, err := queue.Claim(ctx, 1)
+		require.NoError(t, err)
+
+		ids := createPending(t, messages, 2)
+		require.NoError(t, queue.Enqueue(ctx, ids...))
+
+		// The first consumer claims the messages and stops before acking them
+		claimed, err := queue.Claim(ctx, 10)
+		require.NoError(t, err)
+		require.ElementsMatch(t, ids, claimedIDs(claimed))
+
+		config.Consumer = "second"
+		second := repository.NewRedisMessageQueue(client, messages, config)
+
+		claimed, err = second.Claim(ctx, 10)
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+
+		// One of them has been sent before the first consumer stopped, the
+		// other one is left processing
+		require.NoError(t, messages.UpdateSelective(ctx, ids[0], map[string]any{"status": entity.StatusSent}))
+
+		time.Sleep(time.Duration(config.ReclaimIdle)*time.Second + 100*time.Millisecond)
+
+		claimed, err = second.Claim(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, ids[1:], claimedIDs(claimed))
+
+		require.NoError(t, second.Ack(ctx, ids[1]))
+		length, err := client.XLen(config.Stream).Result()
+		require.NoError(t, err)
+		assert.Zero(t, length)
+	})
+}
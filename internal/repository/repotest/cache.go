@@ -0,0 +1,46 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCacheRepository runs the suite against cache. Keys are prefixed with
+// the name of the test, cache may be shared with other tests.
+func TestCacheRepository(t *testing.T, cache interfaces.CacheRepository) {
+	key := func(name string) string { return t.Name() + ":" + name }
+
+	t.Run("Missing", func(t *testing.T) {
+		_, err := cache.Get(key("missing"))
+		require.ErrorIs(t, err, entity.ErrNotFound)
+	})
+
+	t.Run("SetGet", func(t *testing.T) {
+		require.NoError(t, cache.Set(key("value"), []byte("first"), time.Minute))
+		require.NoError(t, cache.Set(key("value"), []byte("second"), time.Minute))
+
+		value, err := cache.Get(key("value"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("second"), value)
+	})
+
+	t.Run("Expire", func(t *testing.T) {
+		require.NoError(t, cache.Set(key("expiring"), []byte("value"), 50*time.Millisecond))
+		require.NoError(t, cache.Set(key("kept"), []byte("value"), 0))
+
+		_, err := cache.Get(key("expiring"))
+		require.NoError(t, err)
+
+		time.Sleep(100 * time.Millisecond)
+
+		_, err = cache.Get(key("expiring"))
+		require.ErrorIs(t, err, entity.ErrNotFound)
+		_, err = cache.Get(key("kept"))
+		require.NoError(t, err)
+	})
+}
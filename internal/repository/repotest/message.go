@@ -0,0 +1,756 @@
+// Package repotest is the conformance suite of the repositories. Every
+// implementation of a repository runs the suite from its tests, so the
+// in-memory ones can stand in for Postgres and Redis.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MessageStore is a message repository under test, empty, along with the
+// way to create the campaigns its messages belong to.
+type MessageStore struct {
+	Repository interfaces.MessageRepository
+	// CreateCampaign adds a campaign in status and returns its id
+	CreateCampaign func(t *testing.T, status entity.CampaignStatus) uint64
+}
+
+// TestMessageRepository runs the suite against the stores newStore returns,
+// a new empty one for every test.
+func TestMessageRepository(t *testing.T, newStore func(t *testing.T) MessageStore) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, store MessageStore)
+	}{
+		{"Create", testCreate},
+		{"Update", testUpdate},
+		{"UpdateSelective", testUpdateSelective},
+		{"UpdateSentByMessageID", testUpdateSentByMessageID},
+		{"GetPending", testGetPending},
+		{"GetPendingSkipsDeferred", testGetPendingSkipsDeferred},
+		{"GetPendingCampaigns", testGetPendingCampaigns},
+		{"GetPendingConcurrent", testGetPendingConcurrent},
+		{"GetPendingOrdered", testGetPendingOrdered},
+		{"GetPendingOrderedHeldBack", testGetPendingOrderedHeldBack},
+		{"GetPendingOrderedConcurrent", testGetPendingOrderedConcurrent},
+		{"ClaimByIDs", testClaimByIDs},
+		{"ClaimByIDsConcurrent", testClaimByIDsConcurrent},
+		{"ReclaimByIDs", testReclaimByIDs},
+		{"ListDueIDs", testListDueIDs},
+		{"Release", testRelease},
+		{"Requeue", testRequeue},
+		{"ResetStuck", testResetStuck},
+		{"CountPending", testCountPending},
+		{"GetSentWithPagination", testGetSentWithPagination},
+		{"Export", testExport},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStore(t))
+		})
+	}
+}
+
+// base is the creation time of the first message of a test, the next ones
+// are created a second apart so they are claimed in a known order.
+var base = time.Now().Add(-time.Hour).Truncate(time.Second)
+
+func createMessages(t *testing.T, repository interfaces.MessageRepository, messages ...entity.Message) []entity.Message {
+	t.Helper()
+
+	for i := range messages {
+		if messages[i].PhoneNumber == "" {
+			messages[i].PhoneNumber = fmt.Sprintf("+9055500%05d", i)
+		}
+		if messages[i].Content == "" {
+			messages[i].Content = fmt.Sprintf("message %d", i)
+		}
+		if messages[i].CreatedAt.IsZero() {
+			messages[i].CreatedAt = base.Add(time.Duration(i) * time.Second)
+		}
+		require.NoError(t, repository.Create(context.Background(), &messages[i]))
+	}
+	return messages
+}
+
+func ids(messages []entity.Message) []uint64 {
+	ids := make([]uint64, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+func exportAll(t *testing.T, repository interfaces.MessageRepository) map[uint64]entity.Message {
+	t.Helper()
+
+	messages := map[uint64]entity.Message{}
+	err := repository.Export(context.Background(), entity.MessageFilter{}, func(message entity.Message) error {
+		messages[message.ID] = message
+		return nil
+	})
+	require.NoError(t, err)
+	return messages
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func testCreate(t *testing.T, store MessageStore) {
+	messages := createMessages(t, store.Repository, entity.Message{}, entity.Message{})
+
+	require.NotZero(t, messages[0].ID)
+	assert.NotEqual(t, messages[0].ID, messages[1].ID)
+
+	stored := exportAll(t, store.Repository)[messages[0].ID]
+	assert.Equal(t, messages[0].PhoneNumber, stored.PhoneNumber)
+	assert.Equal(t, messages[0].Content, stored.Content)
+	assert.Equal(t, entity.StatusPending, stored.Status)
+	assert.Equal(t, entity.DefaultTenantID, stored.TenantID)
+	assert.Equal(t, entity.DefaultChannel, stored.Channel)
+	assert.Equal(t, 1, stored.Segments)
+	assert.Equal(t, entity.EncodingGSM7, stored.Encoding)
+	assert.Equal(t, entity.CategoryTransactional, stored.Category)
+	assert.WithinDuration(t, messages[0].CreatedAt, stored.CreatedAt, time.Millisecond)
+}
+
+func testUpdate(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	messages := createMessages(t, store.Repository, entity.Message{})
+
+	// Zero fields are left as they are
+	err := store.Repository.Update(ctx, messages[0].ID, entity.Message{Status: entity.StatusProcessing})
+	require.NoError(t, err)
+
+	stored := exportAll(t, store.Repository)[messages[0].ID]
+	assert.Equal(t, entity.StatusProcessing, stored.Status)
+	assert.Equal(t, messages[0].Content, stored.Content)
+	assert.NotNil(t, stored.UpdatedAt)
+
+	err = store.Repository.Update(ctx, messages[0].ID+1000, entity.Message{Status: entity.StatusPending})
+	assert.Error(t, err)
+}
+
+func testUpdateSelective(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	messages := createMessages(t, store.Repository, entity.Message{}, entity.Message{})
+	sentAt := base.Add(time.Minute)
+
+	err := store.Repository.UpdateSelective(ctx, messages[0].ID, map[string]any{
+		"status":        entity.StatusFailed,
+		"sent_at":       sentAt,
+		"message_id":    "provider-id",
+		"error_message": "refused",
+		"error_class":   entity.ErrorClassRejected,
+		"attempts":      2,
+		"updated_at":    sentAt,
+	})
+	require.NoError(t, err)
+
+	stored := exportAll(t, store.Repository)
+	updated := stored[messages[0].ID]
+	assert.Equal(t, entity.StatusFailed, updated.Status)
+	require.NotNil(t, updated.SentAt)
+	assert.WithinDuration(t, sentAt, *updated.SentAt, time.Millisecond)
+	assert.Equal(t, ptr("provider-id"), updated.MessageID)
+	assert.Equal(t, ptr("refused"), updated.ErrorMessage)
+	assert.Equal(t, ptr(entity.ErrorClassRejected), updated.ErrorClass)
+	assert.Equal(t, 2, updated.Attempts)
+	assert.Equal(t, entity.StatusPending, stored[messages[1].ID].Status)
+
+	// A nil value clears the column
+	err = store.Repository.UpdateSelective(ctx, messages[0].ID, map[string]any{"error_message": nil})
+	require.NoError(t, err)
+	assert.Nil(t, exportAll(t, store.Repository)[messages[0].ID].ErrorMessage)
+
+	err = store.Repository.UpdateSelective(ctx, messages[1].ID+1000, map[string]any{"status": entity.StatusSent})
+	assert.Error(t, err)
+}
+
+func testUpdateSentByMessageID(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	messages := createMessages(t, store.Repository,
+		entity.Message{Status: entity.StatusSent, MessageID: ptr("sent-id")},
+		entity.Message{Status: entity.StatusProcessing, MessageID: ptr("processing-id")},
+	)
+
+	updated, err := store.Repository.UpdateSentByMessageID(ctx, "sent-id", map[string]any{
+		"status":     entity.StatusDelivered,
+		"updated_at": time.Now(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, messages[0].ID, updated.ID)
+	assert.Equal(t, entity.StatusDelivered, updated.Status)
+	assert.Equal(t, messages[0].PhoneNumber, updated.PhoneNumber)
+
+	// Only sent messages get delivery reports, a second one finds none
+	_, err = store.Repository.UpdateSentByMessageID(ctx, "sent-id", map[string]any{"status": entity.StatusFailed})
+	require.ErrorIs(t, err, entity.ErrNotFound)
+	_, err = store.Repository.UpdateSentByMessageID(ctx, "processing-id", map[string]any{"status": entity.StatusFailed})
+	require.ErrorIs(t, err, entity.ErrNotFound)
+	_, err = store.Repository.UpdateSentByMessageID(ctx, "unknown-id", map[string]any{"status": entity.StatusFailed})
+	require.ErrorIs(t, err, entity.ErrNotFound)
+
+	assert.Equal(t, entity.StatusProcessing, exportAll(t, store.Repository)[messages[1].ID].Status)
+}
+
+func testGetPending(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	messages := createMessages(t, store.Repository,
+		entity.Message{CreatedAt: base.Add(2 * time.Second)},
+		entity.Message{CreatedAt: base},
+		entity.Message{Status: entity.StatusSent, CreatedAt: base.Add(-time.Second)},
+		entity.Message{CreatedAt: base.Add(time.Second)},
+	)
+
+	_, err := store.Repository.GetPending(ctx, 0)
+	require.Error(t, err)
+
+	// Oldest first, moved to processing
+	claimed, err := store.Repository.GetPending(ctx, 2)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{messages[1].ID, messages[3].ID}, ids(claimed))
+	for _, message := range claimed {
+		assert.Equal(t, entity.StatusProcessing, message.Status)
+		assert.NotNil(t, message.UpdatedAt)
+	}
+
+	claimed, err = store.Repository.GetPending(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{messages[0].ID}, ids(claimed))
+
+	claimed, err = store.Repository.GetPending(ctx, 2)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	stored := exportAll(t, store.Repository)
+	assert.Equal(t, entity.StatusProcessing, stored[messages[0].ID].Status)
+	assert.Equal(t, entity.StatusSent, stored[messages[2].ID].Status)
+}
+
+func testGetPendingSkipsDeferred(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	messages := createMessages(t, store.Repository,
+		entity.Message{ScheduledAt: ptr(time.Now().Add(time.Hour))},
+		entity.Message{ScheduledAt: ptr(time.Now().Add(-time.Minute))},
+	)
+
+	claimed, err := store.Repository.GetPending(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{messages[1].ID}, ids(claimed))
+
+	// Once it is due the deferred message is claimed
+	err = store.Repository.UpdateSelective(ctx, messages[0].ID, map[string]any{
+		"scheduled_at": time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+
+	claimed, err = store.Repository.GetPending(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{messages[0].ID}, ids(claimed))
+}
+
+func testGetPendingCampaigns(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	running := store.CreateCampaign(t, entity.CampaignRunning)
+	paused := store.CreateCampaign(t, entity.CampaignPaused)
+	messages := createMessages(t, store.Repository,
+		entity.Message{CampaignID: &running},
+		entity.Message{CampaignID: &paused},
+		entity.Message{},
+	)
+
+	claimed, err := store.Repository.GetPending(ctx, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{messages[0].ID, messages[2].ID}, ids(claimed))
+
+	claimed, err = store.Repository.GetPendingOrdered(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+}
+
+// testGetPendingConcurrent claims from many goroutines at once, every
+// message has to be claimed exactly once.
+func testGetPendingConcurrent(t *testing.T, store MessageStore) {
+	testConcurrentClaims(t, store, store.Repository.GetPending)
+}
+
+func testGetPendingOrderedConcurrent(t *testing.T, store MessageStore) {
+	testConcurrentClaims(t, store, store.Repository.GetPendingOrdered)
+}
+
+func testConcurrentClaims(
+	t *testing.T,
+	store MessageStore,
+	getPending func(ctx context.Context, batch int) ([]entity.Message, error),
+) {
+	const (
+		count   = 200
+		workers = 8
+		batch   = 7
+	)
+
+	messages := createMessages(t, store.Repository, make([]entity.Message, count)...)
+
+	var (
+		mu      sync.Mutex
+		claims  = map[uint64]int{}
+		wg      sync.WaitGroup
+		errOnce sync.Once
+		failure error
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				claimed, err := getPending(context.Background(), batch)
+				if err != nil {
+					errOnce.Do(func() { failure = err })
+					return
+				}
+				if len(claimed) == 0 {
+					return
+				}
+
+				mu.Lock()
+				for _, message := range claimed {
+					claims[message.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, failure)
+
+	// A worker stops at its first empty claim, which another one holding
+	// the last messages may cause, the rest is claimed afterwards
+	for {
+		claimed, err := getPending(context.Background(), batch)
+		require.NoError(t, err)
+		if len(claimed) == 0 {
+			break
+		}
+		for _, message := range claimed {
+			claims[message.ID]++
+		}
+	}
+
+	assert.Len(t, claims, count)
+	for _, message := range messages {
+		assert.Equal(t, 1, claims[message.ID], "message %d claimed %d times", message.ID, claims[message.ID])
+	}
+}
+
+func testGetPendingOrdered(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	messages := createMessages(t, store.Repository,
+		entity.Message{PhoneNumber: "+905550000001"},
+		entity.Message{PhoneNumber: "+905550000001"},
+		entity.Message{PhoneNumber: "+905550000002"},
+		// The ordering key groups messages to different phone numbers
+		entity.Message{PhoneNumber: "+905550000003", OrderingKey: ptr("order-1")},
+		entity.Message{PhoneNumber: "+905550000004", OrderingKey: ptr("order-1")},
+		// An empty ordering key is no key, the phone number is used
+		entity.Message{PhoneNumber: "+905550000005", OrderingKey: ptr("")},
+		entity.Message{PhoneNumber: "+905550000006", OrderingKey: ptr("")},
+	)
+
+	_, err := store.Repository.GetPendingOrdered(ctx, 0)
+	require.Error(t, err)
+
+	claimed, err := store.Repository.GetPendingOrdered(ctx, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t,
+		[]uint64{messages[0].ID, messages[2].ID, messages[3].ID, messages[5].ID, messages[6].ID}, ids(claimed))
+
+	// The next messages of the keys wait for the ones in processing
+	claimed, err = store.Repository.GetPendingOrdered(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	for _, message := range []entity.Message{messages[0], messages[3]} {
+		err = store.Repository.UpdateSelective(ctx, message.ID, map[string]any{"status": entity.StatusSent})
+		require.NoError(t, err)
+	}
+
+	claimed, err = store.Repository.GetPendingOrdered(ctx, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{messages[1].ID, messages[4].ID}, ids(claimed))
+}
+
+func testGetPendingOrderedHeldBack(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	paused := store.CreateCampaign(t, entity.CampaignPaused)
+	messages := createMessages(t, store.Repository,
+		// A deferred message holds back the later ones of its key
+		entity.Message{PhoneNumber: "+905550000001", ScheduledAt: ptr(time.Now().Add(time.Hour))},
+		entity.Message{PhoneNumber: "+905550000001"},
+		// So does one of a campaign that isn't running
+		entity.Message{PhoneNumber: "+905550000002", CampaignID: &paused},
+		entity.Message{PhoneNumber: "+905550000002"},
+		// And one in processing, even newer than the pending ones
+		entity.Message{PhoneNumber: "+905550000003"},
+		entity.Message{PhoneNumber: "+905550000003", Status: entity.StatusProcessing},
+		entity.Message{PhoneNumber: "+905550000004"},
+	)
+
+	claimed, err := store.Repository.GetPendingOrdered(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{messages[6].ID}, ids(claimed))
+
+	// Batches are filled in creation order
+	more := createMessages(t, store.Repository,
+		entity.Message{PhoneNumber: "+905550000005", CreatedAt: base.Add(time.Minute)},
+		entity.Message{PhoneNumber: "+905550000006", CreatedAt: base.Add(time.Minute - time.Second)},
+	)
+	claimed, err = store.Repository.GetPendingOrdered(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{more[1].ID}, ids(claimed))
+}
+
+func testClaimByIDs(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	paused := store.CreateCampaign(t, entity.CampaignPaused)
+	messages := createMessages(t, store.Repository,
+		entity.Message{},
+		entity.Message{Status: entity.StatusSent},
+		entity.Message{ScheduledAt: ptr(time.Now().Add(time.Hour))},
+		entity.Message{CampaignID: &paused},
+		entity.Message{},
+		entity.Message{},
+	)
+
+	claimed, err := store.Repository.ClaimByIDs(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	// Only the ones GetPending would claim, unknown ids are ignored
+	claimed, err = store.Repository.ClaimByIDs(ctx, append(ids(messages[:5]), messages[5].ID+1000))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{messages[0].ID, messages[4].ID}, ids(claimed))
+	for _, message := range claimed {
+		assert.Equal(t, entity.StatusProcessing, message.Status)
+		assert.Contains(t, []string{messages[0].Content, messages[4].Content}, message.Content)
+	}
+
+	claimed, err = store.Repository.ClaimByIDs(ctx, ids(messages))
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{messages[5].ID}, ids(claimed))
+
+	stored := exportAll(t, store.Repository)
+	assert.Equal(t, entity.StatusSent, stored[messages[1].ID].Status)
+	assert.Equal(t, entity.StatusPending, stored[messages[2].ID].Status)
+	assert.Equal(t, entity.StatusPending, stored[messages[3].ID].Status)
+}
+
+// testClaimByIDsConcurrent claims the same ids from many goroutines at
+// once, every message has to be claimed exactly once.
+func testClaimByIDsConcurrent(t *testing.T, store MessageStore) {
+	const workers = 8
+
+	messages := createMessages(t, store.Repository, make([]entity.Message, 50)...)
+
+	var (
+		mu     sync.Mutex
+		claims = map[uint64]int{}
+		wg     sync.WaitGroup
+		errs   = make(chan error, workers)
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claimed, err := store.Repository.ClaimByIDs(context.Background(), ids(messages))
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, message := range claimed {
+				claims[message.ID]++
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	assert.Len(t, claims, len(messages))
+	for _, message := range messages {
+		assert.Equal(t, 1, claims[message.ID], "message %d claimed %d times", message.ID, claims[message.ID])
+	}
+}
+
+func testReclaimByIDs(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	old, recent := ptr(time.Now().Add(-time.Hour)), ptr(time.Now())
+	messages := createMessages(t, store.Repository,
+		entity.Message{},
+		entity.Message{Status: entity.StatusProcessing, UpdatedAt: old},
+		entity.Message{Status: entity.StatusProcessing, UpdatedAt: recent},
+		entity.Message{Status: entity.StatusSent, UpdatedAt: old},
+		entity.Message{Status: entity.StatusProcessing, UpdatedAt: old},
+	)
+
+	claimed, err := store.Repository.ReclaimByIDs(ctx, nil, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	// Due messages and the ones processing since before the limit, among ids
+	claimed, err = store.Repository.ReclaimByIDs(ctx, ids(messages[:4]), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{messages[0].ID, messages[1].ID}, ids(claimed))
+	for _, message := range claimed {
+		assert.Equal(t, entity.StatusProcessing, message.Status)
+		require.NotNil(t, message.UpdatedAt)
+		assert.WithinDuration(t, time.Now(), *message.UpdatedAt, time.Minute)
+	}
+
+	// Taken over, they aren't stale anymore
+	claimed, err = store.Repository.ReclaimByIDs(ctx, ids(messages[:4]), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	stored := exportAll(t, store.Repository)
+	assert.Equal(t, entity.StatusSent, stored[messages[3].ID].Status)
+	assert.Equal(t, entity.StatusProcessing, stored[messages[4].ID].Status)
+}
+
+func testListDueIDs(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	running := store.CreateCampaign(t, entity.CampaignRunning)
+	messages := createMessages(t, store.Repository,
+		entity.Message{CreatedAt: base.Add(time.Minute)},
+		entity.Message{Status: entity.StatusProcessing},
+		entity.Message{ScheduledAt: ptr(time.Now().Add(time.Hour))},
+		entity.Message{CampaignID: &running},
+		entity.Message{},
+	)
+
+	_, err := store.Repository.ListDueIDs(ctx, 0, 0)
+	require.Error(t, err)
+
+	// In id order whatever the creation time
+	due, err := store.Repository.ListDueIDs(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{messages[0].ID, messages[3].ID, messages[4].ID}, due)
+
+	due, err = store.Repository.ListDueIDs(ctx, 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{messages[0].ID, messages[3].ID}, due)
+
+	due, err = store.Repository.ListDueIDs(ctx, messages[3].ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{messages[4].ID}, due)
+}
+
+func testRelease(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	messages := createMessages(t, store.Repository,
+		entity.Message{},
+		entity.Message{},
+		entity.Message{Status: entity.StatusSent},
+		entity.Message{},
+	)
+
+	claimed, err := store.Repository.GetPending(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+
+	released, err := store.Repository.Release(ctx, nil)
+	require.NoError(t, err)
+	assert.Zero(t, released)
+
+	// Only processing messages go back, unknown ids are ignored
+	released, err = store.Repository.Release(ctx, append(ids(messages), messages[3].ID+1000))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, released)
+
+	stored := exportAll(t, store.Repository)
+	assert.Equal(t, entity.StatusPending, stored[messages[0].ID].Status)
+	assert.Equal(t, entity.StatusPending, stored[messages[1].ID].Status)
+	assert.Equal(t, entity.StatusSent, stored[messages[2].ID].Status)
+	assert.Equal(t, entity.StatusPending, stored[messages[3].ID].Status)
+
+	claimed, err = store.Repository.GetPending(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{messages[0].ID, messages[1].ID, messages[3].ID}, ids(claimed))
+}
+
+func testRequeue(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	errorMessage, errorClass := "rejected", entity.ErrorClassRejected
+	messages := createMessages(t, store.Repository,
+		entity.Message{Status: entity.StatusFailed, Attempts: 3, ErrorMessage: &errorMessage, ErrorClass: &errorClass},
+		entity.Message{Status: entity.StatusSent},
+	)
+
+	requeued, err := store.Repository.Requeue(ctx, messages[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, messages[0].ID, requeued.ID)
+	assert.Equal(t, entity.StatusPending, requeued.Status)
+	assert.Zero(t, requeued.Attempts)
+	assert.Nil(t, requeued.ErrorMessage)
+	assert.Nil(t, requeued.ErrorClass)
+
+	// Only failed messages go back, a sent one would be sent twice
+	for _, id := range []uint64{messages[0].ID, messages[1].ID, messages[1].ID + 1000} {
+		_, err = store.Repository.Requeue(ctx, id)
+		require.ErrorIs(t, err, entity.ErrNotFound)
+	}
+
+	claimed, err := store.Repository.GetPending(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{messages[0].ID}, ids(claimed))
+}
+
+func testResetStuck(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	old, recent := ptr(time.Now().Add(-time.Hour)), ptr(time.Now())
+	messages := createMessages(t, store.Repository,
+		entity.Message{Status: entity.StatusProcessing, UpdatedAt: old},
+		entity.Message{Status: entity.StatusProcessing, UpdatedAt: recent},
+		entity.Message{Status: entity.StatusSent, UpdatedAt: old},
+		entity.Message{Status: entity.StatusProcessing, UpdatedAt: old},
+	)
+
+	reset, err := store.Repository.ResetStuck(ctx, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint64{messages[0].ID, messages[3].ID}, reset)
+
+	stored := exportAll(t, store.Repository)
+	assert.Equal(t, entity.StatusPending, stored[messages[0].ID].Status)
+	assert.Equal(t, entity.StatusProcessing, stored[messages[1].ID].Status)
+	assert.Equal(t, entity.StatusSent, stored[messages[2].ID].Status)
+	assert.Equal(t, entity.StatusPending, stored[messages[3].ID].Status)
+
+	// Reset messages are claimed again, and aren't stuck anymore once claimed
+	claimed, err := store.Repository.GetPending(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{messages[0].ID, messages[3].ID}, ids(claimed))
+
+	reset, err = store.Repository.ResetStuck(ctx, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, reset)
+}
+
+func testCountPending(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	running := store.CreateCampaign(t, entity.CampaignRunning)
+	draft := store.CreateCampaign(t, entity.CampaignDraft)
+	createMessages(t, store.Repository,
+		entity.Message{},
+		entity.Message{ScheduledAt: ptr(time.Now().Add(-time.Minute))},
+		entity.Message{ScheduledAt: ptr(time.Now().Add(time.Hour))},
+		entity.Message{CampaignID: &running},
+		entity.Message{CampaignID: &draft},
+		entity.Message{Status: entity.StatusProcessing},
+		entity.Message{Status: entity.StatusSent},
+	)
+
+	count, err := store.Repository.CountPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	_, err = store.Repository.GetPending(ctx, 1)
+	require.NoError(t, err)
+
+	count, err = store.Repository.CountPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func testGetSentWithPagination(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	sentAt := func(minutes int) *time.Time { return ptr(base.Add(time.Duration(minutes) * time.Minute)) }
+	messages := createMessages(t, store.Repository,
+		entity.Message{Status: entity.StatusSent, SentAt: sentAt(1)},
+		entity.Message{Status: entity.StatusDelivered, SentAt: sentAt(3)},
+		entity.Message{Status: entity.StatusSent, SentAt: sentAt(2), TenantID: "other"},
+		entity.Message{Status: entity.StatusFailed, SentAt: sentAt(4)},
+		entity.Message{Status: entity.StatusPending},
+	)
+
+	_, err := store.Repository.GetSentWithPagination(ctx, entity.MessageFilter{}, 0)
+	require.Error(t, err)
+
+	// Latest sent first, whatever filter.Statuses says
+	sent, err := store.Repository.GetSentWithPagination(ctx, entity.MessageFilter{
+		Statuses: []entity.MessageStatus{entity.StatusFailed},
+	}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{messages[1].ID, messages[2].ID, messages[0].ID}, ids(sent))
+
+	sent, err = store.Repository.GetSentWithPagination(ctx, entity.MessageFilter{TenantID: entity.DefaultTenantID}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{messages[1].ID, messages[0].ID}, ids(sent))
+
+	sent, err = store.Repository.GetSentWithPagination(ctx, entity.MessageFilter{
+		PhoneNumber: messages[0].PhoneNumber,
+	}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{messages[0].ID}, ids(sent))
+
+	sent, err = store.Repository.GetSentWithPagination(ctx, entity.MessageFilter{}, 2)
+	require.NoError(t, err)
+	assert.Empty(t, sent)
+}
+
+func testExport(t *testing.T, store MessageStore) {
+	ctx := context.Background()
+	messages := createMessages(t, store.Repository,
+		entity.Message{CreatedAt: base.Add(2 * time.Second)},
+		entity.Message{CreatedAt: base, Status: entity.StatusSent},
+		entity.Message{CreatedAt: base.Add(time.Second), Status: entity.StatusFailed},
+	)
+
+	collect := func(filter entity.MessageFilter) []uint64 {
+		var exported []entity.Message
+		err := store.Repository.Export(ctx, filter, func(message entity.Message) error {
+			exported = append(exported, message)
+			return nil
+		})
+		require.NoError(t, err)
+		return ids(exported)
+	}
+
+	// In id order, not creation order
+	assert.Equal(t, ids(messages), collect(entity.MessageFilter{}))
+	assert.Equal(t, []uint64{messages[1].ID, messages[2].ID}, collect(entity.MessageFilter{
+		Statuses: []entity.MessageStatus{entity.StatusSent, entity.StatusFailed},
+	}))
+	assert.Equal(t, []uint64{messages[0].ID, messages[2].ID}, collect(entity.MessageFilter{
+		From: base.Add(time.Second),
+	}))
+	assert.Equal(t, []uint64{messages[1].ID}, collect(entity.MessageFilter{
+		To: base.Add(time.Second),
+	}))
+
+	// An error of fn stops the export
+	stop := errors.New("stop")
+	var calls int
+	err := store.Repository.Export(ctx, entity.MessageFilter{}, func(entity.Message) error {
+		calls++
+		return stop
+	})
+	require.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
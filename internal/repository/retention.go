@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/crypto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type retentionRepository struct {
+	db      *gorm.DB
+	keyring *crypto.Keyring
+}
+
+func NewRetentionRepository(db *gorm.DB, keyring *crypto.Keyring) interfaces.RetentionRepository {
+	return &retentionRepository{
+		db:      db,
+		keyring: keyring,
+	}
+}
+
+// expiredMessagesSQL selects up to a batch of messages of a status created
+// before a time, oldest first. Rows locked by another replica pruning at
+// the same time are skipped. The partition key is selected along the id so
+// the outer statement only touches the expired partitions.
+const expiredMessagesSQL = `
+	SELECT id, created_at FROM messages
+	WHERE status = ? AND created_at < ?
+	ORDER BY created_at
+	LIMIT ?
+	FOR UPDATE SKIP LOCKED`
+
+// DeleteExpired deletes up to batch messages in status created before
+// before, it returns how many were deleted.
+func (r *retentionRepository) DeleteExpired(
+	ctx context.Context,
+	status entity.MessageStatus,
+	before time.Time,
+	batch int,
+) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Exec("DELETE FROM messages WHERE (id, created_at) IN ("+expiredMessagesSQL+")", status, before, batch)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired %s messages: %w", status, result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// ArchiveExpired moves up to batch messages in status created before
+// before to messages_archive in one statement, it returns how many were moved.
+func (r *retentionRepository) ArchiveExpired(
+	ctx context.Context,
+	status entity.MessageStatus,
+	before time.Time,
+	batch int,
+) (int64, error) {
+	// Columns are listed by name, the ones added to messages after the
+	// archive was created come after archived_at there, which takes its default
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(&entity.Message{}); err != nil {
+		return 0, fmt.Errorf("failed to parse message schema: %w", err)
+	}
+	columns := strings.Join(stmt.Schema.DBNames, ", ")
+
+	result := r.db.WithContext(ctx).Exec(`
+		WITH moved AS (
+			DELETE FROM messages WHERE (id, created_at) IN (`+expiredMessagesSQL+`)
+			RETURNING *
+		)
+		INSERT INTO messages_archive (`+columns+`) SELECT `+columns+` FROM moved`, status, before, batch)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to archive expired %s messages: %w", status, result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// TakeExpired hands up to batch messages in status created before before
+// to fn and deletes them once fn succeeded, in a single transaction so an
+// error of fn keeps them. It returns how many were deleted. fn gets the
+// phone numbers and contents sealed with the primary key, they leave the
+// database as protected as they are in it.
+func (r *retentionRepository) TakeExpired(
+	ctx context.Context,
+	status entity.MessageStatus,
+	before time.Time,
+	batch int,
+	fn func([]entity.Message) error,
+) (int64, error) {
+	var taken int64
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var messages []entity.Message
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND created_at < ?", status, before).
+			Order("created_at").
+			Limit(batch).
+			Find(&messages).Error
+		if err != nil {
+			return fmt.Errorf("failed to select expired %s messages: %w", status, err)
+		}
+
+		if len(messages) == 0 {
+			return nil
+		}
+
+		for i := range messages {
+			if messages[i].PhoneNumber, err = r.keyring.Encrypt(messages[i].PhoneNumber); err != nil {
+				return fmt.Errorf("failed to seal message %d: %w", messages[i].ID, err)
+			}
+			if messages[i].Content, err = r.keyring.Encrypt(messages[i].Content); err != nil {
+				return fmt.Errorf("failed to seal message %d: %w", messages[i].ID, err)
+			}
+		}
+
+		if err = fn(messages); err != nil {
+			return err
+		}
+
+		ids := make([]uint64, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+
+		result := tx.Where("id IN ? AND created_at < ?", ids, before).Delete(&entity.Message{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete expired %s messages: %w", status, result.Error)
+		}
+
+		taken = result.RowsAffected
+		return nil
+	})
+
+	return taken, err
+}
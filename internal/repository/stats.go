@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"gorm.io/gorm"
+)
+
+type statsRepository struct {
+	db *gorm.DB
+}
+
+func NewStatsRepository(db *gorm.DB) interfaces.StatsRepository {
+	return &statsRepository{
+		db: db,
+	}
+}
+
+// CountByStatus counts the messages created in the range per status.
+func (r *statsRepository) CountByStatus(
+	ctx context.Context,
+	filter entity.StatsFilter,
+) (map[entity.MessageStatus]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+
+	err := r.db.WithContext(ctx).
+		Model(&entity.Message{}).
+		Scopes(statsScope(filter, "created_at")).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count messages per status: %w", err)
+	}
+
+	counts := make(map[entity.MessageStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[entity.MessageStatus(row.Status)] = row.Count
+	}
+	return counts, nil
+}
+
+// Throughput counts the messages sent in the range per bucket of granularity.
+func (r *statsRepository) Throughput(
+	ctx context.Context,
+	filter entity.StatsFilter,
+	granularity entity.StatsGranularity,
+) ([]entity.ThroughputBucket, error) {
+	var buckets []entity.ThroughputBucket
+
+	err := r.db.WithContext(ctx).
+		Table("sent_messages").
+		Scopes(statsScope(filter, "sent_at")).
+		Select("date_trunc(?, sent_at) AS start, COUNT(*) AS sent", string(granularity)).
+		Group("start").
+		Order("start").
+		Scan(&buckets).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute throughput per %s: %w", granularity, err)
+	}
+
+	return buckets, nil
+}
+
+// Latency returns the percentiles of processing_time_seconds of the
+// messages sent in the range.
+func (r *statsRepository) Latency(ctx context.Context, filter entity.StatsFilter) (entity.LatencyStats, error) {
+	var latency entity.LatencyStats
+
+	err := r.db.WithContext(ctx).
+		Table("sent_messages").
+		Scopes(statsScope(filter, "sent_at")).
+		Select(`COUNT(*) AS samples,
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY processing_time_seconds), 0) AS p50,
+			COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY processing_time_seconds), 0) AS p95`).
+		Scan(&latency).Error
+	if err != nil {
+		return entity.LatencyStats{}, fmt.Errorf("failed to compute latency: %w", err)
+	}
+
+	return latency, nil
+}
+
+// FailureReasons groups the failed messages created in the range by the
+// prefix of their error, the part before the first colon, most common first.
+func (r *statsRepository) FailureReasons(
+	ctx context.Context,
+	filter entity.StatsFilter,
+) ([]entity.FailureReason, error) {
+	var reasons []entity.FailureReason
+
+	err := r.db.WithContext(ctx).
+		Model(&entity.Message{}).
+		Scopes(statsScope(filter, "created_at")).
+		Select("COALESCE(NULLIF(split_part(error_message, ':', 1), ''), 'unknown') AS reason, COUNT(*) AS count").
+		Where("status = ?", entity.StatusFailed).
+		Group("reason").
+		Order("count DESC, reason").
+		Scan(&reasons).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to group failure reasons: %w", err)
+	}
+
+	return reasons, nil
+}
+
+// statsScope restricts a query to the filter, column being the timestamp
+// the range applies to.
+func statsScope(filter entity.StatsFilter, column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where(column+" >= ? AND "+column+" < ?", filter.From, filter.To)
+		if filter.TenantID != "" {
+			db = db.Where("tenant_id = ?", filter.TenantID)
+		}
+		return db
+	}
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/constant"
+	"github.com/craftaholic/insider/internal/shared/crypto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type suppressionRepository struct {
+	db      *gorm.DB
+	keyring *crypto.Keyring
+}
+
+func NewSuppressionRepository(db *gorm.DB, keyring *crypto.Keyring) interfaces.SuppressionRepository {
+	return &suppressionRepository{
+		db:      db,
+		keyring: keyring,
+	}
+}
+
+// Create adds the suppression, adding a recipient that is already
+// suppressed is not an error and loads the existing record instead.
+func (r *suppressionRepository) Create(ctx context.Context, suppression *entity.Suppression) error {
+	suppression.PhoneNumberHash = phoneNumberHash(r.keyring, suppression.PhoneNumber)
+
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(suppression)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to create suppression: %w", result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	err := r.db.WithContext(ctx).
+		Where(phoneNumberKeyCondition(r.keyring, suppression.PhoneNumber)).
+		Where("channel = ? AND tenant_id = ?", suppression.Channel, suppression.TenantID).
+		First(suppression).Error
+	if err != nil {
+		return fmt.Errorf("failed to load existing suppression: %w", err)
+	}
+
+	return nil
+}
+
+func (r *suppressionRepository) Delete(ctx context.Context, id uint64) error {
+	result := r.db.WithContext(ctx).
+		Delete(&entity.Suppression{}, id)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete suppression with id %d: %w", id, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("suppression with id %d: %w", id, entity.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *suppressionRepository) List(ctx context.Context, tenantID string, page int) ([]entity.Suppression, error) {
+	if page <= 0 {
+		return nil, errors.New("page must be greater than 0")
+	}
+
+	offset := (page - 1) * constant.DefaultPageSize
+
+	query := r.db.WithContext(ctx)
+	if tenantID != "" {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+
+	var suppressions []entity.Suppression
+
+	err := query.
+		Offset(offset).
+		Limit(constant.DefaultPageSize).
+		Order("created_at DESC").
+		Find(&suppressions).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return suppressions, nil
+}
+
+func (r *suppressionRepository) IsSuppressed(
+	ctx context.Context,
+	phoneNumber string,
+	channel string,
+	tenantID string,
+) (bool, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).
+		Model(&entity.Suppression{}).
+		Where(phoneNumberKeyCondition(r.keyring, phoneNumber)).
+		Where("channel = ? AND tenant_id = ?", channel, tenantID).
+		Count(&count).Error
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
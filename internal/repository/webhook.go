@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/constant"
+	"gorm.io/gorm"
+)
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) interfaces.WebhookRepository {
+	return &webhookRepository{
+		db: db,
+	}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription *entity.WebhookSubscription) error {
+	if err := r.db.WithContext(ctx).Create(subscription).Error; err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, id uint64) (entity.WebhookSubscription, error) {
+	var subscription entity.WebhookSubscription
+
+	err := r.db.WithContext(ctx).First(&subscription, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.WebhookSubscription{}, fmt.Errorf("webhook subscription with id %d: %w", id, entity.ErrNotFound)
+	}
+	if err != nil {
+		return entity.WebhookSubscription{}, fmt.Errorf("failed to get webhook subscription with id %d: %w", id, err)
+	}
+
+	return subscription, nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context, tenantID string) ([]entity.WebhookSubscription, error) {
+	query := r.db.WithContext(ctx)
+	if tenantID != "" {
+		query = query.Where("tenant_id = ?", tenantID)
+	}
+
+	var subscriptions []entity.WebhookSubscription
+
+	err := query.
+		Order("id").
+		Find(&subscriptions).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+// ListSubscribers returns the subscriptions of tenantID receiving eventType.
+func (r *webhookRepository) ListSubscribers(
+	ctx context.Context,
+	tenantID string,
+	eventType entity.EventType,
+) ([]entity.WebhookSubscription, error) {
+	eventTypes, err := json.Marshal([]entity.EventType{eventType})
+	if err != nil {
+		return nil, err
+	}
+
+	var subscriptions []entity.WebhookSubscription
+
+	err = r.db.WithContext(ctx).
+		Where("tenant_id = ? AND event_types @> ?::jsonb", tenantID, string(eventTypes)).
+		Find(&subscriptions).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+// DeleteSubscription removes the subscription along with its delivery log.
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id uint64) error {
+	result := r.db.WithContext(ctx).
+		Delete(&entity.WebhookSubscription{}, id)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete webhook subscription with id %d: %w", id, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("webhook subscription with id %d: %w", id, entity.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []entity.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := r.db.WithContext(ctx).Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDeliveries claims the deliveries that are due, along with the ones
+// claimed more than leaseSeconds ago and never finished.
+func (r *webhookRepository) ClaimDeliveries(
+	ctx context.Context,
+	batch int,
+	leaseSeconds int,
+) ([]entity.WebhookDelivery, error) {
+	if batch <= 0 {
+		return nil, errors.New("batch size must be greater than 0")
+	}
+
+	var deliveries []entity.WebhookDelivery
+
+	err := r.db.WithContext(ctx).
+		Raw("SELECT * FROM claim_webhook_deliveries(?, ?)", batch, leaseSeconds).
+		Find(&deliveries).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, id uint64, updates map[string]any) error {
+	result := r.db.WithContext(ctx).
+		Model(&entity.WebhookDelivery{}).
+		Where("id = ?", id).
+		Updates(updates)
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update webhook delivery with id %d: %w", id, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("webhook delivery with id %d: %w", id, entity.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) ListDeliveries(
+	ctx context.Context,
+	subscriptionID uint64,
+	page int,
+) ([]entity.WebhookDelivery, error) {
+	if page <= 0 {
+		return nil, errors.New("page must be greater than 0")
+	}
+
+	offset := (page - 1) * constant.DefaultPageSize
+
+	var deliveries []entity.WebhookDelivery
+
+	err := r.db.WithContext(ctx).
+		Where("subscription_id = ?", subscriptionID).
+		Offset(offset).
+		Limit(constant.DefaultPageSize).
+		Order("created_at DESC").
+		Find(&deliveries).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/utils"
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	HeaderWebhookSignature = "X-Webhook-Signature"
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+)
+
+type webhookSender struct {
+	client *resty.Client
+}
+
+// NewWebhookSender posts events with client, retries are handled by the
+// delivery queue so the client must not retry on its own.
+func NewWebhookSender(client *resty.Client) interfaces.WebhookSender {
+	return &webhookSender{
+		client: client,
+	}
+}
+
+// Send posts the delivery payload signed with secret and returns the status
+// code of the endpoint, anything but a 2xx is an error.
+func (s *webhookSender) Send(ctx context.Context, delivery entity.WebhookDelivery, secret string) (int, error) {
+	body := []byte(delivery.Payload)
+
+	response, err := s.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader(HeaderWebhookSignature, utils.SignPayload(secret, time.Now(), body)).
+		SetHeader(HeaderWebhookEvent, string(delivery.EventType)).
+		SetHeader(HeaderWebhookDelivery, strconv.FormatUint(delivery.ID, 10)).
+		SetBody(body).
+		Post(delivery.URL)
+	if err != nil {
+		return 0, err
+	}
+
+	if !response.IsSuccess() {
+		return response.StatusCode(), fmt.Errorf("endpoint responded with status %d", response.StatusCode())
+	}
+
+	return response.StatusCode(), nil
+}
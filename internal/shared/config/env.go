@@ -18,6 +18,8 @@ type EnvConfig struct {
 	AppEnv         string
 	ContextTimeout int
 	ServerAddress  string
+	AdminAPIKey    string
+	APIKeys        string
 
 	// DB config
 	DBHost     string
@@ -37,12 +39,83 @@ type EnvConfig struct {
 	WebhookURL     string
 	WebhookAuthKey string
 	WebhookTimeout int
+	InboundAPIKey  string
+
+	// Circuit breaker config
+	CircuitBreakerEnabled        bool
+	CircuitBreakerWindow         int
+	CircuitBreakerMinRequests    int
+	CircuitBreakerFailureRate    int
+	CircuitBreakerOpenDuration   int
+	CircuitBreakerHalfOpenProbes int
+
+	// Ingest config
+	PhoneDefaultRegion string
+	SMSMaxSegments     int
+	SMSTransliterate   bool
 
 	// Concurency config
 	MessageBatchNumber  int
 	MessageCronDuration int
 	WorkerCount         int
 	WorkerChanBuffer    int
+	MessageSendTimeout  int
+	MessageStuckTimeout int
+	OrderedDelivery     bool
+
+	// Message queue config
+	QueueBackend       string
+	QueueStream        string
+	QueueGroup         string
+	QueueConsumer      string
+	QueueReclaimIdle   int
+	QueueSweepInterval int
+	QueueSweepBatch    int
+
+	// Message retry config
+	MessageMaxAttempts    int
+	MessageRetryBaseDelay int
+	MessageRetryMaxDelay  int
+
+	// Autoscaling config
+	WorkerMinCount        int
+	WorkerMaxCount        int
+	AutoscaleInterval     int
+	AutoscaleUpCooldown   int
+	AutoscaleDownCooldown int
+
+	// Campaign config
+	CampaignExpandInterval int
+	CampaignExpandChunk    int
+
+	// Event webhooks config
+	EventWebhookDispatchInterval int
+	EventWebhookDispatchBatch    int
+	EventWebhookMaxAttempts      int
+	EventWebhookRetryBaseDelay   int
+	EventWebhookRetryMaxDelay    int
+	EventWebhookTimeout          int
+
+	// Message callbacks config
+	CallbackSigningSecret string
+
+	// Retention config
+	RetentionPolicy     string
+	RetentionMode       string
+	RetentionInterval   int
+	RetentionBatch      int
+	RetentionArchiveDir string
+
+	// Encryption config
+	EncryptionKeys      string
+	EncryptionKeysFile  string
+	EncryptionHashKey   string
+	EncryptionReencrypt bool
+
+	// Partitioning config
+	PartitionMonthsAhead         int
+	PartitionRetentionMonths     int
+	PartitionMaintenanceInterval int
 }
 
 func LoadEnv() {
@@ -55,6 +128,8 @@ func LoadEnv() {
 		AppEnv:         getEnv("APP_ENV", "development"),
 		ContextTimeout: getIntEnv("CONTEXT_TIMEOUT", constant.DefaultContextTimeOut),
 		ServerAddress:  getEnv("SERVER_ADDR", "8080"),
+		AdminAPIKey:    getEnv("ADMIN_API_KEY", ""),
+		APIKeys:        getEnv("API_KEYS", ""),
 
 		// DB config
 		DBHost:     getEnv("DB_HOST", "localhost"),
@@ -74,14 +149,91 @@ func LoadEnv() {
 		WebhookURL:     getEnvOrPanic("WEBHOOK_URL"),
 		WebhookAuthKey: getEnvOrPanic("WEBHOOK_AUTH_KEY"),
 		WebhookTimeout: getIntEnv("WEBHOOK_TIMEOUT", constant.WebhookDefaultTimeout),
+		InboundAPIKey:  getEnv("INBOUND_API_KEY", ""),
+
+		// Circuit breaker config
+		CircuitBreakerEnabled:     getBoolEnv("CIRCUIT_BREAKER_ENABLED", true),
+		CircuitBreakerWindow:      getIntEnv("CIRCUIT_BREAKER_WINDOW", constant.CircuitBreakerDefaultWindow),
+		CircuitBreakerMinRequests: getIntEnv("CIRCUIT_BREAKER_MIN_REQUESTS", constant.CircuitBreakerDefaultMinRequests),
+		CircuitBreakerFailureRate: getIntEnv("CIRCUIT_BREAKER_FAILURE_RATE", constant.CircuitBreakerDefaultFailureRate),
+		CircuitBreakerOpenDuration: getIntEnv(
+			"CIRCUIT_BREAKER_OPEN_DURATION",
+			constant.CircuitBreakerDefaultOpenDuration,
+		),
+		CircuitBreakerHalfOpenProbes: getIntEnv(
+			"CIRCUIT_BREAKER_HALF_OPEN_PROBES",
+			constant.CircuitBreakerDefaultHalfOpenProbes,
+		),
+
+		// Ingest config
+		PhoneDefaultRegion: getEnv("PHONE_DEFAULT_REGION", constant.PhoneDefaultRegion),
+		SMSMaxSegments:     getIntEnv("SMS_MAX_SEGMENTS", constant.SMSDefaultMaxSegments),
+		SMSTransliterate:   getBoolEnv("SMS_TRANSLITERATE", false),
 
 		// Concurency config
 		MessageBatchNumber:  getIntEnv("MESSAGE_BATCH_NUMBER", constant.ProducerDefaultBatchNumber),
 		MessageCronDuration: getIntEnv("MESSAGE_CRON_DURATION", constant.ProducerDefaultCronDuration),
 		WorkerCount:         getIntEnv("WORKER_COUNT", constant.WorkerDefaultCount),
 		WorkerChanBuffer:    getIntEnv("WORKER_CHAN_BUFFER", constant.WorkerDefaultChanBuffer),
+		MessageSendTimeout:  getIntEnv("MESSAGE_SEND_TIMEOUT", constant.WorkerDefaultJobTimeout),
+		MessageStuckTimeout: getIntEnv("MESSAGE_STUCK_TIMEOUT", constant.MessageDefaultStuckTimeout),
+		OrderedDelivery:     getBoolEnv("ORDERED_DELIVERY", false),
+
+		// Message queue config
+		QueueBackend:       getEnv("QUEUE_BACKEND", constant.QueueDefaultBackend),
+		QueueStream:        getEnv("QUEUE_STREAM", constant.QueueDefaultStream),
+		QueueGroup:         getEnv("QUEUE_GROUP", constant.QueueDefaultGroup),
+		QueueConsumer:      getEnv("QUEUE_CONSUMER", ""),
+		QueueReclaimIdle:   getIntEnv("QUEUE_RECLAIM_IDLE", constant.QueueDefaultReclaimIdle),
+		QueueSweepInterval: getIntEnv("QUEUE_SWEEP_INTERVAL", constant.QueueDefaultSweepInterval),
+		QueueSweepBatch:    getIntEnv("QUEUE_SWEEP_BATCH", constant.QueueDefaultSweepBatch),
+
+		// Message retry config
+		MessageMaxAttempts:    getIntEnv("MESSAGE_MAX_ATTEMPTS", constant.MessageDefaultMaxAttempts),
+		MessageRetryBaseDelay: getIntEnv("MESSAGE_RETRY_BASE_DELAY", constant.MessageDefaultRetryBaseDelay),
+		MessageRetryMaxDelay:  getIntEnv("MESSAGE_RETRY_MAX_DELAY", constant.MessageDefaultRetryMaxDelay),
+
+		// Campaign config
+		CampaignExpandInterval: getIntEnv("CAMPAIGN_EXPAND_INTERVAL", constant.CampaignDefaultExpandInterval),
+		CampaignExpandChunk:    getIntEnv("CAMPAIGN_EXPAND_CHUNK", constant.CampaignDefaultExpandChunk),
+
+		// Event webhooks config
+		EventWebhookDispatchInterval: getIntEnv("EVENT_WEBHOOK_DISPATCH_INTERVAL", constant.EventWebhookDefaultDispatchInterval),
+		EventWebhookDispatchBatch:    getIntEnv("EVENT_WEBHOOK_DISPATCH_BATCH", constant.EventWebhookDefaultDispatchBatch),
+		EventWebhookMaxAttempts:      getIntEnv("EVENT_WEBHOOK_MAX_ATTEMPTS", constant.EventWebhookDefaultMaxAttempts),
+		EventWebhookRetryBaseDelay:   getIntEnv("EVENT_WEBHOOK_RETRY_BASE_DELAY", constant.EventWebhookDefaultRetryBaseDelay),
+		EventWebhookRetryMaxDelay:    getIntEnv("EVENT_WEBHOOK_RETRY_MAX_DELAY", constant.EventWebhookDefaultRetryMaxDelay),
+		EventWebhookTimeout:          getIntEnv("EVENT_WEBHOOK_TIMEOUT", constant.EventWebhookDefaultTimeout),
+
+		// Message callbacks config
+		CallbackSigningSecret: getEnv("CALLBACK_SIGNING_SECRET", ""),
+
+		// Retention config
+		RetentionPolicy:     getEnv("RETENTION_POLICY", ""),
+		RetentionMode:       getEnv("RETENTION_MODE", constant.RetentionDefaultMode),
+		RetentionInterval:   getIntEnv("RETENTION_INTERVAL", constant.RetentionDefaultInterval),
+		RetentionBatch:      getIntEnv("RETENTION_BATCH", constant.RetentionDefaultBatch),
+		RetentionArchiveDir: getEnv("RETENTION_ARCHIVE_DIR", constant.RetentionDefaultArchiveDir),
+
+		// Encryption config
+		EncryptionKeys:      getEnv("ENCRYPTION_KEYS", ""),
+		EncryptionKeysFile:  getEnv("ENCRYPTION_KEYS_FILE", ""),
+		EncryptionHashKey:   getEnv("ENCRYPTION_HASH_KEY", ""),
+		EncryptionReencrypt: getBoolEnv("ENCRYPTION_REENCRYPT", false),
+
+		// Partitioning config
+		PartitionMonthsAhead:         getIntEnv("PARTITION_MONTHS_AHEAD", constant.PartitionDefaultMonthsAhead),
+		PartitionRetentionMonths:     getIntEnv("PARTITION_RETENTION_MONTHS", 0),
+		PartitionMaintenanceInterval: getIntEnv("PARTITION_MAINTENANCE_INTERVAL", constant.PartitionDefaultInterval),
 	}
 
+	// Autoscaling config, a fixed size pool unless a range is given
+	env.WorkerMinCount = getIntEnv("WORKER_MIN_COUNT", env.WorkerCount)
+	env.WorkerMaxCount = getIntEnv("WORKER_MAX_COUNT", env.WorkerCount)
+	env.AutoscaleInterval = getIntEnv("AUTOSCALE_INTERVAL", constant.AutoscaleDefaultInterval)
+	env.AutoscaleUpCooldown = getIntEnv("AUTOSCALE_UP_COOLDOWN", constant.AutoscaleDefaultUpCooldown)
+	env.AutoscaleDownCooldown = getIntEnv("AUTOSCALE_DOWN_COOLDOWN", constant.AutoscaleDefaultDownCooldown)
+
 	Env = env
 	logger.Info("Loaded Config", "Config", Env)
 }
@@ -103,6 +255,15 @@ func getIntEnv(key string, defaultVal int) int {
 	return defaultVal
 }
 
+func getBoolEnv(key string, defaultVal bool) bool {
+	if val := os.Getenv(key); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+	return defaultVal
+}
+
 // getEnvOrPanic gets an environment variable or panics if not set.
 func getEnvOrPanic(key string) string {
 	if val := os.Getenv(key); val != "" {
@@ -1,11 +1,14 @@
 package constant
 
+import "time"
+
 const (
 	DefaultTimeout        = 30
 	WriteTimeout          = 30
 	IdleTimeout           = 120
 	DefaultContextTimeOut = 30
 	CorsMaxAge            = 30
+	DefaultPageSize       = 20
 
 	RestExponentialBackOffScale = 2
 	RestMaxRetry                = 2
@@ -15,9 +18,73 @@ const (
 
 	WorkerDefaultChanBuffer = 100
 	WorkerDefaultCount      = 5
+	WorkerDefaultJobTimeout = 120
+
+	AutoscaleDefaultInterval     = 15
+	AutoscaleDefaultUpCooldown   = 30
+	AutoscaleDefaultDownCooldown = 120
+	AutoscaleHighOccupancy       = 0.8
+	AutoscaleLatencyWeight       = 0.2
 
 	ProducerDefaultCronDuration = 30
 	ProducerDefaultBatchNumber  = 2
 
+	QueueDefaultBackend       = "postgres"
+	QueueDefaultStream        = "messages:queue"
+	QueueDefaultGroup         = "senders"
+	QueueDefaultReclaimIdle   = 300
+	QueueDefaultSweepInterval = 30
+	QueueDefaultSweepBatch    = 1000
+	QueueMarkerTTL            = time.Hour
+
 	WebhookDefaultTimeout = 30
+
+	CircuitBreakerDefaultWindow         = 20
+	CircuitBreakerDefaultMinRequests    = 10
+	CircuitBreakerDefaultFailureRate    = 50
+	CircuitBreakerDefaultOpenDuration   = 30
+	CircuitBreakerDefaultHalfOpenProbes = 3
+
+	MessageDefaultMaxAttempts    = 5
+	MessageDefaultRetryBaseDelay = 30
+	MessageDefaultRetryMaxDelay  = 3600
+	MessageDefaultStuckTimeout   = 600
+	MessageStuckCheckInterval    = time.Minute
+	ProviderAlertInterval        = 5 * time.Minute
+
+	PhoneDefaultRegion    = "TR"
+	SMSDefaultMaxSegments = 10
+
+	CampaignDefaultExpandInterval = 10
+	CampaignDefaultExpandChunk    = 500
+	CampaignInsertBatch           = 500
+
+	EventWebhookDefaultDispatchInterval = 5
+	EventWebhookDefaultDispatchBatch    = 50
+	EventWebhookDefaultMaxAttempts      = 8
+	EventWebhookDefaultRetryBaseDelay   = 10
+	EventWebhookDefaultRetryMaxDelay    = 3600
+	EventWebhookDefaultTimeout          = 10
+	EventWebhookDialTimeout             = 10 * time.Second
+
+	EventStreamChannel           = "events"
+	EventStreamSubscriberBuffer  = 64
+	EventStreamHeartbeatInterval = 15 * time.Second
+
+	RetentionDefaultMode       = "delete"
+	RetentionDefaultInterval   = 3600
+	RetentionDefaultBatch      = 1000
+	RetentionDefaultArchiveDir = "./archive"
+	RetentionBatchPause        = 200 * time.Millisecond
+	RetentionReportKey         = "retention:last_report"
+
+	PartitionDefaultMonthsAhead = 3
+	PartitionDefaultInterval    = 86400
+
+	ReencryptBatch      = 500
+	ReencryptBatchPause = 200 * time.Millisecond
+
+	StatsDefaultRange   = 24 * time.Hour
+	StatsMaxRange       = 31 * 24 * time.Hour
+	StatsMinuteMaxRange = 24 * time.Hour
 )
@@ -0,0 +1,329 @@
+// Package crypto holds the keys used to encrypt personal data at rest.
+//
+// Values are sealed with envelope encryption: every value gets its own
+// random data key, which encrypts it with AES-256-GCM and is itself
+// encrypted (wrapped) by a key of the keyring. A sealed value reads
+//
+//	enc:v1:<key id>:<base64 wrapped data key>:<base64 nonce + ciphertext>
+//
+// so rotating the keyring only needs the data keys to be rewrapped, and
+// values written before encryption was enabled, which lack the prefix or
+// the shape of a sealed value, are read as they are.
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// Prefix starts every sealed value
+	Prefix = "enc:v1:"
+
+	keySize = 32
+
+	// sealOverhead is the GCM nonce and tag around a sealed value, a
+	// wrapped data key is exactly that longer than the key
+	sealOverhead   = 12 + 16
+	wrappedKeySize = keySize + sealOverhead
+)
+
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// Keyring holds the key encryption keys by id and the key of the phone
+// number index. New values are sealed with the primary key, the others
+// are only kept to open the values sealed before a rotation. A nil
+// Keyring leaves values in plaintext.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+	hashKey []byte
+}
+
+// LoadKeyring builds the keyring from keys, or from the content of
+// keysFile when it is set. Keys are "<id>:<base64 32 bytes key>" entries
+// separated by commas or new lines, the first one being the primary key.
+// hashKey is the base64 32 bytes key of the phone number index, it never
+// rotates. No keys at all returns a nil Keyring.
+func LoadKeyring(keys string, keysFile string, hashKey string) (*Keyring, error) {
+	if keysFile != "" {
+		content, err := os.ReadFile(keysFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keyring file: %w", err)
+		}
+		keys = string(content)
+	}
+
+	entries := strings.FieldsFunc(keys, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	})
+
+	var keyring *Keyring
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		if keyring == nil {
+			keyring = &Keyring{keys: map[string]cipher.AEAD{}}
+		}
+		if err := keyring.add(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	if keyring == nil {
+		return nil, nil
+	}
+
+	var err error
+	if keyring.hashKey, err = decodeKey(hashKey); err != nil {
+		return nil, fmt.Errorf("invalid phone number hash key: %w", err)
+	}
+
+	return keyring, nil
+}
+
+func (k *Keyring) add(entry string) error {
+	id, encoded, ok := strings.Cut(entry, ":")
+	id = strings.TrimSpace(id)
+	if !ok || id == "" {
+		return fmt.Errorf("keyring entry %q isn't <id>:<key>", entry)
+	}
+	if _, exists := k.keys[id]; exists {
+		return fmt.Errorf("key %q is in the keyring twice", id)
+	}
+
+	key, err := decodeKey(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid key %q: %w", id, err)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	k.keys[id] = aead
+	if k.primary == "" {
+		k.primary = id
+	}
+	return nil
+}
+
+// Enabled tells whether values are encrypted.
+func (k *Keyring) Enabled() bool {
+	return k != nil
+}
+
+// PrimaryKeyID returns the id of the key new values are sealed with.
+func (k *Keyring) PrimaryKeyID() string {
+	if k == nil {
+		return ""
+	}
+	return k.primary
+}
+
+// Encrypt seals plaintext with a new data key wrapped by the primary key.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if k == nil {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(data, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return k.wrap(dataKey, ciphertext)
+}
+
+// Decrypt opens a sealed value, any other value is plaintext written
+// before encryption was enabled and is returned as is, including one that
+// merely starts with the prefix.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	dataKey, ciphertext, err := k.unwrap(value)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(data, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// Reencrypt brings value under the primary key: plaintext is sealed, and
+// the data key of a value sealed with an older key is rewrapped without
+// touching its ciphertext. It reports whether value changed.
+func (k *Keyring) Reencrypt(value string) (string, bool, error) {
+	if k == nil {
+		return value, false, nil
+	}
+
+	if !IsEncrypted(value) {
+		sealed, err := k.Encrypt(value)
+		return sealed, err == nil, err
+	}
+
+	if KeyID(value) == k.primary {
+		return value, false, nil
+	}
+
+	dataKey, ciphertext, err := k.unwrap(value)
+	if err != nil {
+		return "", false, err
+	}
+
+	rewrapped, err := k.wrap(dataKey, ciphertext)
+	return rewrapped, err == nil, err
+}
+
+// Hash returns the hex HMAC-SHA256 of value, the same value always gives
+// the same hash so it can be looked up. It is empty without a keyring.
+func (k *Keyring) Hash(value string) string {
+	if k == nil {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, k.hashKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (k *Keyring) wrap(dataKey []byte, ciphertext []byte) (string, error) {
+	wrapped, err := seal(k.keys[k.primary], dataKey, []byte(k.primary))
+	if err != nil {
+		return "", err
+	}
+
+	return Prefix + k.primary + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+func (k *Keyring) unwrap(value string) ([]byte, []byte, error) {
+	id, wrapped, ciphertext, ok := envelope(value)
+	if !ok {
+		return nil, nil, errors.New("malformed encrypted value")
+	}
+
+	if k == nil {
+		return nil, nil, fmt.Errorf("%w %q: encryption isn't configured", ErrUnknownKey, id)
+	}
+	kek, ok := k.keys[id]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+
+	dataKey, err := open(kek, wrapped, []byte(id))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	return dataKey, ciphertext, nil
+}
+
+// envelope splits a sealed value into the id of its key, its wrapped data
+// key and its ciphertext. It reports false for any value without the shape
+// wrap gives them, such as plaintext starting with the prefix.
+func envelope(value string) (string, []byte, []byte, bool) {
+	if !strings.HasPrefix(value, Prefix) {
+		return "", nil, nil, false
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, Prefix), ":")
+	if len(parts) != 3 || parts[0] == "" {
+		return "", nil, nil, false
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil || len(wrapped) != wrappedKeySize {
+		return "", nil, nil, false
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil || len(ciphertext) < sealOverhead {
+		return "", nil, nil, false
+	}
+
+	return parts[0], wrapped, ciphertext, true
+}
+
+// IsEncrypted tells whether value was sealed by a keyring.
+func IsEncrypted(value string) bool {
+	_, _, _, ok := envelope(value)
+	return ok
+}
+
+// KeyID returns the id of the key value was sealed with, empty for plaintext.
+func KeyID(value string) string {
+	id, _, _, _ := envelope(value)
+	return id
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("key isn't base64: %w", err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns the nonce followed by the ciphertext.
+func seal(aead cipher.AEAD, plaintext []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
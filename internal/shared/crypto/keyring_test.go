@@ -0,0 +1,304 @@
+package crypto_test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/craftaholic/insider/internal/shared/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T) string {
+	t.Helper()
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func loadKeyring(t *testing.T, keys string, hashKey string) *crypto.Keyring {
+	t.Helper()
+
+	keyring, err := crypto.LoadKeyring(keys, "", hashKey)
+	require.NoError(t, err)
+	require.True(t, keyring.Enabled())
+	return keyring
+}
+
+// sealedParts splits a sealed value into its key id, wrapped data key and ciphertext.
+func sealedParts(t *testing.T, value string) []string {
+	t.Helper()
+
+	require.True(t, crypto.IsEncrypted(value))
+	parts := strings.Split(strings.TrimPrefix(value, crypto.Prefix), ":")
+	require.Len(t, parts, 3)
+	return parts
+}
+
+func TestLoadKeyring(t *testing.T) {
+	hashKey := newKey(t)
+
+	t.Run("Empty", func(t *testing.T) {
+		keyring, err := crypto.LoadKeyring(" ,\n# no keys yet\n", "", "")
+		require.NoError(t, err)
+		assert.False(t, keyring.Enabled())
+		assert.Empty(t, keyring.PrimaryKeyID())
+
+		sealed, err := keyring.Encrypt("+905551112233")
+		require.NoError(t, err)
+		assert.Equal(t, "+905551112233", sealed)
+		assert.Empty(t, keyring.Hash("+905551112233"))
+	})
+
+	t.Run("File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "keyring")
+		content := "# rotated on the first of the month\nnew:" + newKey(t) + "\nold:" + newKey(t) + "\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		// The file wins over the keys
+		keyring, err := crypto.LoadKeyring("other:"+newKey(t), path, hashKey)
+		require.NoError(t, err)
+		assert.Equal(t, "new", keyring.PrimaryKeyID())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		key := newKey(t)
+		tests := []struct {
+			name    string
+			keys    string
+			hashKey string
+		}{
+			{"MissingID", ":" + key, hashKey},
+			{"MissingSeparator", key, hashKey},
+			{"NotBase64", "primary:not base64!", hashKey},
+			{"ShortKey", "primary:" + base64.StdEncoding.EncodeToString([]byte("short")), hashKey},
+			{"DuplicateID", "primary:" + key + ",primary:" + newKey(t), hashKey},
+			{"MissingHashKey", "primary:" + key, ""},
+			{"ShortHashKey", "primary:" + key, base64.StdEncoding.EncodeToString([]byte("short"))},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := crypto.LoadKeyring(tt.keys, "", tt.hashKey)
+				assert.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestKeyringRoundTrip(t *testing.T) {
+	keyring := loadKeyring(t, "primary:"+newKey(t), newKey(t))
+
+	for _, plaintext := range []string{"+905551112233", "Merhaba, kodunuz 1234: şçğüöı 🎉", ""} {
+		sealed, err := keyring.Encrypt(plaintext)
+		require.NoError(t, err)
+		assert.Equal(t, "primary", crypto.KeyID(sealed))
+		if plaintext != "" {
+			assert.NotContains(t, sealed, plaintext)
+		}
+
+		opened, err := keyring.Decrypt(sealed)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, opened)
+	}
+
+	// Every value gets its own data key and nonce
+	first, err := keyring.Encrypt("+905551112233")
+	require.NoError(t, err)
+	second, err := keyring.Encrypt("+905551112233")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealedParts(t, first)[1], sealedParts(t, second)[1])
+	assert.NotEqual(t, sealedParts(t, first)[2], sealedParts(t, second)[2])
+
+	// Values written before encryption was enabled are read as they are
+	opened, err := keyring.Decrypt("+905551112233")
+	require.NoError(t, err)
+	assert.Equal(t, "+905551112233", opened)
+	assert.False(t, crypto.IsEncrypted("+905551112233"))
+	assert.Empty(t, crypto.KeyID("+905551112233"))
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldKey, newKeyValue, hashKey := newKey(t), newKey(t), newKey(t)
+
+	before := loadKeyring(t, "old:"+oldKey, hashKey)
+	sealed, err := before.Encrypt("+905551112233")
+	require.NoError(t, err)
+
+	rotated := loadKeyring(t, "new:"+newKeyValue+",old:"+oldKey, hashKey)
+	assert.Equal(t, "new", rotated.PrimaryKeyID())
+
+	// The old key still opens the values sealed before the rotation
+	opened, err := rotated.Decrypt(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "+905551112233", opened)
+
+	// New values are sealed with the new key
+	fresh, err := rotated.Encrypt("+905551112233")
+	require.NoError(t, err)
+	assert.Equal(t, "new", crypto.KeyID(fresh))
+
+	// Reencrypting rewraps the data key and keeps the ciphertext
+	rewrapped, changed, err := rotated.Reencrypt(sealed)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "new", crypto.KeyID(rewrapped))
+	assert.Equal(t, sealedParts(t, sealed)[2], sealedParts(t, rewrapped)[2])
+
+	// Once rewrapped, the old key can go
+	withoutOld := loadKeyring(t, "new:"+newKeyValue, hashKey)
+	opened, err = withoutOld.Decrypt(rewrapped)
+	require.NoError(t, err)
+	assert.Equal(t, "+905551112233", opened)
+	_, err = withoutOld.Decrypt(sealed)
+	require.ErrorIs(t, err, crypto.ErrUnknownKey)
+
+	// Values under the primary key are left alone, plaintext is sealed
+	same, changed, err := rotated.Reencrypt(rewrapped)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, rewrapped, same)
+
+	sealedPlaintext, changed, err := rotated.Reencrypt("+905551112233")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "new", crypto.KeyID(sealedPlaintext))
+
+	// Without a keyring nothing changes
+	var disabled *crypto.Keyring
+	value, changed, err := disabled.Reencrypt(sealed)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, sealed, value)
+}
+
+func TestKeyringWrongKeyAndTamper(t *testing.T) {
+	hashKey := newKey(t)
+	keyring := loadKeyring(t, "primary:"+newKey(t), hashKey)
+
+	sealed, err := keyring.Encrypt("+905551112233")
+	require.NoError(t, err)
+	parts := sealedParts(t, sealed)
+
+	t.Run("WrongKey", func(t *testing.T) {
+		// Same id, another key
+		other := loadKeyring(t, "primary:"+newKey(t), hashKey)
+		_, err := other.Decrypt(sealed)
+		assert.Error(t, err)
+	})
+
+	t.Run("UnknownKey", func(t *testing.T) {
+		other := loadKeyring(t, "other:"+newKey(t), hashKey)
+		_, err := other.Decrypt(sealed)
+		require.ErrorIs(t, err, crypto.ErrUnknownKey)
+
+		var disabled *crypto.Keyring
+		_, err = disabled.Decrypt(sealed)
+		require.ErrorIs(t, err, crypto.ErrUnknownKey)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		flip := func(encoded string) string {
+			raw, err := base64.RawStdEncoding.DecodeString(encoded)
+			require.NoError(t, err)
+			raw[len(raw)-1] ^= 0x01
+			return base64.RawStdEncoding.EncodeToString(raw)
+		}
+
+		tests := []struct {
+			name  string
+			value string
+		}{
+			{"Ciphertext", crypto.Prefix + parts[0] + ":" + parts[1] + ":" + flip(parts[2])},
+			{"DataKey", crypto.Prefix + parts[0] + ":" + flip(parts[1]) + ":" + parts[2]},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := keyring.Decrypt(tt.value)
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("MovedToAnotherKey", func(t *testing.T) {
+		// The key id is bound to the wrapped data key
+		other := loadKeyring(t, "primary:"+newKey(t)+",other:"+newKey(t), hashKey)
+		moved := crypto.Prefix + "other:" + parts[1] + ":" + parts[2]
+		_, err := other.Decrypt(moved)
+		assert.Error(t, err)
+	})
+}
+
+func TestKeyringPrefixedPlaintext(t *testing.T) {
+	keyring := loadKeyring(t, "primary:"+newKey(t), newKey(t))
+
+	sealed, err := keyring.Encrypt("+905551112233")
+	require.NoError(t, err)
+	parts := sealedParts(t, sealed)
+
+	// Values starting with the prefix without the shape of a sealed one
+	// were written as they are
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"Text", crypto.Prefix + " your code is 1234"},
+		{"PrefixOnly", crypto.Prefix},
+		{"MissingPart", crypto.Prefix + parts[0] + ":" + parts[1]},
+		{"ExtraPart", sealed + ":more"},
+		{"NoKeyID", crypto.Prefix + ":" + parts[1] + ":" + parts[2]},
+		{"NotBase64", crypto.Prefix + parts[0] + ":" + parts[1] + ":%%%"},
+		{"ShortDataKey", crypto.Prefix + parts[0] + ":AAAA:" + parts[2]},
+		{"ShortCiphertext", crypto.Prefix + parts[0] + ":" + parts[1] + ":AAAA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.False(t, crypto.IsEncrypted(tt.value))
+			assert.Empty(t, crypto.KeyID(tt.value))
+
+			plaintext, err := keyring.Decrypt(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.value, plaintext)
+
+			var disabled *crypto.Keyring
+			plaintext, err = disabled.Decrypt(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.value, plaintext)
+
+			// Sealed like any plaintext
+			reencrypted, changed, err := keyring.Reencrypt(tt.value)
+			require.NoError(t, err)
+			assert.True(t, changed)
+			assert.Equal(t, "primary", crypto.KeyID(reencrypted))
+
+			opened, err := keyring.Decrypt(reencrypted)
+			require.NoError(t, err)
+			assert.Equal(t, tt.value, opened)
+		})
+	}
+}
+
+func TestKeyringHash(t *testing.T) {
+	hashKey := newKey(t)
+	keyring := loadKeyring(t, "primary:"+newKey(t), hashKey)
+
+	hash := keyring.Hash("+905551112233")
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, keyring.Hash("+905551112233"))
+	assert.NotEqual(t, hash, keyring.Hash("+905551112234"))
+
+	// Rotating the keyring keeps the hashes, they only depend on the hash key
+	rotated := loadKeyring(t, "new:"+newKey(t)+",primary:"+newKey(t), hashKey)
+	assert.Equal(t, hash, rotated.Hash("+905551112233"))
+
+	other := loadKeyring(t, "primary:"+newKey(t), newKey(t))
+	assert.NotEqual(t, hash, other.Hash("+905551112233"))
+}
@@ -0,0 +1,118 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/craftaholic/insider/internal/shared/redact"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactingCore masks phone numbers, message contents and secrets in the
+// fields of every entry before handing it to the wrapped core. Fields are
+// recognized by their name, structs and maps by the names of their
+// members, and phone numbers are also masked within any text.
+type redactingCore struct {
+	zapcore.Core
+}
+
+func newRedactingCore(core zapcore.Core) zapcore.Core {
+	return &redactingCore{Core: core}
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = redact.PhoneNumbers(entry.Message)
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		redacted[i] = redactField(field)
+	}
+	return redacted
+}
+
+func redactField(field zapcore.Field) zapcore.Field {
+	kind := redact.KindOf(field.Key)
+
+	switch field.Type {
+	case zapcore.StringType:
+		return zap.String(field.Key, redact.String(kind, field.String))
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok {
+			return zap.String(field.Key, redact.String(kind, err.Error()))
+		}
+	case zapcore.StringerType:
+		if stringer, ok := field.Interface.(fmt.Stringer); ok {
+			return zap.String(field.Key, redact.String(kind, stringer.String()))
+		}
+	case zapcore.ReflectType:
+		return zap.Any(field.Key, redactValue(kind, field.Interface))
+	case zapcore.SkipType, zapcore.NamespaceType:
+		return field
+	}
+
+	// Numbers, booleans, times and marshalers are only hidden when they
+	// are secrets or contents
+	if kind == redact.KindSecret || kind == redact.KindContent {
+		return zap.String(field.Key, redact.Redacted)
+	}
+	return field
+}
+
+// redactValue masks a struct, map or slice through its JSON
+// form, so nested members are masked by their names too.
+func redactValue(kind redact.Kind, value any) any {
+	if kind == redact.KindSecret || kind == redact.KindContent {
+		return redact.Redacted
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return redact.Redacted
+	}
+
+	var decoded any
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		return redact.Redacted
+	}
+
+	return redactDecoded(kind, decoded)
+}
+
+func redactDecoded(kind redact.Kind, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, member := range v {
+			v[key] = redactDecoded(redact.KindOf(key), member)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redactDecoded(kind, item)
+		}
+		return v
+	case string:
+		return redact.String(kind, v)
+	case nil:
+		return nil
+	default:
+		if kind == redact.KindSecret || kind == redact.KindContent {
+			return redact.Redacted
+		}
+		return v
+	}
+}
@@ -0,0 +1,148 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newTestLogger returns a logger writing JSON entries to the returned
+// buffer through the redacting core.
+func newTestLogger() (*ZapLogger, *bytes.Buffer) {
+	var buffer bytes.Buffer
+
+	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	core := newRedactingCore(zapcore.NewCore(encoder, zapcore.AddSync(&buffer), zap.DebugLevel))
+	return &ZapLogger{logger: zap.New(core)}, &buffer
+}
+
+// lastEntry decodes the last entry written to buffer.
+func lastEntry(t *testing.T, buffer *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	lines := bytes.Split(bytes.TrimSpace(buffer.Bytes()), []byte("\n"))
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &entry))
+	return entry
+}
+
+type stringer string
+
+func (s stringer) String() string { return string(s) }
+
+func TestRedactingCoreFields(t *testing.T) {
+	logger, buffer := newTestLogger()
+
+	logger.Info("Message sent to +905551111113",
+		"phone_number", "+905551111113",
+		"content", "Your code is 1234",
+		"api_key", "sk_live_0123456789",
+		"password", 1234,
+		"error", errors.New("provider rejected +905551111113"),
+		"recipient", stringer("+905551111113"),
+		"message_id", "3f0c2a",
+		"attempt", 2,
+	)
+
+	entry := lastEntry(t, buffer)
+	assert.Equal(t, "Message sent to +*********113", entry["msg"])
+	assert.Equal(t, "+*********113", entry["phone_number"])
+	assert.Equal(t, "[REDACTED]", entry["content"])
+	assert.Equal(t, "[REDACTED]", entry["api_key"])
+	assert.Equal(t, "[REDACTED]", entry["password"])
+	assert.Equal(t, "provider rejected +*********113", entry["error"])
+	assert.Equal(t, "+*********113", entry["recipient"])
+	assert.Equal(t, "3f0c2a", entry["message_id"])
+	assert.InDelta(t, 2, entry["attempt"], 0)
+
+	output := buffer.String()
+	for _, leaked := range []string{"905551111113", "Your code is 1234", "sk_live_0123456789"} {
+		assert.NotContains(t, output, leaked)
+	}
+}
+
+func TestRedactingCoreWithFields(t *testing.T) {
+	logger, buffer := newTestLogger()
+
+	logger.WithFields("phone_number", "+905551111113", "webhook_auth_key", "abc").
+		Info("Sending message")
+
+	entry := lastEntry(t, buffer)
+	assert.Equal(t, "+*********113", entry["phone_number"])
+	assert.Equal(t, "[REDACTED]", entry["webhook_auth_key"])
+}
+
+func TestRedactingCoreStructs(t *testing.T) {
+	type message struct {
+		ID          uint64
+		PhoneNumber string
+		Content     string
+		Recipients  []string
+		Note        string
+	}
+	type config struct {
+		DatabaseDSN    string `json:"database_dsn"`
+		EncryptionKeys string `json:"encryption_keys"`
+		WorkerCount    int    `json:"worker_count"`
+		Webhook        struct {
+			URL     string `json:"url"`
+			AuthKey string `json:"auth_key"`
+		} `json:"webhook"`
+	}
+
+	logger, buffer := newTestLogger()
+
+	dumped := config{
+		DatabaseDSN:    "postgres://insider:hunter2@db/insider",
+		EncryptionKeys: "primary:c2VjcmV0",
+		WorkerCount:    4,
+	}
+	dumped.Webhook.URL = "https://example.com/hook"
+	dumped.Webhook.AuthKey = "abc123"
+
+	logger.Info("Dump",
+		"message", message{
+			ID:          7,
+			PhoneNumber: "+905551111113",
+			Content:     "Your code is 1234",
+			Recipients:  []string{"+905551111113", "+14155550123"},
+			Note:        "forwarded from +905551111113",
+		},
+		"config", dumped,
+		"headers", map[string]string{"Authorization": "Bearer abc123", "Accept": "application/json"},
+		"body", map[string]any{"to": "+905551111113"},
+	)
+
+	entry := lastEntry(t, buffer)
+
+	dumpedMessage, ok := entry["message"].(map[string]any)
+	require.True(t, ok)
+	assert.InDelta(t, 7, dumpedMessage["ID"], 0)
+	assert.Equal(t, "+*********113", dumpedMessage["PhoneNumber"])
+	assert.Equal(t, "[REDACTED]", dumpedMessage["Content"])
+	assert.Equal(t, []any{"+*********113", "+********123"}, dumpedMessage["Recipients"])
+	assert.Equal(t, "forwarded from +*********113", dumpedMessage["Note"])
+
+	dumpedConfig, ok := entry["config"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "[REDACTED]", dumpedConfig["database_dsn"])
+	assert.Equal(t, "[REDACTED]", dumpedConfig["encryption_keys"])
+	assert.InDelta(t, 4, dumpedConfig["worker_count"], 0)
+	assert.Equal(t, map[string]any{"url": "https://example.com/hook", "auth_key": "[REDACTED]"}, dumpedConfig["webhook"])
+
+	assert.Equal(t, map[string]any{"Authorization": "[REDACTED]", "Accept": "application/json"}, entry["headers"])
+
+	// A content field is hidden whatever its shape
+	assert.Equal(t, "[REDACTED]", entry["body"])
+
+	output := buffer.String()
+	for _, leaked := range []string{"905551111113", "Your code is 1234", "hunter2", "c2VjcmV0", "abc123"} {
+		assert.NotContains(t, output, leaked)
+	}
+}
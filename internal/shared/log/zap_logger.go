@@ -58,8 +58,8 @@ func Init() {
 		consoleEncoder = zapcore.NewConsoleEncoder(developmentCfg)
 	}
 
-	// log to stdout
-	core := zapcore.NewCore(consoleEncoder, stdout, logLevel)
+	// log to stdout, masking personal data and secrets
+	core := newRedactingCore(zapcore.NewCore(consoleEncoder, stdout, logLevel))
 	logger = zap.New(core)
 	BaseLogger = &ZapLogger{logger: logger}
 }
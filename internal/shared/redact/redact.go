@@ -0,0 +1,114 @@
+// Package redact masks personal data and secrets before they leave the
+// service through logs or API responses.
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces a value that is hidden entirely.
+const Redacted = "[REDACTED]"
+
+// visibleDigits is how many trailing digits of a phone number stay readable.
+const visibleDigits = 3
+
+// phoneNumberPattern matches phone numbers in international format within
+// text, along with the ones of query strings where + is escaped.
+var phoneNumberPattern = regexp.MustCompile(`(\+|%2[Bb])\d[\d ]{6,17}\d`)
+
+// PhoneNumber masks every digit of phoneNumber but the last 3, "+905551111113"
+// becomes "+*********113".
+func PhoneNumber(phoneNumber string) string {
+	digits := 0
+	for _, r := range phoneNumber {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+
+	var masked strings.Builder
+	masked.Grow(len(phoneNumber))
+	for _, r := range phoneNumber {
+		if r >= '0' && r <= '9' {
+			if digits > visibleDigits {
+				r = '*'
+			}
+			digits--
+		}
+		masked.WriteRune(r)
+	}
+	return masked.String()
+}
+
+// PhoneNumbers masks the phone numbers in international format found in text.
+func PhoneNumbers(text string) string {
+	return phoneNumberPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if escaped, ok := strings.CutPrefix(match, "%2"); ok {
+			return "%2" + escaped[:1] + PhoneNumber(escaped[1:])
+		}
+		return PhoneNumber(match)
+	})
+}
+
+// Kind is how the value of a field is masked, as told by its name.
+type Kind int
+
+const (
+	// KindNone values are only searched for phone numbers
+	KindNone Kind = iota
+	// KindPhoneNumber values keep their last digits
+	KindPhoneNumber
+	// KindContent values are message contents, hidden entirely
+	KindContent
+	// KindSecret values are credentials, hidden entirely
+	KindSecret
+)
+
+var (
+	phoneNumberKeys = []string{"phonenumber", "phone", "recipient", "msisdn"}
+	contentKeys     = []string{"content", "body", "text"}
+	secretKeys      = []string{
+		"password", "secret", "token", "authorization", "apikey", "authkey",
+		"encryptionkey", "hashkey", "privatekey", "credential", "dsn",
+	}
+)
+
+// KindOf classifies a field by its name, case, underscores and dashes
+// aside: "phone_number", "PhoneNumber" and "phoneNumbers" are all phone numbers.
+func KindOf(key string) Kind {
+	normalized := strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(key))
+	normalized = strings.TrimSuffix(normalized, "s")
+
+	for _, secret := range secretKeys {
+		if strings.Contains(normalized, secret) {
+			return KindSecret
+		}
+	}
+	for _, phoneNumber := range phoneNumberKeys {
+		if normalized == phoneNumber || strings.HasSuffix(normalized, "phonenumber") {
+			return KindPhoneNumber
+		}
+	}
+	for _, content := range contentKeys {
+		if normalized == content {
+			return KindContent
+		}
+	}
+	return KindNone
+}
+
+// String masks value according to kind.
+func String(kind Kind, value string) string {
+	switch kind {
+	case KindPhoneNumber:
+		return PhoneNumber(value)
+	case KindContent, KindSecret:
+		if value == "" {
+			return value
+		}
+		return Redacted
+	default:
+		return PhoneNumbers(value)
+	}
+}
@@ -0,0 +1,104 @@
+package redact_test
+
+import (
+	"testing"
+
+	"github.com/craftaholic/insider/internal/shared/redact"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPhoneNumber(t *testing.T) {
+	tests := []struct {
+		name        string
+		phoneNumber string
+		want        string
+	}{
+		{"E164", "+905551111113", "+*********113"},
+		{"Spaced", "+90 555 111 11 13", "+** *** *** *1 13"},
+		{"Local", "05551111113", "********113"},
+		{"Short", "113", "113"},
+		{"Empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, redact.PhoneNumber(tt.phoneNumber))
+		})
+	}
+}
+
+func TestPhoneNumbers(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"Sentence", "failed to send to +905551111113: invalid", "failed to send to +*********113: invalid"},
+		{"Several", "+905551111113,+14155550123", "+*********113,+********123"},
+		{"QueryString", "/message/sent?phone_number=%2B905551111113&page=1", "/message/sent?phone_number=%2B*********113&page=1"},
+		{"TooShort", "order +12345 shipped", "order +12345 shipped"},
+		{"NoPlus", "id 905551111113", "id 905551111113"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, redact.PhoneNumbers(tt.text))
+		})
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		key  string
+		want redact.Kind
+	}{
+		{"phone_number", redact.KindPhoneNumber},
+		{"PhoneNumber", redact.KindPhoneNumber},
+		{"phoneNumbers", redact.KindPhoneNumber},
+		{"from_phone_number", redact.KindPhoneNumber},
+		{"recipient", redact.KindPhoneNumber},
+		{"msisdn", redact.KindPhoneNumber},
+		{"content", redact.KindContent},
+		{"Body", redact.KindContent},
+		{"text", redact.KindContent},
+		{"password", redact.KindSecret},
+		{"WebhookAuthKey", redact.KindSecret},
+		{"api-key", redact.KindSecret},
+		{"Authorization", redact.KindSecret},
+		{"ENCRYPTION_KEYS", redact.KindSecret},
+		{"hash_key", redact.KindSecret},
+		{"database_dsn", redact.KindSecret},
+		{"client_secret", redact.KindSecret},
+		{"refresh_tokens", redact.KindSecret},
+		{"message_id", redact.KindNone},
+		{"status", redact.KindNone},
+		{"content_type", redact.KindNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.want, redact.KindOf(tt.key))
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name  string
+		kind  redact.Kind
+		value string
+		want  string
+	}{
+		{"PhoneNumber", redact.KindPhoneNumber, "+905551111113", "+*********113"},
+		{"Content", redact.KindContent, "Your code is 1234", redact.Redacted},
+		{"Secret", redact.KindSecret, "whsec_0123456789", redact.Redacted},
+		{"EmptySecret", redact.KindSecret, "", ""},
+		{"Text", redact.KindNone, "sent to +905551111113", "sent to +*********113"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, redact.String(tt.kind, tt.value))
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+)
+
+type AuditUsecase struct {
+	auditRepository interfaces.AuditRepository
+}
+
+func NewAuditUsecase(auditRepository interfaces.AuditRepository) interfaces.AuditUsecase {
+	return &AuditUsecase{
+		auditRepository: auditRepository,
+	}
+}
+
+// Record writes action to the audit log on behalf of the actor of c. The
+// action already happened, so a failure to record it is logged rather
+// than returned.
+func (au *AuditUsecase) Record(c context.Context, action entity.AuditAction, parameters map[string]any) {
+	logger := log.FromCtx(c).WithFields("action", "Record audit", "audit_action", action)
+
+	if parameters == nil {
+		parameters = map[string]any{}
+	}
+	encoded, err := json.Marshal(parameters)
+	if err != nil {
+		logger.Error("Failed to encode audit parameters", "error", err)
+		encoded = []byte("{}")
+	}
+
+	actor := entity.ActorFromContext(c)
+	record := entity.AuditRecord{
+		Actor:      actor.Name,
+		Action:     action,
+		Parameters: string(encoded),
+	}
+	if actor.SourceIP != "" {
+		record.SourceIP = &actor.SourceIP
+	}
+
+	if err = au.auditRepository.Create(c, &record); err != nil {
+		logger.Error("Failed to write audit record", "error", err, "actor", actor.Name)
+	}
+}
+
+// RecordAPIKeys records the admin API keys when they differ from the ones
+// last recorded, keys are configured rather than changed through the API
+// so a change shows at the first start after it. Only the names, roles
+// and a fingerprint of the keys are recorded.
+func (au *AuditUsecase) RecordAPIKeys(c context.Context, keys []entity.APIKey) {
+	logger := log.FromCtx(c).WithFields("action", "Record api keys")
+
+	entries := make([]string, len(keys))
+	summary := make([]map[string]any, len(keys))
+	for i, key := range keys {
+		secret := sha256.Sum256([]byte(key.Key))
+		entries[i] = fmt.Sprintf("%s:%s:%t:%x", key.Name, key.Role, key.MaskPII, secret)
+		summary[i] = map[string]any{"name": key.Name, "role": key.Role, "masked": key.MaskPII}
+	}
+	slices.Sort(entries)
+	fingerprint := sha256.Sum256([]byte(strings.Join(entries, "\n")))
+
+	last, err := au.auditRepository.Latest(c, entity.AuditAPIKeysUpdate)
+	if err != nil && !errors.Is(err, entity.ErrNotFound) {
+		logger.Error("Failed to load the last api keys record", "error", err)
+		return
+	}
+
+	if err == nil {
+		var recorded struct {
+			Fingerprint string `json:"fingerprint"`
+		}
+		if json.Unmarshal([]byte(last.Parameters), &recorded) == nil &&
+			recorded.Fingerprint == hex.EncodeToString(fingerprint[:]) {
+			return
+		}
+	}
+
+	au.Record(c, entity.AuditAPIKeysUpdate, map[string]any{
+		"keys":        summary,
+		"fingerprint": hex.EncodeToString(fingerprint[:]),
+	})
+}
+
+// ListAuditRecords returns a page of the audit log, newest first.
+func (au *AuditUsecase) ListAuditRecords(
+	c context.Context,
+	filter entity.AuditFilter,
+	page int,
+) ([]entity.AuditRecord, error) {
+	if filter.Action != "" && !slices.Contains(entity.AuditActions, filter.Action) {
+		return nil, fmt.Errorf("%w: unknown audit action %q", entity.ErrValidation, filter.Action)
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", entity.ErrValidation)
+	}
+
+	return au.auditRepository.List(c, filter, page)
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminChangesAudited(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(ctx context.Context, audit *auditRecorder) error
+		want   []entity.AuditAction
+	}{
+		{
+			name: "Campaign",
+			change: func(ctx context.Context, audit *auditRecorder) error {
+				campaigns := NewCampaignUsecase(newCampaignStore(), audit, entity.CampaignConfig{}, entity.IngestConfig{})
+				campaign, err := campaigns.CreateCampaign(ctx, entity.Campaign{Name: "Spring sale", Content: "20% off"})
+				if err != nil {
+					return err
+				}
+				if _, _, err = campaigns.AddRecipients(ctx, campaign.ID, []string{"+905551111111", "not a number"}); err != nil {
+					return err
+				}
+				for _, change := range []func(context.Context, uint64) (entity.Campaign, error){
+					campaigns.StartCampaign, campaigns.PauseCampaign, campaigns.CancelCampaign,
+				} {
+					if _, err = change(ctx, campaign.ID); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			want: []entity.AuditAction{
+				entity.AuditCampaignCreate,
+				entity.AuditCampaignRecipients,
+				entity.AuditCampaignStart,
+				entity.AuditCampaignPause,
+				entity.AuditCampaignCancel,
+			},
+		},
+		{
+			name: "RefusedCampaignChange",
+			change: func(ctx context.Context, audit *auditRecorder) error {
+				campaigns := NewCampaignUsecase(newCampaignStore(), audit, entity.CampaignConfig{}, entity.IngestConfig{})
+				campaign, err := campaigns.CreateCampaign(ctx, entity.Campaign{Name: "Spring sale", Content: "20% off"})
+				if err != nil {
+					return err
+				}
+				if _, err = campaigns.PauseCampaign(ctx, campaign.ID); err == nil {
+					t.Error("a draft campaign was paused")
+				}
+				return nil
+			},
+			want: []entity.AuditAction{entity.AuditCampaignCreate},
+		},
+		{
+			name: "QuietHours",
+			change: func(ctx context.Context, audit *auditRecorder) error {
+				quietHours := NewQuietHoursUsecase(quietHoursStore{}, audit)
+				saved, err := quietHours.SetQuietHours(ctx, entity.QuietHours{StartTime: "22:00", EndTime: "08:00"})
+				if err != nil {
+					return err
+				}
+				return quietHours.RemoveQuietHours(ctx, saved.ID)
+			},
+			want: []entity.AuditAction{entity.AuditQuietHoursSet, entity.AuditQuietHoursDelete},
+		},
+		{
+			name: "WebhookSubscription",
+			change: func(ctx context.Context, audit *auditRecorder) error {
+				webhooks := NewWebhookUsecase(subscriptionStore{}, nil, audit, entity.WebhookConfig{})
+				subscription, err := webhooks.CreateSubscription(ctx, entity.WebhookSubscription{URL: "https://93.184.216.34/hooks"})
+				if err != nil {
+					return err
+				}
+				return webhooks.DeleteSubscription(ctx, subscription.ID)
+			},
+			want: []entity.AuditAction{entity.AuditWebhookCreate, entity.AuditWebhookDelete},
+		},
+		{
+			name: "Requeue",
+			change: func(ctx context.Context, audit *auditRecorder) error {
+				messages := memory.NewMessageRepository(nil, nil)
+				messageUsecase := newTestMessageUsecase(messages, nil, testServiceConfig())
+				messageUsecase.auditUsecase = audit
+
+				failed := entity.Message{PhoneNumber: "+905551111111", Content: "Your code is 1234", Status: entity.StatusFailed}
+				if err := messages.Create(ctx, &failed); err != nil {
+					return err
+				}
+				sent := entity.Message{PhoneNumber: "+905551111112", Content: "Your code is 5678", Status: entity.StatusSent}
+				if err := messages.Create(ctx, &sent); err != nil {
+					return err
+				}
+
+				if _, err := messageUsecase.RequeueMessage(ctx, failed.ID); err != nil {
+					return err
+				}
+				// A sent message isn't requeued, nor recorded
+				if _, err := messageUsecase.RequeueMessage(ctx, sent.ID); err == nil {
+					t.Error("a sent message was requeued")
+				}
+				return nil
+			},
+			want: []entity.AuditAction{entity.AuditMessageRequeue},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := &auditRecorder{}
+			require.NoError(t, tt.change(context.Background(), audit))
+			assert.Equal(t, tt.want, audit.recorded())
+		})
+	}
+}
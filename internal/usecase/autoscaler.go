@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/shared/constant"
+	"github.com/craftaholic/insider/internal/shared/log"
+)
+
+// autoscaler decides the size of the worker pool from the pending backlog,
+// the job channel occupancy and the observed send latency. Cooldowns keep
+// it from flapping between two sizes on bursty traffic.
+type autoscaler struct {
+	interval     time.Duration
+	upCooldown   time.Duration
+	downCooldown time.Duration
+
+	mu            sync.Mutex
+	latency       time.Duration // exponentially weighted moving average
+	lastScaleUp   time.Time
+	lastScaleDown time.Time
+}
+
+func newAutoscaler(config entity.ServiceConfig) *autoscaler {
+	return &autoscaler{
+		interval:     time.Duration(config.AutoscaleInterval) * time.Second,
+		upCooldown:   time.Duration(config.AutoscaleUpCooldown) * time.Second,
+		downCooldown: time.Duration(config.AutoscaleDownCooldown) * time.Second,
+	}
+}
+
+// observeLatency feeds the duration of one SendNotification call.
+func (a *autoscaler) observeLatency(d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.latency == 0 {
+		a.latency = d
+		return
+	}
+
+	a.latency = time.Duration(
+		constant.AutoscaleLatencyWeight*float64(d) + (1-constant.AutoscaleLatencyWeight)*float64(a.latency),
+	)
+}
+
+// desiredWorkers returns how many workers are needed to send everything the
+// fetcher can hand over within one fetch cycle. A worker sends
+// cronDuration / latency messages per cycle, while the fetcher hands over at
+// most batchNumber new messages on top of what is already queued.
+func (a *autoscaler) desiredWorkers(config entity.ServiceConfig, current int, backlog int64, queued int,
+	occupancy float64) int {
+	a.mu.Lock()
+	latency := a.latency
+	a.mu.Unlock()
+
+	work := min(backlog, int64(config.ProducerBatchNumber)) + int64(queued)
+
+	desired := current
+	switch {
+	case latency > 0:
+		cycle := time.Duration(config.ProducerCronDuration) * time.Second
+		desired = int(math.Ceil(float64(work) * float64(latency) / float64(cycle)))
+	case work > 0:
+		// No latency sample yet, probe upward while there is work
+		desired = current + 1
+	}
+
+	// The channel filling up means workers can't keep up whatever the estimate says
+	if occupancy >= constant.AutoscaleHighOccupancy {
+		desired = max(desired, current+1)
+	}
+
+	return min(max(desired, config.WorkerMinCount), config.WorkerMaxCount)
+}
+
+// allow applies the cooldowns and records the scaling decision. A scale down
+// also waits for the down cooldown after the last scale up.
+func (a *autoscaler) allow(current, desired int, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch {
+	case desired > current && now.Sub(a.lastScaleUp) >= a.upCooldown:
+		a.lastScaleUp = now
+		return true
+	case desired < current &&
+		now.Sub(a.lastScaleDown) >= a.downCooldown &&
+		now.Sub(a.lastScaleUp) >= a.downCooldown:
+		a.lastScaleDown = now
+		return true
+	default:
+		return false
+	}
+}
+
+func (mu *MessageUsecase) autoscalerLoop(c context.Context) {
+	if mu.autoscaler.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(mu.autoscaler.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+			mu.autoscale(c)
+		}
+	}
+}
+
+func (mu *MessageUsecase) autoscale(c context.Context) {
+	logger := log.FromCtx(c).WithFields("action", "Autoscale worker pool")
+
+	mu.mu.RLock()
+	config, isRunning, pool := mu.config, mu.isRunning, mu.workerPool
+	mu.mu.RUnlock()
+
+	if !isRunning || pool == nil || !config.Autoscaled() {
+		return
+	}
+
+	backlog, err := mu.messageRepository.CountPending(c)
+	if err != nil {
+		logger.Error("Failed to count pending messages", "error", err)
+		return
+	}
+
+	current := pool.Size()
+	desired := mu.autoscaler.desiredWorkers(config, current, backlog, pool.Queued(), pool.Occupancy())
+	if desired == current || !mu.autoscaler.allow(current, desired, time.Now()) {
+		return
+	}
+
+	mu.mu.Lock()
+	defer mu.mu.Unlock()
+
+	// The range may have been changed or the service stopped in the meantime
+	if !mu.isRunning || mu.workerPool != pool || !mu.config.Autoscaled() {
+		return
+	}
+
+	desired = min(max(desired, mu.config.WorkerMinCount), mu.config.WorkerMaxCount)
+	mu.config.WorkerCount = desired
+	pool.Resize(desired)
+
+	logger.Info("Worker pool resized", "from", current, "to", desired, "backlog", backlog)
+}
@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoscalerDesiredWorkers(t *testing.T) {
+	config := entity.ServiceConfig{
+		ProducerCronDuration: 10,
+		ProducerBatchNumber:  100,
+		WorkerMinCount:       1,
+		WorkerMaxCount:       20,
+	}
+
+	tests := []struct {
+		name      string
+		latency   time.Duration
+		current   int
+		backlog   int64
+		queued    int
+		occupancy float64
+		want      int
+	}{
+		{name: "NoSampleNoWork", current: 3, want: 3},
+		{name: "NoSampleProbesUp", current: 3, backlog: 10, want: 4},
+		{name: "BacklogCappedByBatch", latency: 100 * time.Millisecond, current: 5, backlog: 5000, want: 1},
+		{name: "BacklogAndQueued", latency: time.Second, current: 5, backlog: 100, queued: 20, want: 12},
+		{name: "AtMost", latency: 2 * time.Second, current: 5, backlog: 100, queued: 100, want: 20},
+		{name: "AtLeast", latency: time.Second, current: 5, want: 1},
+		{name: "HighOccupancy", latency: 100 * time.Millisecond, current: 5, queued: 8, occupancy: 0.9, want: 6},
+		{name: "HighOccupancyAtMost", latency: 100 * time.Millisecond, current: 20, queued: 8, occupancy: 1, want: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaler := newAutoscaler(config)
+			if tt.latency > 0 {
+				scaler.observeLatency(tt.latency)
+			}
+
+			got := scaler.desiredWorkers(config, tt.current, tt.backlog, tt.queued, tt.occupancy)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAutoscalerObserveLatency(t *testing.T) {
+	scaler := newAutoscaler(entity.ServiceConfig{})
+
+	// The first sample is taken as is, the next ones weigh 20%
+	scaler.observeLatency(100 * time.Millisecond)
+	assert.Equal(t, 100*time.Millisecond, scaler.latency)
+
+	scaler.observeLatency(200 * time.Millisecond)
+	assert.Equal(t, 120*time.Millisecond, scaler.latency)
+}
+
+func TestAutoscalerAllow(t *testing.T) {
+	type decision struct {
+		at       time.Duration
+		current  int
+		desired  int
+		expected bool
+	}
+
+	tests := []struct {
+		name      string
+		decisions []decision
+	}{
+		{
+			name: "FirstDecisions",
+			decisions: []decision{
+				{at: 0, current: 2, desired: 4, expected: true},
+				{at: 0, current: 4, desired: 4, expected: false},
+			},
+		},
+		{
+			name: "UpCooldown",
+			decisions: []decision{
+				{at: 0, current: 2, desired: 3, expected: true},
+				{at: 29 * time.Second, current: 3, desired: 4, expected: false},
+				{at: 30 * time.Second, current: 3, desired: 4, expected: true},
+			},
+		},
+		{
+			name: "DownCooldown",
+			decisions: []decision{
+				{at: 0, current: 5, desired: 4, expected: true},
+				{at: 119 * time.Second, current: 4, desired: 3, expected: false},
+				{at: 120 * time.Second, current: 4, desired: 3, expected: true},
+			},
+		},
+		{
+			name: "DownWaitsAfterUp",
+			decisions: []decision{
+				{at: 0, current: 2, desired: 3, expected: true},
+				{at: 60 * time.Second, current: 3, desired: 2, expected: false},
+				{at: 120 * time.Second, current: 3, desired: 2, expected: true},
+			},
+		},
+		{
+			name: "UpRightAfterDown",
+			decisions: []decision{
+				{at: 0, current: 5, desired: 4, expected: true},
+				{at: time.Second, current: 4, desired: 6, expected: true},
+			},
+		},
+	}
+
+	start := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaler := newAutoscaler(entity.ServiceConfig{AutoscaleUpCooldown: 30, AutoscaleDownCooldown: 120})
+
+			for _, d := range tt.decisions {
+				assert.Equal(t, d.expected, scaler.allow(d.current, d.desired, start.Add(d.at)),
+					"from %d to %d at %s", d.current, d.desired, d.at)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/craftaholic/insider/internal/utils"
+)
+
+type CampaignUsecase struct {
+	campaignRepository interfaces.CampaignRepository
+	auditUsecase       interfaces.AuditUsecase
+
+	config       entity.CampaignConfig
+	ingestConfig entity.IngestConfig
+}
+
+func NewCampaignUsecase(
+	campaignRepository interfaces.CampaignRepository,
+	auditUsecase interfaces.AuditUsecase,
+	config entity.CampaignConfig,
+	ingestConfig entity.IngestConfig,
+) interfaces.CampaignUsecase {
+	return &CampaignUsecase{
+		campaignRepository: campaignRepository,
+		auditUsecase:       auditUsecase,
+		config:             config,
+		ingestConfig:       ingestConfig,
+	}
+}
+
+// CreateCampaign stores a new campaign as draft, its content goes through the
+// same checks as a single message.
+func (cu *CampaignUsecase) CreateCampaign(c context.Context, campaign entity.Campaign) (entity.Campaign, error) {
+	logger := log.FromCtx(c).WithFields("action", "Create campaign", "tenant_id", campaign.TenantID)
+
+	if strings.TrimSpace(campaign.Name) == "" {
+		return entity.Campaign{}, fmt.Errorf("%w: name must not be empty", entity.ErrValidation)
+	}
+
+	var err error
+	campaign.Content, campaign.Encoding, campaign.Segments, err = prepareContent(cu.ingestConfig, campaign.Content)
+	if err != nil {
+		return entity.Campaign{}, err
+	}
+
+	campaign.Status = entity.CampaignDraft
+	if campaign.TenantID == "" {
+		campaign.TenantID = entity.DefaultTenantID
+	}
+	if campaign.Category == "" {
+		campaign.Category = entity.CategoryMarketing
+	}
+
+	if err = cu.campaignRepository.Create(c, &campaign); err != nil {
+		return entity.Campaign{}, err
+	}
+
+	cu.auditUsecase.Record(c, entity.AuditCampaignCreate, map[string]any{
+		"campaign_id": campaign.ID,
+		"name":        campaign.Name,
+		"tenant_id":   campaign.TenantID,
+		"category":    campaign.Category,
+	})
+
+	logger.Info("Campaign created", "campaign_id", campaign.ID)
+	return campaign, nil
+}
+
+// AddRecipients adds phone numbers to the audience of a draft campaign. It
+// returns how many were added, numbers already in the audience are skipped,
+// and the numbers rejected because they can't receive a message.
+func (cu *CampaignUsecase) AddRecipients(c context.Context, id uint64, phoneNumbers []string) (int64, []string, error) {
+	logger := log.FromCtx(c).WithFields("action", "Add campaign recipients", "campaign_id", id)
+
+	campaign, err := cu.campaignRepository.Get(c, id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if campaign.Status != entity.CampaignDraft {
+		return 0, nil, fmt.Errorf("%w: recipients can only be added to a draft campaign, it is %s",
+			entity.ErrConflict, campaign.Status)
+	}
+
+	rejected := []string{}
+	recipients := make([]entity.CampaignRecipient, 0, len(phoneNumbers))
+	for _, raw := range phoneNumbers {
+		phoneNumber, countryCode, err := utils.NormalizePhoneNumber(raw, cu.ingestConfig.DefaultRegion)
+		if err != nil {
+			rejected = append(rejected, raw)
+			continue
+		}
+
+		recipient := entity.CampaignRecipient{
+			CampaignID:  id,
+			PhoneNumber: phoneNumber,
+			CountryCode: &countryCode,
+		}
+		if timezone := utils.PhoneTimezone(phoneNumber); timezone != "" {
+			recipient.Timezone = &timezone
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	added, err := cu.campaignRepository.AddRecipients(c, recipients)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Counts only, the audit log doesn't keep the audience
+	cu.auditUsecase.Record(c, entity.AuditCampaignRecipients, map[string]any{
+		"campaign_id": id,
+		"added":       added,
+		"rejected":    len(rejected),
+	})
+
+	logger.Info("Campaign recipients added", "added", added, "rejected", len(rejected))
+	return added, rejected, nil
+}
+
+// AddSegment adds the recipients of a segment to the audience of a draft
+// campaign, they are the ones its tenant has already sent messages to. It
+// returns how many were added, numbers already in the audience are skipped.
+func (cu *CampaignUsecase) AddSegment(c context.Context, id uint64, segment entity.CampaignSegment) (int64, error) {
+	logger := log.FromCtx(c).WithFields("action", "Add campaign segment", "campaign_id", id)
+
+	campaign, err := cu.campaignRepository.Get(c, id)
+	if err != nil {
+		return 0, err
+	}
+
+	if campaign.Status != entity.CampaignDraft {
+		return 0, fmt.Errorf("%w: recipients can only be added to a draft campaign, it is %s",
+			entity.ErrConflict, campaign.Status)
+	}
+
+	if segment.CountryCode != nil {
+		countryCode := strings.ToUpper(*segment.CountryCode)
+		segment.CountryCode = &countryCode
+	}
+
+	added, err := cu.campaignRepository.AddSegment(c, campaign, segment)
+	if err != nil {
+		return 0, err
+	}
+
+	cu.auditUsecase.Record(c, entity.AuditCampaignRecipients, map[string]any{
+		"campaign_id":  id,
+		"added":        added,
+		"country_code": segment.CountryCode,
+		"category":     segment.Category,
+		"since":        segment.Since,
+		"delivered":    segment.Delivered,
+	})
+
+	logger.Info("Campaign segment added", "added", added)
+	return added, nil
+}
+
+func (cu *CampaignUsecase) GetCampaign(c context.Context, id uint64) (entity.Campaign, entity.CampaignStats, error) {
+	campaign, err := cu.campaignRepository.Get(c, id)
+	if err != nil {
+		return entity.Campaign{}, entity.CampaignStats{}, err
+	}
+
+	stats, err := cu.campaignRepository.Stats(c, id)
+	if err != nil {
+		return entity.Campaign{}, entity.CampaignStats{}, err
+	}
+
+	return campaign, stats, nil
+}
+
+func (cu *CampaignUsecase) ListCampaigns(c context.Context, tenantID string, page int) ([]entity.Campaign, error) {
+	return cu.campaignRepository.List(c, tenantID, page)
+}
+
+// StartCampaign starts a draft campaign, or resumes a paused one. Its
+// recipients are expanded once its schedule has come.
+func (cu *CampaignUsecase) StartCampaign(c context.Context, id uint64) (entity.Campaign, error) {
+	return cu.transition(c, id, entity.CampaignRunning, entity.AuditCampaignStart)
+}
+
+// PauseCampaign stops the messages of the campaign that haven't been
+// claimed yet from being sent until it is started again.
+func (cu *CampaignUsecase) PauseCampaign(c context.Context, id uint64) (entity.Campaign, error) {
+	return cu.transition(c, id, entity.CampaignPaused, entity.AuditCampaignPause)
+}
+
+// CancelCampaign stops the campaign for good, its messages that haven't
+// been claimed yet are cancelled and the rest of the audience is dropped.
+func (cu *CampaignUsecase) CancelCampaign(c context.Context, id uint64) (entity.Campaign, error) {
+	campaign, err := cu.transition(c, id, entity.CampaignCancelled, entity.AuditCampaignCancel)
+	if err != nil {
+		return entity.Campaign{}, err
+	}
+
+	// No message of a cancelled campaign is claimed anymore, the ones
+	// already claimed are still sent
+	cancelled, err := cu.campaignRepository.CancelPendingMessages(c, id)
+	if err != nil {
+		return entity.Campaign{}, err
+	}
+
+	log.FromCtx(c).Info("Campaign messages cancelled", "campaign_id", id, "cancelled", cancelled)
+	return campaign, nil
+}
+
+// transition moves the campaign of id to next and records it as action.
+func (cu *CampaignUsecase) transition(
+	c context.Context,
+	id uint64,
+	next entity.CampaignStatus,
+	action entity.AuditAction,
+) (entity.Campaign, error) {
+	logger := log.FromCtx(c).WithFields("action", "Change campaign status", "campaign_id", id)
+
+	campaign, err := cu.campaignRepository.Get(c, id)
+	if err != nil {
+		return entity.Campaign{}, err
+	}
+
+	if !campaign.CanTransition(next) {
+		return entity.Campaign{}, fmt.Errorf("%w: campaign can't go from %s to %s",
+			entity.ErrConflict, campaign.Status, next)
+	}
+
+	if err = cu.campaignRepository.UpdateStatus(c, id, campaign.Status, next); err != nil {
+		return entity.Campaign{}, err
+	}
+
+	cu.auditUsecase.Record(c, action, map[string]any{
+		"campaign_id": id,
+		"from":        campaign.Status,
+		"to":          next,
+	})
+
+	logger.Info("Campaign status changed", "from", campaign.Status, "to", next)
+	campaign.Status = next
+	return campaign, nil
+}
+
+// StartExpander runs the expansion of campaign recipients into messages in
+// the background until c is done. Every round expands one chunk per due
+// campaign so a large audience doesn't hold back the others, and completes
+// the campaigns that have nothing left to send.
+func (cu *CampaignUsecase) StartExpander(c context.Context) {
+	if cu.config.ExpandInterval <= 0 || cu.config.ExpandChunk <= 0 {
+		log.FromCtx(c).Warn("Campaign expansion is disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(cu.config.ExpandInterval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.Done():
+				return
+			case <-ticker.C:
+				cu.expand(c)
+			}
+		}
+	}()
+}
+
+func (cu *CampaignUsecase) expand(c context.Context) {
+	logger := log.FromCtx(c).WithFields("action", "Expand campaigns")
+
+	campaigns, err := cu.campaignRepository.ListDue(c)
+	if err != nil {
+		logger.Error("Failed to list due campaigns", "error", err)
+		return
+	}
+
+	for _, campaign := range campaigns {
+		expanded, err := cu.campaignRepository.ExpandRecipients(c, campaign, cu.config.ExpandChunk)
+		if err != nil {
+			logger.Error("Failed to expand campaign recipients", "campaign_id", campaign.ID, "error", err)
+			continue
+		}
+
+		if expanded > 0 {
+			logger.Info("Campaign recipients expanded", "campaign_id", campaign.ID, "expanded", expanded)
+			continue
+		}
+
+		completed, err := cu.campaignRepository.Complete(c, campaign.ID)
+		if err != nil {
+			logger.Error("Failed to complete campaign", "campaign_id", campaign.ID, "error", err)
+			continue
+		}
+
+		if completed {
+			logger.Info("Campaign completed", "campaign_id", campaign.ID)
+		}
+	}
+}
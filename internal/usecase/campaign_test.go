@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCampaignTransitions(t *testing.T) {
+	type change func(*CampaignUsecase, context.Context, uint64) (entity.Campaign, error)
+	start, pause, cancel := (*CampaignUsecase).StartCampaign, (*CampaignUsecase).PauseCampaign, (*CampaignUsecase).CancelCampaign
+
+	tests := []struct {
+		name    string
+		changes []change
+		// refused is the index of the change expected to be refused, -1 for none
+		refused int
+		want    entity.CampaignStatus
+	}{
+		{name: "Start", changes: []change{start}, refused: -1, want: entity.CampaignRunning},
+		{name: "Pause", changes: []change{start, pause}, refused: -1, want: entity.CampaignPaused},
+		{name: "Resume", changes: []change{start, pause, start}, refused: -1, want: entity.CampaignRunning},
+		{name: "CancelDraft", changes: []change{cancel}, refused: -1, want: entity.CampaignCancelled},
+		{name: "CancelRunning", changes: []change{start, cancel}, refused: -1, want: entity.CampaignCancelled},
+		{name: "CancelPaused", changes: []change{start, pause, cancel}, refused: -1, want: entity.CampaignCancelled},
+		{name: "PauseDraft", changes: []change{pause}, refused: 0, want: entity.CampaignDraft},
+		{name: "StartRunning", changes: []change{start, start}, refused: 1, want: entity.CampaignRunning},
+		{name: "PausePaused", changes: []change{start, pause, pause}, refused: 2, want: entity.CampaignPaused},
+		{name: "StartCancelled", changes: []change{cancel, start}, refused: 1, want: entity.CampaignCancelled},
+		{name: "CancelCancelled", changes: []change{cancel, cancel}, refused: 1, want: entity.CampaignCancelled},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newCampaignStore()
+			campaigns := newTestCampaignUsecase(store)
+			campaign, err := campaigns.CreateCampaign(ctx, entity.Campaign{Name: "Spring sale", Content: "20% off"})
+			require.NoError(t, err)
+			require.Equal(t, entity.CampaignDraft, campaign.Status)
+
+			for i, change := range tt.changes {
+				changed, err := change(campaigns, ctx, campaign.ID)
+				if i == tt.refused {
+					require.ErrorIs(t, err, entity.ErrConflict, "change %d", i)
+					continue
+				}
+				require.NoError(t, err, "change %d", i)
+				assert.Equal(t, changed.Status, store.campaigns[campaign.ID].Status)
+			}
+
+			stored, _, err := campaigns.GetCampaign(ctx, campaign.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, stored.Status)
+		})
+	}
+
+	t.Run("Unknown", func(t *testing.T) {
+		campaigns := newTestCampaignUsecase(newCampaignStore())
+		_, err := campaigns.StartCampaign(ctx, 42)
+		require.ErrorIs(t, err, entity.ErrNotFound)
+	})
+}
+
+func TestCampaignExpansion(t *testing.T) {
+	ctx := context.Background()
+	store := newCampaignStore()
+	campaigns := newTestCampaignUsecase(store)
+
+	campaign, err := campaigns.CreateCampaign(ctx, entity.Campaign{Name: "Spring sale", Content: "20% off"})
+	require.NoError(t, err)
+	added, rejected, err := campaigns.AddRecipients(ctx, campaign.ID,
+		[]string{"+905551111111", "+905551111112", "+905551111113", "+905551111114", "+905551111115"})
+	require.NoError(t, err)
+	require.EqualValues(t, 5, added)
+	require.Empty(t, rejected)
+
+	// A draft campaign isn't expanded
+	campaigns.expand(ctx)
+	assert.Empty(t, store.messageStatuses(campaign.ID))
+
+	// One chunk of 2 recipients per round
+	_, err = campaigns.StartCampaign(ctx, campaign.ID)
+	require.NoError(t, err)
+	campaigns.expand(ctx)
+	assert.Len(t, store.messageStatuses(campaign.ID), 2)
+
+	// Nor is a paused one, and the audience can't change once started
+	_, err = campaigns.PauseCampaign(ctx, campaign.ID)
+	require.NoError(t, err)
+	campaigns.expand(ctx)
+	assert.Len(t, store.messageStatuses(campaign.ID), 2)
+	_, _, err = campaigns.AddRecipients(ctx, campaign.ID, []string{"+905551111116"})
+	require.ErrorIs(t, err, entity.ErrConflict)
+
+	_, err = campaigns.StartCampaign(ctx, campaign.ID)
+	require.NoError(t, err)
+	campaigns.expand(ctx)
+	campaigns.expand(ctx)
+	assert.Len(t, store.messageStatuses(campaign.ID), 5)
+
+	// Every recipient is expanded, the campaign still has messages to send
+	campaigns.expand(ctx)
+	running, stats, err := campaigns.GetCampaign(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.CampaignRunning, running.Status)
+	assert.Equal(t, entity.CampaignStats{Recipients: 5, Expanded: 5, Pending: 5}, stats)
+
+	store.send(campaign.ID)
+	campaigns.expand(ctx)
+	completed, stats, err := campaigns.GetCampaign(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.CampaignCompleted, completed.Status)
+	assert.NotNil(t, completed.CompletedAt)
+	assert.Equal(t, entity.CampaignStats{Recipients: 5, Expanded: 5, Sent: 5}, stats)
+
+	// Completed for good
+	_, err = campaigns.CancelCampaign(ctx, campaign.ID)
+	require.ErrorIs(t, err, entity.ErrConflict)
+}
+
+func TestCampaignCancelStopsUnclaimedMessages(t *testing.T) {
+	ctx := context.Background()
+	store := newCampaignStore()
+	campaigns := newTestCampaignUsecase(store)
+
+	campaign, err := campaigns.CreateCampaign(ctx, entity.Campaign{Name: "Spring sale", Content: "20% off"})
+	require.NoError(t, err)
+	_, _, err = campaigns.AddRecipients(ctx, campaign.ID, []string{"+905551111111", "+905551111112", "+905551111113"})
+	require.NoError(t, err)
+	_, err = campaigns.StartCampaign(ctx, campaign.ID)
+	require.NoError(t, err)
+
+	// The first two are sent, the third is expanded after them
+	campaigns.expand(ctx)
+	store.send(campaign.ID)
+	campaigns.expand(ctx)
+
+	_, err = campaigns.CancelCampaign(ctx, campaign.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.MessageStatus{entity.StatusSent, entity.StatusSent, entity.StatusCancelled},
+		store.messageStatuses(campaign.ID))
+
+	// A cancelled campaign is neither expanded nor completed
+	campaigns.expand(ctx)
+	assert.Equal(t, entity.CampaignCancelled, store.campaigns[campaign.ID].Status)
+}
+
+func TestCampaignAddSegment(t *testing.T) {
+	ctx := context.Background()
+	store := newCampaignStore()
+	audit := &auditRecorder{}
+	campaigns := NewCampaignUsecase(store, audit, entity.CampaignConfig{}, entity.IngestConfig{}).(*CampaignUsecase)
+
+	campaign, err := campaigns.CreateCampaign(ctx, entity.Campaign{Name: "Spring sale", Content: "20% off"})
+	require.NoError(t, err)
+
+	countryCode, category := "tr", entity.CategoryMarketing
+	_, err = campaigns.AddSegment(ctx, campaign.ID, entity.CampaignSegment{
+		CountryCode: &countryCode,
+		Category:    &category,
+		Delivered:   true,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, store.segments, 1)
+	require.NotNil(t, store.segments[0].CountryCode)
+	assert.Equal(t, "TR", *store.segments[0].CountryCode)
+	assert.Equal(t, entity.CategoryMarketing, *store.segments[0].Category)
+	assert.True(t, store.segments[0].Delivered)
+	assert.Equal(t, []entity.AuditAction{entity.AuditCampaignCreate, entity.AuditCampaignRecipients}, audit.recorded())
+
+	// Only the audience of a draft campaign can change
+	_, err = campaigns.StartCampaign(ctx, campaign.ID)
+	require.NoError(t, err)
+	_, err = campaigns.AddSegment(ctx, campaign.ID, entity.CampaignSegment{})
+	require.ErrorIs(t, err, entity.ErrConflict)
+	assert.Len(t, store.segments, 1)
+
+	_, err = campaigns.AddSegment(ctx, 42, entity.CampaignSegment{})
+	require.ErrorIs(t, err, entity.ErrNotFound)
+}
+
+// newTestCampaignUsecase returns a campaign usecase over store expanding
+// 2 recipients per campaign and round.
+func newTestCampaignUsecase(store *campaignStore) *CampaignUsecase {
+	return NewCampaignUsecase(store, &auditRecorder{}, entity.CampaignConfig{
+		ExpandInterval: 1,
+		ExpandChunk:    2,
+	}, entity.IngestConfig{}).(*CampaignUsecase)
+}
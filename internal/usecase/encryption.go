@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/constant"
+	"github.com/craftaholic/insider/internal/shared/log"
+)
+
+type EncryptionUsecase struct {
+	encryptionRepository interfaces.EncryptionRepository
+}
+
+func NewEncryptionUsecase(encryptionRepository interfaces.EncryptionRepository) interfaces.EncryptionUsecase {
+	return &EncryptionUsecase{
+		encryptionRepository: encryptionRepository,
+	}
+}
+
+// StartReencryption rewrites, in the background, the messages, suppressions,
+// campaign recipients and webhook deliveries stored in plaintext or sealed
+// with a key older than the primary one, table by table and batch by batch
+// until none is left or c is done. Old keys can be removed from the keyring
+// once it has finished.
+func (eu *EncryptionUsecase) StartReencryption(c context.Context) {
+	tables := []struct {
+		name      string
+		reencrypt func(c context.Context, after uint64, batch int) (uint64, int, error)
+	}{
+		{"messages", eu.encryptionRepository.ReencryptMessages},
+		{"suppressions", eu.encryptionRepository.ReencryptSuppressions},
+		{"campaign recipients", eu.encryptionRepository.ReencryptCampaignRecipients},
+		{"webhook deliveries", eu.encryptionRepository.ReencryptWebhookDeliveries},
+	}
+
+	go func() {
+		logger := log.FromCtx(c).WithFields("action", "Reencrypt personal data")
+
+		for _, table := range tables {
+			var (
+				after uint64
+				total int
+			)
+			for {
+				next, rewritten, err := table.reencrypt(c, after, constant.ReencryptBatch)
+				after = next
+				total += rewritten
+				if err != nil {
+					logger.Error("Reencryption stopped", "table", table.name, "error", err, "reencrypted", total)
+					return
+				}
+
+				if rewritten == 0 {
+					logger.Info("Table reencrypted", "table", table.name, "reencrypted", total)
+					break
+				}
+
+				select {
+				case <-c.Done():
+					return
+				case <-time.After(constant.ReencryptBatchPause):
+				}
+			}
+		}
+
+		logger.Info("Personal data reencrypted")
+	}()
+}
@@ -0,0 +1,27 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+)
+
+type eventPublishers []interfaces.EventPublisher
+
+// NewEventPublishers hands every event to each publisher, one failing
+// doesn't keep the event from the others.
+func NewEventPublishers(publishers ...interfaces.EventPublisher) interfaces.EventPublisher {
+	return eventPublishers(publishers)
+}
+
+func (ep eventPublishers) Publish(c context.Context, event entity.Event) error {
+	var errs []error
+	for _, publisher := range ep {
+		if err := publisher.Publish(c, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/craftaholic/insider/internal/domain/dto"
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+)
+
+// EventStreamUsecase publishes events to the stream shared by every replica
+// and fans the events it receives out to the clients connected to this one.
+type EventStreamUsecase struct {
+	eventStream interfaces.EventStream
+	buffer      int
+
+	mu          sync.Mutex
+	subscribers map[*streamSubscriber]struct{}
+}
+
+type streamSubscriber struct {
+	filter   entity.StreamFilter
+	messages chan entity.StreamMessage
+}
+
+func NewEventStreamUsecase(eventStream interfaces.EventStream, buffer int) interfaces.EventStreamUsecase {
+	return &EventStreamUsecase{
+		eventStream: eventStream,
+		buffer:      buffer,
+		subscribers: map[*streamSubscriber]struct{}{},
+	}
+}
+
+func (eu *EventStreamUsecase) Publish(c context.Context, event entity.Event) error {
+	payload, err := json.Marshal(dto.ConvertStreamEventToDTO(event))
+	if err != nil {
+		return fmt.Errorf("failed to marshal stream event: %w", err)
+	}
+
+	return eu.eventStream.Publish(c, payload)
+}
+
+// Subscribe returns the events matching filter as they are received, until
+// the returned function is called. A client too slow to keep up with its
+// buffer misses events rather than holding back the others.
+func (eu *EventStreamUsecase) Subscribe(
+	c context.Context,
+	filter entity.StreamFilter,
+) (<-chan entity.StreamMessage, func()) {
+	subscriber := &streamSubscriber{
+		filter:   filter,
+		messages: make(chan entity.StreamMessage, eu.buffer),
+	}
+
+	eu.mu.Lock()
+	eu.subscribers[subscriber] = struct{}{}
+	eu.mu.Unlock()
+
+	var once sync.Once
+	return subscriber.messages, func() {
+		once.Do(func() {
+			eu.mu.Lock()
+			defer eu.mu.Unlock()
+
+			// Nothing is sent to it once removed, it can be closed safely
+			delete(eu.subscribers, subscriber)
+			close(subscriber.messages)
+		})
+	}
+}
+
+// Start receives the events of every replica in the background until c is done.
+func (eu *EventStreamUsecase) Start(c context.Context) error {
+	payloads, err := eu.eventStream.Subscribe(c)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for payload := range payloads {
+			eu.broadcast(c, payload)
+		}
+	}()
+
+	return nil
+}
+
+func (eu *EventStreamUsecase) broadcast(c context.Context, payload []byte) {
+	var event dto.StreamEventDTO
+	if err := json.Unmarshal(payload, &event); err != nil {
+		log.FromCtx(c).Warn("Dropping malformed stream event", "error", err)
+		return
+	}
+
+	message := entity.StreamMessage{
+		ID:       event.ID,
+		Type:     event.Type,
+		TenantID: event.TenantID,
+		Payload:  payload,
+	}
+
+	eu.mu.Lock()
+	defer eu.mu.Unlock()
+
+	for subscriber := range eu.subscribers {
+		if !subscriber.filter.Matches(message) {
+			continue
+		}
+
+		select {
+		case subscriber.messages <- message:
+		default:
+			log.FromCtx(c).Warn("Stream client is too slow, event dropped", "event_id", message.ID)
+		}
+	}
+}
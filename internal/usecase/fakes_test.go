@@ -0,0 +1,397 @@
+package usecase
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/repository"
+	"github.com/craftaholic/insider/internal/repository/memory"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// The usecases log through the base logger, only errors are of interest here
+	if os.Getenv("LOG_LEVEL") == "" {
+		_ = os.Setenv("LOG_LEVEL", "error")
+	}
+	log.Init()
+
+	os.Exit(m.Run())
+}
+
+// notificationFunc is a NotificationService sending with a function.
+type notificationFunc func(ctx context.Context, message entity.Message) (string, error)
+
+func (f notificationFunc) SendNotification(ctx context.Context, message entity.Message) (string, error) {
+	return f(ctx, message)
+}
+
+// hookedMessageRepository calls beforeUpdate before every UpdateSelective.
+type hookedMessageRepository struct {
+	interfaces.MessageRepository
+	beforeUpdate func(id uint64, updates map[string]any)
+}
+
+func (r *hookedMessageRepository) UpdateSelective(ctx context.Context, id uint64, updates map[string]any) error {
+	r.beforeUpdate(id, updates)
+	return r.MessageRepository.UpdateSelective(ctx, id, updates)
+}
+
+// noSuppressions is a suppression list nobody is on.
+type noSuppressions struct {
+	interfaces.SuppressionRepository
+}
+
+func (noSuppressions) IsSuppressed(context.Context, string, string, string) (bool, error) {
+	return false, nil
+}
+
+// suppressionStore is a suppression repository keeping the suppressions created.
+type suppressionStore struct {
+	interfaces.SuppressionRepository
+
+	mu           sync.Mutex
+	suppressions []entity.Suppression
+}
+
+func (s *suppressionStore) Create(_ context.Context, suppression *entity.Suppression) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	suppression.ID = uint64(len(s.suppressions) + 1)
+	s.suppressions = append(s.suppressions, *suppression)
+	return nil
+}
+
+// noQuietHours is a quiet hours repository without any window.
+type noQuietHours struct {
+	interfaces.QuietHoursRepository
+}
+
+func (noQuietHours) List(context.Context, string) ([]entity.QuietHours, error) {
+	return nil, nil
+}
+
+// eventRecorder keeps the events published to it.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []entity.Event
+}
+
+func (r *eventRecorder) Publish(_ context.Context, event entity.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+	return nil
+}
+
+// auditRecorder keeps the actions recorded to it.
+type auditRecorder struct {
+	interfaces.AuditUsecase
+
+	mu      sync.Mutex
+	actions []entity.AuditAction
+}
+
+func (r *auditRecorder) Record(_ context.Context, action entity.AuditAction, _ map[string]any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.actions = append(r.actions, action)
+}
+
+func (r *auditRecorder) recorded() []entity.AuditAction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]entity.AuditAction(nil), r.actions...)
+}
+
+// testServiceConfig is a single worker service retrying messages up to 3 times.
+func testServiceConfig() entity.ServiceConfig {
+	return entity.ServiceConfig{
+		WorkerCount:          1,
+		JobBuffer:            4,
+		ProducerCronDuration: 1,
+		ProducerBatchNumber:  4,
+		StuckTimeout:         60,
+		WorkerMinCount:       1,
+		WorkerMaxCount:       1,
+		MaxAttempts:          3,
+		RetryBaseDelay:       60,
+		RetryMaxDelay:        600,
+	}
+}
+
+// newTestMessageUsecase returns a message usecase sending the messages of
+// messageRepository through notificationService, claimed with the Postgres
+// queue and without a circuit breaker.
+func newTestMessageUsecase(
+	messageRepository interfaces.MessageRepository,
+	notificationService interfaces.NotificationService,
+	config entity.ServiceConfig,
+) *MessageUsecase {
+	breaker := repository.NewNotificationCircuitBreaker(notificationService, entity.CircuitBreakerConfig{})
+
+	return NewMessageUsecase(
+		messageRepository,
+		repository.NewPostgresMessageQueue(messageRepository, config.OrderedDelivery),
+		memory.NewCacheRepository(),
+		breaker,
+		breaker,
+		noSuppressions{},
+		noQuietHours{},
+		&eventRecorder{},
+		&auditRecorder{},
+		config,
+		entity.IngestConfig{},
+	).(*MessageUsecase)
+}
+
+// messagesIn returns the messages of messageRepository in status.
+func messagesIn(t *testing.T, messageRepository interfaces.MessageRepository, status entity.MessageStatus) []entity.Message {
+	t.Helper()
+
+	var messages []entity.Message
+	err := messageRepository.Export(context.Background(), entity.MessageFilter{Statuses: []entity.MessageStatus{status}},
+		func(message entity.Message) error {
+			messages = append(messages, message)
+			return nil
+		})
+	require.NoError(t, err)
+	return messages
+}
+
+// campaignStore is a campaign repository keeping the campaigns, their
+// recipients and the messages they are expanded into in memory.
+type campaignStore struct {
+	interfaces.CampaignRepository
+
+	mu         sync.Mutex
+	campaigns  map[uint64]entity.Campaign
+	recipients []entity.CampaignRecipient
+	messages   []entity.Message
+	segments   []entity.CampaignSegment
+}
+
+func newCampaignStore() *campaignStore {
+	return &campaignStore{campaigns: map[uint64]entity.Campaign{}}
+}
+
+func (s *campaignStore) Create(_ context.Context, campaign *entity.Campaign) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	campaign.ID = uint64(len(s.campaigns) + 1)
+	s.campaigns[campaign.ID] = *campaign
+	return nil
+}
+
+func (s *campaignStore) Get(_ context.Context, id uint64) (entity.Campaign, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	campaign, ok := s.campaigns[id]
+	if !ok {
+		return entity.Campaign{}, entity.ErrNotFound
+	}
+	return campaign, nil
+}
+
+func (s *campaignStore) ListDue(context.Context) ([]entity.Campaign, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []entity.Campaign
+	for id := range uint64(len(s.campaigns)) {
+		campaign := s.campaigns[id+1]
+		if campaign.Status == entity.CampaignRunning &&
+			(campaign.ScheduledAt == nil || !campaign.ScheduledAt.After(time.Now())) {
+			due = append(due, campaign)
+		}
+	}
+	return due, nil
+}
+
+func (s *campaignStore) UpdateStatus(_ context.Context, id uint64, from entity.CampaignStatus, to entity.CampaignStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	campaign, ok := s.campaigns[id]
+	if !ok || campaign.Status != from {
+		return entity.ErrConflict
+	}
+	campaign.Status = to
+	s.campaigns[id] = campaign
+	return nil
+}
+
+func (s *campaignStore) AddRecipients(_ context.Context, recipients []entity.CampaignRecipient) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recipients = append(s.recipients, recipients...)
+	return int64(len(recipients)), nil
+}
+
+// AddSegment keeps the segment, the store has no past messages to select from.
+func (s *campaignStore) AddSegment(_ context.Context, _ entity.Campaign, segment entity.CampaignSegment) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.segments = append(s.segments, segment)
+	return 0, nil
+}
+
+func (s *campaignStore) ExpandRecipients(_ context.Context, campaign entity.Campaign, chunk int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expanded := 0
+	for i, recipient := range s.recipients {
+		if expanded == chunk {
+			break
+		}
+		if recipient.CampaignID != campaign.ID || recipient.ExpandedAt != nil {
+			continue
+		}
+
+		now := time.Now()
+		s.recipients[i].ExpandedAt = &now
+		message := campaign.MessageFor(recipient)
+		message.ID = uint64(len(s.messages) + 1)
+		s.messages = append(s.messages, message)
+		expanded++
+	}
+	return expanded, nil
+}
+
+func (s *campaignStore) CancelPendingMessages(_ context.Context, id uint64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var cancelled int64
+	for i, message := range s.messages {
+		if *message.CampaignID == id && message.Status == entity.StatusPending {
+			s.messages[i].Status = entity.StatusCancelled
+			cancelled++
+		}
+	}
+	return cancelled, nil
+}
+
+func (s *campaignStore) Complete(_ context.Context, id uint64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	campaign := s.campaigns[id]
+	if campaign.Status != entity.CampaignRunning {
+		return false, nil
+	}
+	for _, recipient := range s.recipients {
+		if recipient.CampaignID == id && recipient.ExpandedAt == nil {
+			return false, nil
+		}
+	}
+	for _, message := range s.messages {
+		if *message.CampaignID == id &&
+			(message.Status == entity.StatusPending || message.Status == entity.StatusProcessing) {
+			return false, nil
+		}
+	}
+
+	now := time.Now()
+	campaign.Status, campaign.CompletedAt = entity.CampaignCompleted, &now
+	s.campaigns[id] = campaign
+	return true, nil
+}
+
+func (s *campaignStore) Stats(_ context.Context, id uint64) (entity.CampaignStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stats entity.CampaignStats
+	for _, recipient := range s.recipients {
+		if recipient.CampaignID == id {
+			stats.Recipients++
+			if recipient.ExpandedAt != nil {
+				stats.Expanded++
+			}
+		}
+	}
+	for _, message := range s.messages {
+		if *message.CampaignID != id {
+			continue
+		}
+		switch message.Status {
+		case entity.StatusPending:
+			stats.Pending++
+		case entity.StatusSent:
+			stats.Sent++
+		case entity.StatusCancelled:
+			stats.Cancelled++
+		}
+	}
+	return stats, nil
+}
+
+// messageStatuses returns the statuses of the messages of the campaign of id.
+func (s *campaignStore) messageStatuses(id uint64) []entity.MessageStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var statuses []entity.MessageStatus
+	for _, message := range s.messages {
+		if *message.CampaignID == id {
+			statuses = append(statuses, message.Status)
+		}
+	}
+	return statuses
+}
+
+// send moves the pending messages of the campaign of id to sent.
+func (s *campaignStore) send(id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, message := range s.messages {
+		if *message.CampaignID == id && message.Status == entity.StatusPending {
+			s.messages[i].Status = entity.StatusSent
+		}
+	}
+}
+
+// quietHoursStore is a quiet hours repository accepting every change.
+type quietHoursStore struct {
+	interfaces.QuietHoursRepository
+}
+
+func (quietHoursStore) Upsert(_ context.Context, quietHours *entity.QuietHours) error {
+	quietHours.ID = 1
+	return nil
+}
+
+func (quietHoursStore) Delete(context.Context, uint64) error {
+	return nil
+}
+
+// subscriptionStore is a webhook repository accepting every subscription change.
+type subscriptionStore struct {
+	interfaces.WebhookRepository
+}
+
+func (subscriptionStore) CreateSubscription(_ context.Context, subscription *entity.WebhookSubscription) error {
+	subscription.ID = 1
+	return nil
+}
+
+func (subscriptionStore) DeleteSubscription(context.Context, uint64) error {
+	return nil
+}
@@ -2,49 +2,199 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/craftaholic/insider/internal/domain/entity"
 	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/constant"
 	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/craftaholic/insider/internal/utils"
+	"github.com/google/uuid"
 )
 
 type MessageUsecase struct {
-	messageRepository   interfaces.MessageRepository
-	cacheRepository     interfaces.CacheRepository
-	notificationService interfaces.NotificationService
-
-	jobBuffer            int
-	workerCount          int
-	producerCronDuration int
-	producerBatchNumber  int
-
-	workerPool *WorkerPool
-	cancel     context.CancelFunc
-	isRunning  bool
-	mu         sync.RWMutex
+	messageRepository     interfaces.MessageRepository
+	messageQueue          interfaces.MessageQueue
+	cacheRepository       interfaces.CacheRepository
+	notificationService   interfaces.NotificationService
+	circuitBreaker        interfaces.CircuitBreaker
+	suppressionRepository interfaces.SuppressionRepository
+	quietHoursRepository  interfaces.QuietHoursRepository
+	eventPublisher        interfaces.EventPublisher
+	auditUsecase          interfaces.AuditUsecase
+
+	config       entity.ServiceConfig
+	ingestConfig entity.IngestConfig
+	autoscaler   *autoscaler
+
+	workerPool  *WorkerPool
+	cancel      context.CancelFunc
+	isRunning   bool
+	mu          sync.RWMutex
+	cronUpdated chan struct{}
+
+	// lastAuthAlert is the unix time of the last auth failure alert
+	lastAuthAlert atomic.Int64
 }
 
 func NewMessageUsecase(
 	messageRepository interfaces.MessageRepository,
+	messageQueue interfaces.MessageQueue,
 	cacheRepository interfaces.CacheRepository,
 	notificationService interfaces.NotificationService,
-	jobBuffer int,
-	workerCount int,
-	producerCronDuration int,
-	producerBatchNumber int,
+	circuitBreaker interfaces.CircuitBreaker,
+	suppressionRepository interfaces.SuppressionRepository,
+	quietHoursRepository interfaces.QuietHoursRepository,
+	eventPublisher interfaces.EventPublisher,
+	auditUsecase interfaces.AuditUsecase,
+	config entity.ServiceConfig,
+	ingestConfig entity.IngestConfig,
 ) interfaces.MessageUsecase {
 	return &MessageUsecase{
-		messageRepository:    messageRepository,
-		cacheRepository:      cacheRepository,
-		notificationService:  notificationService,
-		workerCount:          workerCount,
-		jobBuffer:            jobBuffer,
-		producerCronDuration: producerCronDuration,
-		producerBatchNumber:  producerBatchNumber,
+		messageRepository:     messageRepository,
+		messageQueue:          messageQueue,
+		cacheRepository:       cacheRepository,
+		notificationService:   notificationService,
+		circuitBreaker:        circuitBreaker,
+		suppressionRepository: suppressionRepository,
+		quietHoursRepository:  quietHoursRepository,
+		eventPublisher:        eventPublisher,
+		auditUsecase:          auditUsecase,
+		config:                config,
+		ingestConfig:          ingestConfig,
+		autoscaler:            newAutoscaler(config),
+		cronUpdated:           make(chan struct{}, 1),
+	}
+}
+
+// CreateMessage validates and normalizes a new message before storing it as
+// pending. The phone number is stored in E.164 along with its country code,
+// and the content along with its encoding and number of SMS segments. The
+// recipient's timezone, used for quiet hours, is derived from the phone
+// number unless it is set on the message. A callback url is only accepted
+// when callbacks can be signed.
+func (mu *MessageUsecase) CreateMessage(c context.Context, message entity.Message) (entity.Message, error) {
+	logger := log.FromCtx(c).WithFields("action", "Create message")
+
+	phoneNumber, countryCode, err := utils.NormalizePhoneNumber(message.PhoneNumber, mu.ingestConfig.DefaultRegion)
+	if err != nil {
+		return entity.Message{}, err
+	}
+
+	message.Content, message.Encoding, message.Segments, err = prepareContent(mu.ingestConfig, message.Content)
+	if err != nil {
+		return entity.Message{}, err
+	}
+
+	message.PhoneNumber = phoneNumber
+	message.CountryCode = &countryCode
+	message.Status = entity.StatusPending
+	if message.TenantID == "" {
+		message.TenantID = entity.DefaultTenantID
+	}
+	if message.Channel == "" {
+		message.Channel = entity.DefaultChannel
+	}
+	if message.Category == "" {
+		message.Category = entity.CategoryTransactional
+	}
+	if message.OrderingKey != nil && *message.OrderingKey == "" {
+		message.OrderingKey = nil
+	}
+
+	if message.Timezone != nil && *message.Timezone != "" {
+		if _, err = time.LoadLocation(*message.Timezone); err != nil {
+			return entity.Message{}, fmt.Errorf("%w: timezone %q is unknown", entity.ErrValidation, *message.Timezone)
+		}
+	} else if timezone := utils.PhoneTimezone(phoneNumber); timezone != "" {
+		message.Timezone = &timezone
+	}
+
+	if message.CallbackURL != nil && *message.CallbackURL != "" {
+		if !mu.ingestConfig.CallbacksEnabled {
+			return entity.Message{}, fmt.Errorf("%w: callbacks aren't enabled", entity.ErrValidation)
+		}
+		// The service posts to the url, only known callers may choose it
+		if _, ok := entity.APIKeyFromContext(c); !ok {
+			return entity.Message{}, fmt.Errorf("%w: callback_url requires an api key", entity.ErrValidation)
+		}
+		if err = validateWebhookURL(c, *message.CallbackURL); err != nil {
+			return entity.Message{}, err
+		}
+	} else {
+		message.CallbackURL = nil
+	}
+
+	if err = mu.messageRepository.Create(c, &message); err != nil {
+		return entity.Message{}, err
+	}
+
+	logger.Info("Message created", "message_id", message.ID)
+	mu.publishEvent(c, entity.EventMessageCreated, message)
+
+	// Scheduled messages are enqueued by the queue sweep once they are due
+	if message.ScheduledAt == nil || !message.ScheduledAt.After(time.Now()) {
+		if err = mu.messageQueue.Enqueue(c, message.ID); err != nil {
+			logger.Warn("Failed to enqueue message, it waits for the next sweep", "message_id", message.ID, "error", err)
+		}
+	}
+	return message, nil
+}
+
+// prepareContent transliterates the content when configured and returns it
+// along with its encoding and number of segments, which must be within the limit.
+func prepareContent(ingestConfig entity.IngestConfig, content string) (string, entity.SMSEncoding, int, error) {
+	if strings.TrimSpace(content) == "" {
+		return "", "", 0, fmt.Errorf("%w: content must not be empty", entity.ErrValidation)
+	}
+
+	if ingestConfig.Transliterate {
+		content = utils.Transliterate(content)
+	}
+
+	encoding, segments := utils.CountSegments(content)
+	if ingestConfig.MaxSegments > 0 && segments > ingestConfig.MaxSegments {
+		return "", "", 0, fmt.Errorf("%w: content takes %d %s segments, the limit is %d",
+			entity.ErrValidation, segments, encoding, ingestConfig.MaxSegments)
+	}
+
+	return content, encoding, segments, nil
+}
+
+// HandleDeliveryReport records the delivery report of a sent message, the
+// message is looked up by the id the provider returned when sending it.
+func (mu *MessageUsecase) HandleDeliveryReport(c context.Context, report entity.DeliveryReport) error {
+	logger := log.FromCtx(c).WithFields("action", "Handle delivery report", "message_uuid", report.MessageID)
+
+	event := entity.EventMessageDelivered
+	updates := map[string]any{
+		"status":     entity.StatusDelivered,
+		"updated_at": time.Now(),
+	}
+	if !report.Delivered {
+		reason := "undelivered"
+		if report.Error != nil && *report.Error != "" {
+			reason += ": " + *report.Error
+		}
+		event = entity.EventMessageFailed
+		updates["status"] = entity.StatusFailed
+		updates["error_message"] = reason
 	}
+
+	message, err := mu.messageRepository.UpdateSentByMessageID(c, report.MessageID, updates)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Delivery report recorded", "status", message.Status)
+	mu.publishEvent(c, event, message)
+	return nil
 }
 
 func (mu *MessageUsecase) StartAutomatedSending(c context.Context) error {
@@ -55,20 +205,43 @@ func (mu *MessageUsecase) StartAutomatedSending(c context.Context) error {
 
 	if mu.isRunning {
 		logger.Info("Automated sending already started don't need to do anything")
+		mu.auditUsecase.Record(c, entity.AuditServiceStart, map[string]any{"was_running": true})
 		return nil
 	}
 
 	serviceCtx, cancel := context.WithCancel(c)
 	mu.cancel = cancel
 
+	// Start inside the autoscaling range
+	if mu.config.Autoscaled() {
+		mu.config.WorkerCount = min(max(mu.config.WorkerCount, mu.config.WorkerMinCount), mu.config.WorkerMaxCount)
+	}
+
 	// Create worker pool
-	mu.workerPool = newWorkerPool(c, mu.workerCount, mu.jobBuffer) // 5 concurrent workers
-	mu.workerPool.Start(mu.processSingleMessage)
+	newPool := newWorkerPool
+	if mu.config.OrderedDelivery {
+		newPool = newOrderedWorkerPool
+	}
+	mu.workerPool = newPool(
+		c,
+		mu.config.WorkerCount,
+		mu.config.JobBuffer,
+		time.Duration(mu.config.JobTimeout)*time.Second,
+	)
+	mu.workerPool.Start(mu.processClaimedMessage, mu.handleMessagePanic)
 
 	// Start message fetcher
 	go mu.messageFetcher(serviceCtx)
 
+	// Start the autoscaler, it stays idle unless a worker count range is configured
+	go mu.autoscalerLoop(serviceCtx)
+
+	// Put the messages left in processing for too long back to pending
+	go mu.stuckResetLoop(serviceCtx)
+
 	mu.isRunning = true
+	mu.publishEvent(c, entity.EventServiceStarted, entity.Message{})
+	mu.auditUsecase.Record(c, entity.AuditServiceStart, map[string]any{"was_running": false})
 	return nil
 }
 
@@ -76,59 +249,164 @@ func (mu *MessageUsecase) messageFetcher(c context.Context) {
 	// Trigger the first time
 	mu.fetchMessages(c)
 
-	ticker := time.NewTicker(time.Duration(mu.producerCronDuration) * time.Second)
+	ticker := time.NewTicker(mu.cronInterval())
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-c.Done():
 			return
+		case <-mu.cronUpdated:
+			// The interval has been changed through UpdateServiceConfig
+			ticker.Reset(mu.cronInterval())
 		case <-ticker.C:
 			mu.fetchMessages(c)
 		}
 	}
 }
 
+func (mu *MessageUsecase) cronInterval() time.Duration {
+	mu.mu.RLock()
+	defer mu.mu.RUnlock()
+
+	return time.Duration(mu.config.ProducerCronDuration) * time.Second
+}
+
 func (mu *MessageUsecase) fetchMessages(c context.Context) {
-	if mu.isRunning {
-		messages, err := mu.messageRepository.GetPending(c, mu.producerBatchNumber)
+	mu.mu.RLock()
+	isRunning, batchNumber := mu.isRunning, mu.config.ProducerBatchNumber
+	mu.mu.RUnlock()
+
+	if isRunning {
+		// The provider is known to be down, claimed messages would only be
+		// released again
+		if breaker := mu.circuitBreaker.Stats(c); breaker.State == entity.CircuitOpen {
+			log.FromCtx(c).Info("Notification circuit is open, skipping this fetch cycle",
+				"retry_at", breaker.RetryAt)
+			return
+		}
+
+		// Only claim what the pool can take right now, claiming more would
+		// mean flipping the rest back to pending
+		freeSlots := mu.workerPool.FreeSlots()
+		if freeSlots == 0 {
+			log.FromCtx(c).Info("Worker pool is saturated, skipping this fetch cycle",
+				"in_flight", mu.workerPool.InFlight())
+			return
+		}
+
+		messages, err := mu.messageQueue.Claim(c, min(batchNumber, freeSlots))
+		if err != nil {
+			log.FromCtx(c).Error("Failed to claim messages", "error", err)
+			return
+		}
+		if len(messages) == 0 {
+			return
+		}
+
+		quietHours, err := mu.loadQuietHours(c)
 		if err != nil {
+			// Can't tell whether sending is allowed, try again on the next cycle
+			log.FromCtx(c).Error("Failed to load quiet hours, releasing claimed messages", "error", err)
+			claimed := make([]uint64, 0, len(messages))
+			for _, message := range messages {
+				claimed = append(claimed, message.ID)
+			}
+			mu.releaseMessages(c, claimed...)
+			mu.ackMessages(c, claimed...)
 			return
 		}
 
+		now := time.Now()
+		var unqueued []uint64
 		for _, message := range messages {
 			log.FromCtx(c).Info("Fetching", "message", message.ID)
-			succeed := mu.workerPool.AddJob(message)
-			// If can't add job to queue -> convert the status
-			if !succeed {
-				message.Status = "pending"
-				err = mu.messageRepository.Update(c, message.ID, message)
-				if err != nil {
-					log.FromCtx(c).
-						Error("Error changing status of message back to pending", "message", message.ID)
-				}
+
+			if allowedAt, quiet := quietUntil(message, quietHours, now); quiet {
+				mu.deferMessage(c, message.ID, allowedAt)
+				mu.ackMessages(c, message.ID)
+				continue
 			}
+
+			// A shard can still be full in ordered mode, the message goes
+			// back with the others that didn't fit
+			if !mu.workerPool.AddJob(message) {
+				unqueued = append(unqueued, message.ID)
+			}
+		}
+
+		if len(unqueued) > 0 {
+			mu.releaseMessages(c, unqueued...)
+			mu.ackMessages(c, unqueued...)
 		}
 	}
 }
 
-func (mu *MessageUsecase) StopAutomatedSending(c context.Context) error {
-	mu.mu.Lock()
-	defer mu.mu.Unlock()
+func (mu *MessageUsecase) stuckResetLoop(c context.Context) {
+	ticker := time.NewTicker(constant.MessageStuckCheckInterval)
+	defer ticker.Stop()
 
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+			mu.resetStuckMessages(c)
+		}
+	}
+}
+
+// resetStuckMessages puts the messages processing for longer than the
+// stuck timeout back to pending and in the queue. A message is only that
+// long in processing when its replica died or failed to update it, sends
+// have a shorter deadline. In ordered mode this also frees its sequence key.
+func (mu *MessageUsecase) resetStuckMessages(c context.Context) {
+	logger := log.FromCtx(c).WithFields("action", "Reset stuck messages")
+
+	mu.mu.RLock()
+	stuckTimeout := time.Duration(mu.config.StuckTimeout) * time.Second
+	mu.mu.RUnlock()
+
+	ids, err := mu.messageRepository.ResetStuck(c, time.Now().Add(-stuckTimeout))
+	if err != nil {
+		logger.Error("Failed to reset stuck messages", "error", err)
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	logger.Warn("Stuck messages put back to pending", "message_ids", ids)
+	if err = mu.messageQueue.Enqueue(c, ids...); err != nil {
+		logger.Warn("Failed to enqueue reset messages, they wait for the next sweep", "error", err)
+	}
+}
+
+// StopAutomatedSending stops the fetcher and waits for the workers to finish
+// the messages they are handling. The pool is stopped once the lock is
+// released, a worker retrying a message still reads the config under it.
+func (mu *MessageUsecase) StopAutomatedSending(c context.Context) error {
 	logger := log.FromCtx(c)
 	logger.Info("Stopping automated sending notification...")
 
-	if !mu.isRunning {
+	mu.mu.Lock()
+	wasRunning, workerPool := mu.isRunning, mu.workerPool
+	mu.isRunning = false
+	mu.cancel()
+	mu.mu.Unlock()
+
+	if !wasRunning {
 		logger.Info("Automated sending service already stopped")
 	}
 
-	if mu.workerPool != nil {
-		mu.workerPool.Stop()
+	if workerPool != nil {
+		workerPool.Stop()
 	}
 
-	mu.isRunning = false
-	mu.cancel()
+	if wasRunning {
+		mu.publishEvent(c, entity.EventServiceStopped, entity.Message{})
+	}
+	mu.auditUsecase.Record(c, entity.AuditServiceStop, map[string]any{"was_running": wasRunning})
 	logger.Info("Stopping automated sending notification successfully")
 	return nil
 }
@@ -140,11 +418,195 @@ func (mu *MessageUsecase) GetAutomatedSendingStatus(c context.Context) (bool, er
 	return mu.isRunning, nil
 }
 
-func (mu *MessageUsecase) GetSentMessagesWithPagination(c context.Context, page int) ([]entity.Message, error) {
+func (mu *MessageUsecase) GetWorkerPoolStats(c context.Context) (entity.WorkerPoolStats, error) {
+	mu.mu.RLock()
+	defer mu.mu.RUnlock()
+
+	if !mu.isRunning || mu.workerPool == nil {
+		return entity.WorkerPoolStats{}, nil
+	}
+
+	return mu.workerPool.Stats(), nil
+}
+
+func (mu *MessageUsecase) GetCircuitBreakerStats(c context.Context) (entity.CircuitBreakerStats, error) {
+	return mu.circuitBreaker.Stats(c), nil
+}
+
+func (mu *MessageUsecase) GetServiceConfig(c context.Context) (entity.ServiceConfig, error) {
+	mu.mu.RLock()
+	defer mu.mu.RUnlock()
+
+	return mu.config, nil
+}
+
+// UpdateServiceConfig applies new worker pool and fetcher settings without
+// restarting the service. The worker pool is resized in place and the fetcher
+// picks up the new interval and batch size on its next tick. The job buffer
+// and the autoscaler timings can't be changed live so they are kept as is,
+// neither can the worker counts in ordered mode where they set the shards.
+func (mu *MessageUsecase) UpdateServiceConfig(
+	c context.Context,
+	config entity.ServiceConfig,
+) (entity.ServiceConfig, error) {
+	logger := log.FromCtx(c).WithFields("action", "Update service config")
+
+	if config.WorkerCount <= 0 || config.ProducerCronDuration <= 0 || config.ProducerBatchNumber <= 0 {
+		return entity.ServiceConfig{}, errors.New("worker count, cron duration and batch number must be greater than 0")
+	}
+
+	if config.WorkerMinCount <= 0 || config.WorkerMinCount > config.WorkerMaxCount {
+		return entity.ServiceConfig{}, errors.New("worker min count must be greater than 0 and not above max count")
+	}
+
+	mu.mu.Lock()
+	defer mu.mu.Unlock()
+
+	if mu.config.OrderedDelivery && (config.WorkerCount != mu.config.WorkerCount ||
+		config.WorkerMinCount != mu.config.WorkerMinCount || config.WorkerMaxCount != mu.config.WorkerMaxCount) {
+		return entity.ServiceConfig{}, errors.New("worker counts can't be changed in ordered delivery mode")
+	}
+
+	cronChanged := mu.config.ProducerCronDuration != config.ProducerCronDuration
+	previous := mu.config
+
+	mu.config.WorkerMinCount = config.WorkerMinCount
+	mu.config.WorkerMaxCount = config.WorkerMaxCount
+	mu.config.WorkerCount = config.WorkerCount
+	if mu.config.Autoscaled() {
+		mu.config.WorkerCount = min(max(config.WorkerCount, config.WorkerMinCount), config.WorkerMaxCount)
+	}
+	mu.config.ProducerCronDuration = config.ProducerCronDuration
+	mu.config.ProducerBatchNumber = config.ProducerBatchNumber
+
+	if mu.isRunning && mu.workerPool != nil {
+		mu.workerPool.Resize(mu.config.WorkerCount)
+	}
+
+	if cronChanged {
+		// Non blocking, a pending signal already covers this change
+		select {
+		case mu.cronUpdated <- struct{}{}:
+		default:
+		}
+	}
+
+	mu.auditUsecase.Record(c, entity.AuditServiceConfigUpdate, map[string]any{
+		"previous": serviceConfigAuditParameters(previous),
+		"updated":  serviceConfigAuditParameters(mu.config),
+	})
+
+	logger.Info("Service config updated", "config", mu.config)
+	return mu.config, nil
+}
+
+// serviceConfigAuditParameters returns the settings UpdateServiceConfig changes.
+func serviceConfigAuditParameters(config entity.ServiceConfig) map[string]any {
+	return map[string]any{
+		"worker_count":     config.WorkerCount,
+		"worker_min_count": config.WorkerMinCount,
+		"worker_max_count": config.WorkerMaxCount,
+		"cron_duration":    config.ProducerCronDuration,
+		"batch_number":     config.ProducerBatchNumber,
+	}
+}
+
+// RequeueMessage puts a failed message back to pending for a new round of
+// attempts, it is sent on one of the next fetch cycles. Messages in any other
+// status aren't requeued, they were sent or are still being handled.
+func (mu *MessageUsecase) RequeueMessage(c context.Context, id uint64) (entity.Message, error) {
+	logger := log.FromCtx(c).WithFields("action", "Requeue message", "message_id", id)
+
+	message, err := mu.messageRepository.Requeue(c, id)
+	if err != nil {
+		return entity.Message{}, err
+	}
+
+	mu.auditUsecase.Record(c, entity.AuditMessageRequeue, map[string]any{
+		"message_id": id,
+		"tenant_id":  message.TenantID,
+	})
+
+	logger.Info("Message requeued")
+	if err = mu.messageQueue.Enqueue(c, message.ID); err != nil {
+		logger.Warn("Failed to enqueue message, it waits for the next sweep", "error", err)
+	}
+	return message, nil
+}
+
+func (mu *MessageUsecase) GetSentMessagesWithPagination(
+	c context.Context,
+	filter entity.MessageFilter,
+	page int,
+) ([]entity.Message, error) {
 	logger := log.FromCtx(c).WithFields("action", "Get sent message with pagination", "page", page)
 	logger.Info("Getting all sent message of this page")
 
-	return mu.messageRepository.GetSentWithPagination(c, page)
+	filter, err := mu.normalizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return mu.messageRepository.GetSentWithPagination(c, filter, page)
+}
+
+// ExportMessages calls fn with every message matching filter, sent,
+// delivered and failed ones unless other final statuses are asked for.
+func (mu *MessageUsecase) ExportMessages(
+	c context.Context,
+	filter entity.MessageFilter,
+	fn func(entity.Message) error,
+) error {
+	logger := log.FromCtx(c).WithFields("action", "Export messages", "tenant_id", filter.TenantID)
+
+	filter, err := mu.normalizeFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []entity.MessageStatus{entity.StatusSent, entity.StatusDelivered, entity.StatusFailed}
+	}
+	for _, status := range filter.Statuses {
+		if !slices.Contains(entity.FinalStatuses, status) {
+			return fmt.Errorf("%w: messages can't be exported in status %q", entity.ErrValidation, status)
+		}
+	}
+
+	exported := 0
+	err = mu.messageRepository.Export(c, filter, func(message entity.Message) error {
+		exported++
+		return fn(message)
+	})
+	logger.Info("Messages exported", "exported", exported, "statuses", filter.Statuses, "error", err)
+	return err
+}
+
+// normalizeFilter brings the phone number of filter to E.164 so it matches
+// the stored ones, and checks its range.
+func (mu *MessageUsecase) normalizeFilter(filter entity.MessageFilter) (entity.MessageFilter, error) {
+	if filter.PhoneNumber != "" {
+		phoneNumber, _, err := utils.NormalizePhoneNumber(filter.PhoneNumber, mu.ingestConfig.DefaultRegion)
+		if err != nil {
+			return entity.MessageFilter{}, err
+		}
+		filter.PhoneNumber = phoneNumber
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return entity.MessageFilter{}, fmt.Errorf("%w: from must be before to", entity.ErrValidation)
+	}
+
+	return filter, nil
+}
+
+// processClaimedMessage processes a claimed message and acks it to the
+// queue once its status has been updated. A panicking message is acked by
+// handleMessagePanic instead.
+func (mu *MessageUsecase) processClaimedMessage(ctx context.Context, message entity.Message) error {
+	err := mu.processSingleMessage(ctx, message)
+	mu.ackMessages(context.WithoutCancel(ctx), message.ID)
+	return err
 }
 
 // This function will provide at-least 1 notification sent but it will make sure
@@ -153,12 +615,79 @@ func (mu *MessageUsecase) processSingleMessage(ctx context.Context, message enti
 	logger := log.FromCtx(ctx).WithFields("message_id", message.ID)
 	logger.Info("Processing message")
 
+	// ctx carries the per message deadline and the stop signal, the status
+	// updates below must still go through once it is done
+	dbCtx := context.WithoutCancel(ctx)
+	mu.publishEvent(dbCtx, entity.EventMessageProcessing, message)
+
+	// 0. Don't burn a provider attempt on a number that can't receive it,
+	// messages inserted straight into the database skip CreateMessage
+	if _, _, err := utils.NormalizePhoneNumber(message.PhoneNumber, mu.ingestConfig.DefaultRegion); err != nil {
+		mu.handleMessageFailure(dbCtx, message, "", "invalid_recipient", err)
+		return err
+	}
+
+	// Never send to a recipient who opted out
+	suppressed, err := mu.suppressionRepository.IsSuppressed(ctx, message.PhoneNumber, message.Channel, message.TenantID)
+	if err != nil {
+		// Can't tell whether sending is allowed, try again later
+		mu.releaseMessages(dbCtx, message.ID)
+		return fmt.Errorf("failed to check suppression list: %w", err)
+	}
+
+	if suppressed {
+		logger.Info("Recipient is suppressed, message won't be sent")
+		timestamp := time.Now()
+		updates := map[string]any{
+			"status":     entity.StatusSuppressed,
+			"updated_at": timestamp,
+		}
+		if err = mu.messageRepository.UpdateSelective(dbCtx, message.ID, updates); err != nil {
+			logger.Error("Failed to set message status to suppressed", "error", err)
+			return nil
+		}
+
+		message.Status = entity.StatusSuppressed
+		message.UpdatedAt = &timestamp
+		mu.publishOutcome(dbCtx, entity.EventMessageSuppressed, message)
+		return nil
+	}
+
 	// 1. Send notification
 	logger.Info("Sending notification")
+	sendStart := time.Now()
 	messageUUID, err := mu.notificationService.SendNotification(ctx, message)
+	if !errors.Is(err, entity.ErrCircuitOpen) {
+		mu.autoscaler.observeLatency(time.Since(sendStart))
+	}
 	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrCircuitOpen):
+			// The provider is known to be down, the message waits for it
+			// rather than failing
+			mu.releaseMessages(dbCtx, message.ID)
+			return err
+		case errors.Is(ctx.Err(), context.Canceled):
+			// The service is stopping, give the message back so it is sent
+			// on the next start instead of failing it
+			mu.releaseMessages(dbCtx, message.ID)
+			return fmt.Errorf("notification interrupted: %w", err)
+		}
+
+		reason, class := "notification_failed", entity.ErrorClassOf(err)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			reason = "notification_timeout"
+		}
+		if class == entity.ErrorClassAuthFailure {
+			mu.alertAuthFailure(dbCtx, err)
+		}
+
+		if class.Retryable() && mu.retryMessage(dbCtx, message, class, reason, err) {
+			return fmt.Errorf("failed to send notification, retrying later: %w", err)
+		}
+
 		// Update status to failed before returning
-		mu.handleMessageFailure(ctx, message.ID, "notification_failed", err)
+		mu.handleMessageFailure(dbCtx, message, class, reason, err)
 		return fmt.Errorf("failed to send notification: %w", err)
 	}
 
@@ -172,15 +701,23 @@ func (mu *MessageUsecase) processSingleMessage(ctx context.Context, message enti
 		"status":     "sent",
 		"sent_at":    timestamp,
 		"message_id": messageUUID, // Store the UUID from notification service
+		"attempts":   message.Attempts + 1,
 		"updated_at": timestamp,
 	}
 
-	err = mu.messageRepository.UpdateSelective(ctx, message.ID, updates)
+	err = mu.messageRepository.UpdateSelective(dbCtx, message.ID, updates)
 	if err != nil {
 		// This error won't return cause message already sent
 		logger.Error("Failed to update message status", "error", err)
 	}
 
+	message.Status = entity.StatusSent
+	message.SentAt = &timestamp
+	message.MessageID = &messageUUID
+	message.Attempts++
+	message.UpdatedAt = &timestamp
+	mu.publishOutcome(dbCtx, entity.EventMessageSent, message)
+
 	// 3. Cache the result
 	if err = mu.cacheMessageResult(messageUUID, timestamp); err != nil {
 		// This error won't return cause message already sent
@@ -191,23 +728,233 @@ func (mu *MessageUsecase) processSingleMessage(ctx context.Context, message enti
 	return nil
 }
 
+// handleMessageFailure fails message for good. class is the provider error
+// class, empty when the message didn't reach the provider.
 func (mu *MessageUsecase) handleMessageFailure(
 	ctx context.Context,
-	messageID uint64,
+	message entity.Message,
+	class entity.ErrorClass,
 	reason string,
 	originalErr error,
 ) {
-	logger := log.FromCtx(ctx).WithFields("message_id", messageID)
+	logger := log.FromCtx(ctx).WithFields("message_id", message.ID)
 
+	timestamp := time.Now()
+	errorMessage := fmt.Sprintf("%s: %v", reason, originalErr)
 	updates := map[string]any{
 		"status":        "failed",
-		"error_message": fmt.Sprintf("%s: %v", reason, originalErr),
-		"updated_at":    time.Now(),
+		"error_message": errorMessage,
+		"updated_at":    timestamp,
+	}
+	if class != "" {
+		message.ErrorClass = &class
+		message.Attempts++
+		updates["error_class"] = class
+		updates["attempts"] = message.Attempts
 	}
 
-	if err := mu.messageRepository.UpdateSelective(ctx, messageID, updates); err != nil {
+	if err := mu.messageRepository.UpdateSelective(ctx, message.ID, updates); err != nil {
 		logger.Error("Failed to set message status to failed", "error", err)
+		return
+	}
+
+	message.Status = entity.StatusFailed
+	message.ErrorMessage = &errorMessage
+	message.UpdatedAt = &timestamp
+	mu.publishOutcome(ctx, entity.EventMessageFailed, message)
+}
+
+// retryMessage puts message back to pending until its next attempt, with an
+// exponential backoff or the delay the provider asked for. It returns false
+// when the message is out of attempts and has to fail instead.
+func (mu *MessageUsecase) retryMessage(
+	ctx context.Context,
+	message entity.Message,
+	class entity.ErrorClass,
+	reason string,
+	originalErr error,
+) bool {
+	logger := log.FromCtx(ctx).WithFields("message_id", message.ID)
+
+	mu.mu.RLock()
+	config := mu.config
+	mu.mu.RUnlock()
+
+	attempts := message.Attempts + 1
+	if attempts >= config.MaxAttempts {
+		return false
+	}
+
+	delay := max(config.RetryDelay(attempts), entity.RetryAfterOf(originalErr))
+
+	timestamp := time.Now()
+	errorMessage := fmt.Sprintf("%s: %v", reason, originalErr)
+	updates := map[string]any{
+		"status":        entity.StatusPending,
+		"scheduled_at":  timestamp.Add(delay),
+		"attempts":      attempts,
+		"error_message": errorMessage,
+		"error_class":   class,
+		"updated_at":    timestamp,
+	}
+
+	if err := mu.messageRepository.UpdateSelective(ctx, message.ID, updates); err != nil {
+		// Left processing, resetStuckMessages puts the message back to
+		// pending after the stuck timeout
+		logger.Error("Failed to schedule message retry", "error", err)
+		return true
+	}
+
+	logger.Warn("Notification failed, message will be retried",
+		"error_class", class, "attempts", attempts, "retry_in", delay.String())
+	return true
+}
+
+// alertAuthFailure raises an alert that the provider refuses our
+// credentials, at most once per constant.ProviderAlertInterval.
+func (mu *MessageUsecase) alertAuthFailure(ctx context.Context, err error) {
+	now := time.Now()
+	last := mu.lastAuthAlert.Load()
+	if now.Sub(time.Unix(last, 0)) < constant.ProviderAlertInterval ||
+		!mu.lastAuthAlert.CompareAndSwap(last, now.Unix()) {
+		return
+	}
+
+	log.FromCtx(ctx).Error("ALERT: notification provider rejected the credentials, check WEBHOOK_AUTH_KEY",
+		"alert", entity.EventProviderAuthFailed, "error", err)
+	mu.publishEvent(ctx, entity.EventProviderAuthFailed, entity.Message{})
+}
+
+// publishEvent hands a lifecycle event of message to the webhook
+// subscriptions and the live stream, a failure doesn't affect the message
+// itself.
+func (mu *MessageUsecase) publishEvent(ctx context.Context, eventType entity.EventType, message entity.Message) {
+	mu.publish(ctx, eventType, message, false)
+}
+
+// publishOutcome publishes the event of the status processing message ended
+// with, which is also posted to its callback url.
+func (mu *MessageUsecase) publishOutcome(ctx context.Context, eventType entity.EventType, message entity.Message) {
+	mu.publish(ctx, eventType, message, true)
+}
+
+func (mu *MessageUsecase) publish(ctx context.Context, eventType entity.EventType, message entity.Message, final bool) {
+	event := entity.Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		OccurredAt: time.Now(),
+		Message:    message,
+		Final:      final,
+	}
+
+	if err := mu.eventPublisher.Publish(ctx, event); err != nil {
+		log.FromCtx(ctx).Error("Failed to publish message event",
+			"message_id", message.ID, "event", eventType, "error", err)
+	}
+}
+
+// handleMessagePanic is called by the worker pool when processing a message
+// panicked, the worker itself keeps running.
+func (mu *MessageUsecase) handleMessagePanic(ctx context.Context, message entity.Message, recovered any) {
+	ctx = context.WithoutCancel(ctx)
+	mu.handleMessageFailure(ctx, message, "", "panic", fmt.Errorf("%v", recovered))
+	mu.ackMessages(ctx, message.ID)
+}
+
+// releaseMessages puts claimed messages back to pending in one update,
+// then back in the queue so the fetcher picks them up again.
+func (mu *MessageUsecase) releaseMessages(ctx context.Context, messageIDs ...uint64) {
+	logger := log.FromCtx(ctx).WithFields("message_ids", messageIDs)
+
+	if _, err := mu.messageRepository.Release(ctx, messageIDs); err != nil {
+		logger.Error("Failed to set messages status back to pending", "error", err)
+		return
+	}
+
+	if err := mu.messageQueue.Enqueue(ctx, messageIDs...); err != nil {
+		logger.Warn("Failed to enqueue released messages, they wait for the next sweep", "error", err)
+	}
+}
+
+// ackMessages tells the queue claimed messages are done with, their status
+// has to be updated first. An unacked message is claimed again by another
+// replica once Postgres lets it.
+func (mu *MessageUsecase) ackMessages(ctx context.Context, messageIDs ...uint64) {
+	if err := mu.messageQueue.Ack(ctx, messageIDs...); err != nil {
+		log.FromCtx(ctx).Warn("Failed to ack messages", "message_ids", messageIDs, "error", err)
+	}
+}
+
+// deferMessage puts a claimed message back to pending, it won't be
+// claimed again before scheduledAt.
+func (mu *MessageUsecase) deferMessage(ctx context.Context, messageID uint64, scheduledAt time.Time) {
+	logger := log.FromCtx(ctx).WithFields("message_id", messageID)
+
+	updates := map[string]any{
+		"status":       entity.StatusPending,
+		"scheduled_at": scheduledAt,
+		"updated_at":   time.Now(),
+	}
+
+	if err := mu.messageRepository.UpdateSelective(ctx, messageID, updates); err != nil {
+		logger.Error("Failed to defer message", "error", err)
+		return
+	}
+
+	logger.Info("Message is inside its quiet hours, deferred", "scheduled_at", scheduledAt)
+}
+
+type quietHoursKey struct {
+	tenantID string
+	category entity.MessageCategory
+}
+
+// loadQuietHours returns the quiet hours of every tenant by tenant and category.
+func (mu *MessageUsecase) loadQuietHours(ctx context.Context) (map[quietHoursKey]entity.QuietHours, error) {
+	list, err := mu.quietHoursRepository.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	quietHours := make(map[quietHoursKey]entity.QuietHours, len(list))
+	for _, q := range list {
+		quietHours[quietHoursKey{tenantID: q.TenantID, category: q.Category}] = q
+	}
+	return quietHours, nil
+}
+
+// quietUntil returns the end of the quiet hours message falls in at now,
+// and false when it can be sent right away.
+func quietUntil(message entity.Message, quietHours map[quietHoursKey]entity.QuietHours, now time.Time) (time.Time, bool) {
+	window, ok := quietHours[quietHoursKey{tenantID: message.TenantID, category: message.Category}]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	local := now.In(recipientLocation(message))
+	if !window.Contains(local) {
+		return time.Time{}, false
+	}
+
+	return window.NextAllowed(local), true
+}
+
+// recipientLocation returns the timezone set on the message, the one of its
+// phone number for messages inserted straight into the database or whose
+// timezone this host doesn't know, UTC otherwise.
+func recipientLocation(message entity.Message) *time.Location {
+	if message.Timezone != nil && *message.Timezone != "" {
+		if location, err := time.LoadLocation(*message.Timezone); err == nil {
+			return location
+		}
+	}
+
+	if timezone := utils.PhoneTimezone(message.PhoneNumber); timezone != "" {
+		if location, err := time.LoadLocation(timezone); err == nil {
+			return location
+		}
 	}
+	return time.UTC
 }
 
 // Helper function for caching.
@@ -0,0 +1,271 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/fakeprovider"
+	"github.com/craftaholic/insider/internal/repository"
+	"github.com/craftaholic/insider/internal/repository/memory"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopAutomatedSendingDuringRetry(t *testing.T) {
+	ctx := context.Background()
+	messages := memory.NewMessageRepository(nil, nil)
+
+	var messageUsecase *MessageUsecase
+	retrying := make(chan struct{})
+	hooked := &hookedMessageRepository{
+		MessageRepository: messages,
+		beforeUpdate: func(_ uint64, updates map[string]any) {
+			if updates["status"] != entity.StatusPending {
+				return
+			}
+
+			// The retry goes on once the pool is stopping, reading the
+			// config like retryMessage does
+			close(retrying)
+			<-messageUsecase.workerPool.ctx.Done()
+			_, _ = messageUsecase.GetServiceConfig(ctx)
+		},
+	}
+	transient := notificationFunc(func(context.Context, entity.Message) (string, error) {
+		return "", &entity.NotificationError{Class: entity.ErrorClassTransient, StatusCode: 503, Detail: "unavailable"}
+	})
+	messageUsecase = newTestMessageUsecase(hooked, transient, testServiceConfig())
+
+	require.NoError(t, messages.Create(ctx, &entity.Message{PhoneNumber: "+905551111113", Content: "Your code is 1234"}))
+	require.NoError(t, messageUsecase.StartAutomatedSending(ctx))
+
+	select {
+	case <-retrying:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message wasn't retried")
+	}
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- messageUsecase.StopAutomatedSending(ctx) }()
+
+	select {
+	case err := <-stopped:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stopping the service waited forever on the message being retried")
+	}
+
+	pending := messagesIn(t, messages, entity.StatusPending)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 1, pending[0].Attempts)
+	require.NotNil(t, pending[0].ScheduledAt)
+	assert.True(t, pending[0].ScheduledAt.After(time.Now()))
+
+	running, err := messageUsecase.GetAutomatedSendingStatus(ctx)
+	require.NoError(t, err)
+	assert.False(t, running)
+}
+
+func TestFetchClaimsOnlyFreeSlots(t *testing.T) {
+	tests := []struct {
+		name           string
+		buffer         int
+		queued         int
+		batch          int
+		wantProcessing int
+	}{
+		{name: "LessFreeSlotsThanBatch", buffer: 2, batch: 4, wantProcessing: 2},
+		{name: "MoreFreeSlotsThanBatch", buffer: 10, batch: 4, wantProcessing: 4},
+		{name: "PartlyQueued", buffer: 4, queued: 3, batch: 4, wantProcessing: 1},
+		{name: "Saturated", buffer: 4, queued: 4, batch: 4, wantProcessing: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			messages := memory.NewMessageRepository(nil, nil)
+			for i := range 5 {
+				require.NoError(t, messages.Create(ctx, &entity.Message{
+					PhoneNumber: fmt.Sprintf("+90555111111%d", i),
+					Content:     "Your code is 1234",
+				}))
+			}
+
+			config := testServiceConfig()
+			config.ProducerBatchNumber = tt.batch
+			messageUsecase := newTestMessageUsecase(messages, nil, config)
+
+			// A pool without workers, the claimed messages stay in its buffer
+			pool := newWorkerPool(ctx, 0, tt.buffer, 0)
+			for range tt.queued {
+				require.True(t, pool.AddJob(entity.Message{}))
+			}
+			messageUsecase.isRunning, messageUsecase.workerPool = true, pool
+
+			messageUsecase.fetchMessages(ctx)
+
+			// Nothing is claimed only to be put back to pending
+			assert.Len(t, messagesIn(t, messages, entity.StatusProcessing), tt.wantProcessing)
+			assert.Len(t, messagesIn(t, messages, entity.StatusPending), 5-tt.wantProcessing)
+			assert.Equal(t, tt.queued+tt.wantProcessing, pool.Queued())
+		})
+	}
+}
+
+func TestSendThroughProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      fakeprovider.Config
+		apiKey      string
+		wantOutcome fakeprovider.Outcome
+		wantStatus  entity.MessageStatus
+		wantClass   entity.ErrorClass
+		// wantDelay is the least the retry is scheduled after
+		wantDelay time.Duration
+	}{
+		{
+			name:        "Accepted",
+			config:      fakeprovider.Config{AuthKey: "provider-key"},
+			apiKey:      "provider-key",
+			wantOutcome: fakeprovider.OutcomeAccepted,
+			wantStatus:  entity.StatusSent,
+		},
+		{
+			name:        "InvalidRecipient",
+			config:      fakeprovider.Config{InvalidRecipientRate: 1},
+			wantOutcome: fakeprovider.OutcomeInvalidRecipient,
+			wantStatus:  entity.StatusFailed,
+			wantClass:   entity.ErrorClassInvalidRecipient,
+		},
+		{
+			name:        "ServerError",
+			config:      fakeprovider.Config{ServerErrorRate: 1},
+			wantOutcome: fakeprovider.OutcomeServerError,
+			wantStatus:  entity.StatusPending,
+			wantClass:   entity.ErrorClassTransient,
+			wantDelay:   time.Minute - time.Second,
+		},
+		{
+			name:        "RateLimited",
+			config:      fakeprovider.Config{RateLimitRate: 1, RetryAfter: 900},
+			wantOutcome: fakeprovider.OutcomeRateLimited,
+			wantStatus:  entity.StatusPending,
+			wantClass:   entity.ErrorClassRateLimited,
+			wantDelay:   15*time.Minute - time.Second,
+		},
+		{
+			name:        "Malformed",
+			config:      fakeprovider.Config{MalformedRate: 1},
+			wantOutcome: fakeprovider.OutcomeMalformed,
+			wantStatus:  entity.StatusPending,
+			wantClass:   entity.ErrorClassTransient,
+		},
+		{
+			name:        "Unauthorized",
+			config:      fakeprovider.Config{AuthKey: "provider-key"},
+			apiKey:      "revoked-key",
+			wantOutcome: fakeprovider.OutcomeUnauthorized,
+			wantStatus:  entity.StatusPending,
+			wantClass:   entity.ErrorClassAuthFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			provider := fakeprovider.NewServer(tt.config)
+			defer provider.Close()
+
+			messages := memory.NewMessageRepository(nil, nil)
+			notification := repository.NewNotificationService(resty.New(), tt.apiKey, provider.URL)
+			messageUsecase := newTestMessageUsecase(messages, notification, testServiceConfig())
+
+			message := entity.Message{PhoneNumber: "+905551111111", Content: "Your code is 1234"}
+			require.NoError(t, messages.Create(ctx, &message))
+			require.NoError(t, messageUsecase.StartAutomatedSending(ctx))
+
+			// The message is done with once it is out of processing
+			require.Eventually(t, func() bool {
+				return len(provider.Requests()) == 1 && len(messagesIn(t, messages, entity.StatusProcessing)) == 0
+			}, 5*time.Second, 10*time.Millisecond)
+			require.NoError(t, messageUsecase.StopAutomatedSending(ctx))
+
+			request := provider.Requests()[0]
+			assert.Equal(t, tt.wantOutcome, request.Outcome)
+			assert.Equal(t, message.PhoneNumber, request.To)
+			assert.Equal(t, message.Content, request.Content)
+
+			stored := messagesIn(t, messages, tt.wantStatus)
+			require.Len(t, stored, 1)
+			assert.Equal(t, 1, stored[0].Attempts)
+			if tt.wantClass == "" {
+				require.NotNil(t, stored[0].MessageID)
+				assert.Equal(t, request.MessageID, *stored[0].MessageID)
+				assert.Nil(t, stored[0].ErrorClass)
+				return
+			}
+
+			require.NotNil(t, stored[0].ErrorClass)
+			assert.Equal(t, tt.wantClass, *stored[0].ErrorClass)
+			if tt.wantStatus == entity.StatusPending {
+				// Retried after the backoff or the Retry-After of the provider
+				require.NotNil(t, stored[0].ScheduledAt)
+				assert.True(t, stored[0].ScheduledAt.After(time.Now().Add(tt.wantDelay)),
+					"retried at %s", stored[0].ScheduledAt)
+			}
+		})
+	}
+}
+
+func TestQuietUntil(t *testing.T) {
+	quietHours := map[quietHoursKey]entity.QuietHours{
+		{tenantID: "default", category: entity.CategoryMarketing}: {
+			TenantID:  "default",
+			Category:  entity.CategoryMarketing,
+			StartTime: "22:00",
+			EndTime:   "08:00",
+		},
+	}
+	// 23:30 in Istanbul
+	now := time.Date(2026, time.May, 4, 20, 30, 0, 0, time.UTC)
+	istanbulMorning := time.Date(2026, time.May, 5, 5, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		phone    string
+		timezone string
+		category entity.MessageCategory
+		want     time.Time
+	}{
+		{name: "Timezone", phone: "+15555550100", timezone: "Europe/Istanbul", want: istanbulMorning},
+		{name: "PhoneNumber", phone: "+905551111111", want: istanbulMorning},
+		{name: "TimezoneOverPhoneNumber", phone: "+905551111111", timezone: "UTC"},
+		{name: "UnknownTimezone", phone: "+905551111111", timezone: "Mars/Olympus", want: istanbulMorning},
+		{name: "UnknownTimezoneAndPhoneNumber", phone: "12345", timezone: "Mars/Olympus"},
+		{name: "OtherCategory", phone: "+905551111111", category: entity.CategoryTransactional},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := entity.Message{
+				TenantID:    "default",
+				Category:    entity.CategoryMarketing,
+				PhoneNumber: tt.phone,
+			}
+			if tt.category != "" {
+				message.Category = tt.category
+			}
+			if tt.timezone != "" {
+				message.Timezone = &tt.timezone
+			}
+
+			until, quiet := quietUntil(message, quietHours, now)
+			assert.Equal(t, !tt.want.IsZero(), quiet)
+			assert.True(t, tt.want.Equal(until), "quiet until %s", until)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+)
+
+type PartitionUsecase struct {
+	partitionRepository interfaces.PartitionRepository
+
+	config entity.PartitionConfig
+}
+
+func NewPartitionUsecase(
+	partitionRepository interfaces.PartitionRepository,
+	config entity.PartitionConfig,
+) interfaces.PartitionUsecase {
+	return &PartitionUsecase{
+		partitionRepository: partitionRepository,
+		config:              config,
+	}
+}
+
+// StartMaintenance creates the upcoming partitions of the messages table
+// and drops the expired ones in the background until c is done, once
+// right away and then every Interval.
+func (pu *PartitionUsecase) StartMaintenance(c context.Context) {
+	if pu.config.Interval <= 0 {
+		log.FromCtx(c).Warn("Message partition maintenance is disabled")
+		return
+	}
+
+	go func() {
+		pu.maintain(c)
+
+		ticker := time.NewTicker(time.Duration(pu.config.Interval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.Done():
+				return
+			case <-ticker.C:
+				pu.maintain(c)
+			}
+		}
+	}()
+}
+
+func (pu *PartitionUsecase) maintain(c context.Context) {
+	logger := log.FromCtx(c).WithFields("action", "Maintain message partitions")
+
+	created, err := pu.partitionRepository.CreatePartitions(c, pu.config.MonthsAhead)
+	if err != nil {
+		logger.Error("Failed to create message partitions", "error", err)
+	} else if len(created) > 0 {
+		logger.Info("Message partitions created", "partitions", created)
+	}
+
+	if pu.config.RetentionMonths <= 0 {
+		return
+	}
+
+	dropped, err := pu.partitionRepository.DropPartitions(c, pu.config.RetentionMonths)
+	if err != nil {
+		logger.Error("Failed to drop message partitions", "error", err)
+	} else if len(dropped) > 0 {
+		logger.Info("Message partitions dropped", "partitions", dropped)
+	}
+}
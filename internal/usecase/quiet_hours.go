@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+)
+
+type QuietHoursUsecase struct {
+	quietHoursRepository interfaces.QuietHoursRepository
+	auditUsecase         interfaces.AuditUsecase
+}
+
+func NewQuietHoursUsecase(
+	quietHoursRepository interfaces.QuietHoursRepository,
+	auditUsecase interfaces.AuditUsecase,
+) interfaces.QuietHoursUsecase {
+	return &QuietHoursUsecase{
+		quietHoursRepository: quietHoursRepository,
+		auditUsecase:         auditUsecase,
+	}
+}
+
+// SetQuietHours creates or replaces the quiet hours of a tenant for one category.
+func (qu *QuietHoursUsecase) SetQuietHours(
+	c context.Context,
+	quietHours entity.QuietHours,
+) (entity.QuietHours, error) {
+	logger := log.FromCtx(c).WithFields("action", "Set quiet hours", "tenant_id", quietHours.TenantID)
+
+	if quietHours.TenantID == "" {
+		quietHours.TenantID = entity.DefaultTenantID
+	}
+	if quietHours.Category == "" {
+		quietHours.Category = entity.CategoryMarketing
+	}
+
+	if err := quietHours.Validate(); err != nil {
+		return entity.QuietHours{}, err
+	}
+
+	if err := qu.quietHoursRepository.Upsert(c, &quietHours); err != nil {
+		return entity.QuietHours{}, err
+	}
+
+	qu.auditUsecase.Record(c, entity.AuditQuietHoursSet, map[string]any{
+		"quiet_hours_id": quietHours.ID,
+		"tenant_id":      quietHours.TenantID,
+		"category":       quietHours.Category,
+		"start_time":     quietHours.StartTime,
+		"end_time":       quietHours.EndTime,
+	})
+
+	logger.Info("Quiet hours saved", "category", quietHours.Category,
+		"start_time", quietHours.StartTime, "end_time", quietHours.EndTime)
+	return quietHours, nil
+}
+
+func (qu *QuietHoursUsecase) RemoveQuietHours(c context.Context, id uint64) error {
+	logger := log.FromCtx(c).WithFields("action", "Remove quiet hours", "quiet_hours_id", id)
+
+	if err := qu.quietHoursRepository.Delete(c, id); err != nil {
+		return err
+	}
+
+	qu.auditUsecase.Record(c, entity.AuditQuietHoursDelete, map[string]any{"quiet_hours_id": id})
+
+	logger.Info("Quiet hours removed")
+	return nil
+}
+
+func (qu *QuietHoursUsecase) ListQuietHours(c context.Context, tenantID string) ([]entity.QuietHours, error) {
+	return qu.quietHoursRepository.List(c, tenantID)
+}
@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/constant"
+	"github.com/craftaholic/insider/internal/shared/log"
+)
+
+type RetentionUsecase struct {
+	retentionRepository interfaces.RetentionRepository
+	cacheRepository     interfaces.CacheRepository
+
+	config entity.RetentionConfig
+}
+
+func NewRetentionUsecase(
+	retentionRepository interfaces.RetentionRepository,
+	cacheRepository interfaces.CacheRepository,
+	config entity.RetentionConfig,
+) interfaces.RetentionUsecase {
+	return &RetentionUsecase{
+		retentionRepository: retentionRepository,
+		cacheRepository:     cacheRepository,
+		config:              config,
+	}
+}
+
+// GetRetention returns the retention policy along with the report of the
+// last run of any replica, nil when none has run yet.
+func (ru *RetentionUsecase) GetRetention(c context.Context) (entity.RetentionConfig, *entity.RetentionReport, error) {
+	data, err := ru.cacheRepository.Get(constant.RetentionReportKey)
+	if errors.Is(err, entity.ErrNotFound) {
+		return ru.config, nil, nil
+	}
+	if err != nil {
+		return entity.RetentionConfig{}, nil, fmt.Errorf("failed to load retention report: %w", err)
+	}
+
+	var report entity.RetentionReport
+	if err = json.Unmarshal(data, &report); err != nil {
+		return entity.RetentionConfig{}, nil, fmt.Errorf("failed to decode retention report: %w", err)
+	}
+
+	return ru.config, &report, nil
+}
+
+// StartJob prunes the messages past their retention in the background
+// until c is done, once right away and then every Interval.
+func (ru *RetentionUsecase) StartJob(c context.Context) {
+	if len(ru.config.Rules) == 0 || ru.config.Interval <= 0 || ru.config.Batch <= 0 {
+		log.FromCtx(c).Warn("Message retention is disabled")
+		return
+	}
+
+	go func() {
+		ru.run(c)
+
+		ticker := time.NewTicker(time.Duration(ru.config.Interval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.Done():
+				return
+			case <-ticker.C:
+				ru.run(c)
+			}
+		}
+	}()
+}
+
+func (ru *RetentionUsecase) run(c context.Context) {
+	logger := log.FromCtx(c).WithFields("action", "Prune messages", "mode", ru.config.Mode)
+
+	report := entity.RetentionReport{
+		StartedAt: time.Now(),
+		Mode:      ru.config.Mode,
+		Pruned:    map[entity.MessageStatus]int64{},
+	}
+
+	var archive *archiveFile
+	if ru.config.Mode == entity.RetentionFile {
+		archive = &archiveFile{dir: ru.config.ArchiveDir, startedAt: report.StartedAt}
+	}
+
+	err := ru.prune(c, &report, archive)
+	if archive != nil {
+		if closeErr := archive.Close(); closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+		if archive.path != "" {
+			report.Files = append(report.Files, archive.path)
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	if err != nil {
+		report.Error = err.Error()
+		logger.Error("Message pruning stopped", "error", err, "pruned", report.Pruned)
+	} else {
+		logger.Info("Messages pruned", "pruned", report.Pruned, "total", report.Total(),
+			"duration", report.FinishedAt.Sub(report.StartedAt))
+	}
+
+	data, err := json.Marshal(report)
+	if err == nil {
+		err = ru.cacheRepository.Set(constant.RetentionReportKey, data, 0)
+	}
+	if err != nil {
+		logger.Error("Failed to save retention report", "error", err)
+	}
+}
+
+// prune applies every rule batch by batch, pausing in between so the
+// deletes don't starve the rest of the traffic.
+func (ru *RetentionUsecase) prune(c context.Context, report *entity.RetentionReport, archive *archiveFile) error {
+	for _, rule := range ru.config.Rules {
+		before := report.StartedAt.AddDate(0, 0, -rule.Days)
+
+		for {
+			pruned, err := ru.pruneBatch(c, rule.Status, before, archive)
+			report.Pruned[rule.Status] += pruned
+			if err != nil {
+				return err
+			}
+
+			// A short batch means nothing is left, or the rest is locked
+			// by another replica pruning at the same time
+			if pruned < int64(ru.config.Batch) {
+				break
+			}
+
+			select {
+			case <-c.Done():
+				return c.Err()
+			case <-time.After(constant.RetentionBatchPause):
+			}
+		}
+	}
+
+	return nil
+}
+
+func (ru *RetentionUsecase) pruneBatch(
+	c context.Context,
+	status entity.MessageStatus,
+	before time.Time,
+	archive *archiveFile,
+) (int64, error) {
+	switch ru.config.Mode {
+	case entity.RetentionArchive:
+		return ru.retentionRepository.ArchiveExpired(c, status, before, ru.config.Batch)
+	case entity.RetentionFile:
+		return ru.retentionRepository.TakeExpired(c, status, before, ru.config.Batch, archive.Write)
+	default:
+		return ru.retentionRepository.DeleteExpired(c, status, before, ru.config.Batch)
+	}
+}
+
+// archiveFile is the gzip compressed NDJSON file of one run, created with
+// its first message.
+type archiveFile struct {
+	dir       string
+	startedAt time.Time
+	path      string
+	file      *os.File
+	gzip      *gzip.Writer
+}
+
+// Write appends messages to the file and syncs it to disk, the messages are
+// only deleted afterwards. A run cut short leaves a file without its gzip
+// trailer, its content still reads with zcat.
+func (a *archiveFile) Write(messages []entity.Message) error {
+	if a.file == nil {
+		if err := os.MkdirAll(a.dir, 0o750); err != nil {
+			return fmt.Errorf("failed to create archive directory: %w", err)
+		}
+
+		path := filepath.Join(a.dir, fmt.Sprintf("messages-%s.ndjson.gz", a.startedAt.UTC().Format("20060102T150405Z")))
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+		if err != nil {
+			return fmt.Errorf("failed to create archive file: %w", err)
+		}
+
+		a.path, a.file, a.gzip = path, file, gzip.NewWriter(file)
+	}
+
+	encoder := json.NewEncoder(a.gzip)
+	for _, message := range messages {
+		if err := encoder.Encode(message); err != nil {
+			return fmt.Errorf("failed to write archive file: %w", err)
+		}
+	}
+
+	if err := a.gzip.Flush(); err != nil {
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+	if err := a.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync archive file: %w", err)
+	}
+	return nil
+}
+
+func (a *archiveFile) Close() error {
+	if a.file == nil {
+		return nil
+	}
+
+	if err := a.gzip.Close(); err != nil {
+		_ = a.file.Close()
+		return fmt.Errorf("failed to finish archive file: %w", err)
+	}
+	if err := a.file.Sync(); err != nil {
+		_ = a.file.Close()
+		return fmt.Errorf("failed to sync archive file: %w", err)
+	}
+	return a.file.Close()
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/constant"
+	"github.com/craftaholic/insider/internal/shared/log"
+)
+
+type StatsUsecase struct {
+	statsRepository interfaces.StatsRepository
+}
+
+func NewStatsUsecase(statsRepository interfaces.StatsRepository) interfaces.StatsUsecase {
+	return &StatsUsecase{
+		statsRepository: statsRepository,
+	}
+}
+
+// GetStats computes the message statistics of the filter. The range ends
+// now and covers the last day unless given, it can't exceed a month and
+// the per minute throughput is only computed up to a day.
+func (su *StatsUsecase) GetStats(c context.Context, filter entity.StatsFilter) (entity.MessageStats, error) {
+	logger := log.FromCtx(c).WithFields("action", "Get stats", "tenant_id", filter.TenantID)
+
+	if filter.To.IsZero() {
+		filter.To = time.Now()
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.Add(-constant.StatsDefaultRange)
+	}
+	if !filter.From.Before(filter.To) {
+		return entity.MessageStats{}, fmt.Errorf("%w: from must be before to", entity.ErrValidation)
+	}
+	if filter.To.Sub(filter.From) > constant.StatsMaxRange {
+		return entity.MessageStats{}, fmt.Errorf("%w: the range can't exceed %s",
+			entity.ErrValidation, constant.StatsMaxRange)
+	}
+
+	stats := entity.MessageStats{Filter: filter}
+
+	var err error
+	if stats.Counts, err = su.statsRepository.CountByStatus(c, filter); err != nil {
+		return entity.MessageStats{}, err
+	}
+
+	if filter.To.Sub(filter.From) <= constant.StatsMinuteMaxRange {
+		stats.ThroughputPerMin, err = su.statsRepository.Throughput(c, filter, entity.GranularityMinute)
+		if err != nil {
+			return entity.MessageStats{}, err
+		}
+	}
+
+	if stats.ThroughputPerHour, err = su.statsRepository.Throughput(c, filter, entity.GranularityHour); err != nil {
+		return entity.MessageStats{}, err
+	}
+
+	if stats.Latency, err = su.statsRepository.Latency(c, filter); err != nil {
+		return entity.MessageStats{}, err
+	}
+
+	if stats.FailureReasons, err = su.statsRepository.FailureReasons(c, filter); err != nil {
+		return entity.MessageStats{}, err
+	}
+
+	logger.Info("Stats computed", "from", filter.From, "to", filter.To)
+	return stats, nil
+}
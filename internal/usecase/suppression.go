@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/craftaholic/insider/internal/shared/redact"
+	"github.com/craftaholic/insider/internal/utils"
+)
+
+// stopKeywords are the replies that opt a recipient out, matched on the
+// first word of the inbound message regardless of case and of the
+// punctuation around it.
+var stopKeywords = map[string]struct{}{
+	"STOP":        {},
+	"STOPALL":     {},
+	"UNSUBSCRIBE": {},
+	"CANCEL":      {},
+	"END":         {},
+	"QUIT":        {},
+}
+
+type SuppressionUsecase struct {
+	suppressionRepository interfaces.SuppressionRepository
+	auditUsecase          interfaces.AuditUsecase
+	ingestConfig          entity.IngestConfig
+}
+
+func NewSuppressionUsecase(
+	suppressionRepository interfaces.SuppressionRepository,
+	auditUsecase interfaces.AuditUsecase,
+	ingestConfig entity.IngestConfig,
+) interfaces.SuppressionUsecase {
+	return &SuppressionUsecase{
+		suppressionRepository: suppressionRepository,
+		auditUsecase:          auditUsecase,
+		ingestConfig:          ingestConfig,
+	}
+}
+
+func (su *SuppressionUsecase) AddSuppression(
+	c context.Context,
+	suppression entity.Suppression,
+) (entity.Suppression, error) {
+	logger := log.FromCtx(c).WithFields("action", "Add suppression", "tenant_id", suppression.TenantID)
+
+	// Messages are stored in E.164, the suppression has to match them
+	phoneNumber, _, err := utils.NormalizePhoneNumber(suppression.PhoneNumber, su.ingestConfig.DefaultRegion)
+	if err != nil {
+		return entity.Suppression{}, err
+	}
+	suppression.PhoneNumber = phoneNumber
+
+	if suppression.Channel == "" {
+		suppression.Channel = entity.DefaultChannel
+	}
+	if suppression.TenantID == "" {
+		suppression.TenantID = entity.DefaultTenantID
+	}
+	if suppression.Source == "" {
+		suppression.Source = entity.SuppressionSourceAdmin
+	}
+
+	if err = su.suppressionRepository.Create(c, &suppression); err != nil {
+		return entity.Suppression{}, err
+	}
+
+	// The audit log keeps the phone number masked like the logs
+	su.auditUsecase.Record(c, entity.AuditSuppressionCreate, map[string]any{
+		"suppression_id": suppression.ID,
+		"phone_number":   redact.PhoneNumber(suppression.PhoneNumber),
+		"channel":        suppression.Channel,
+		"tenant_id":      suppression.TenantID,
+		"source":         suppression.Source,
+	})
+
+	logger.Info("Recipient suppressed", "suppression_id", suppression.ID, "source", suppression.Source)
+	return suppression, nil
+}
+
+func (su *SuppressionUsecase) RemoveSuppression(c context.Context, id uint64) error {
+	logger := log.FromCtx(c).WithFields("action", "Remove suppression", "suppression_id", id)
+
+	if err := su.suppressionRepository.Delete(c, id); err != nil {
+		return err
+	}
+
+	su.auditUsecase.Record(c, entity.AuditSuppressionDelete, map[string]any{"suppression_id": id})
+
+	logger.Info("Suppression removed")
+	return nil
+}
+
+func (su *SuppressionUsecase) ListSuppressions(
+	c context.Context,
+	tenantID string,
+	page int,
+) ([]entity.Suppression, error) {
+	return su.suppressionRepository.List(c, tenantID, page)
+}
+
+// HandleInboundMessage suppresses the sender when the reply is a STOP
+// keyword, any other inbound message is ignored.
+func (su *SuppressionUsecase) HandleInboundMessage(c context.Context, inbound entity.InboundMessage) error {
+	logger := log.FromCtx(c).WithFields("action", "Handle inbound message")
+
+	words := strings.Fields(inbound.Content)
+	if len(words) == 0 {
+		return nil
+	}
+
+	// "Stop.", "STOP!" and "¡Stop!" opt out as well
+	keyword := strings.ToUpper(strings.TrimFunc(words[0], unicode.IsPunct))
+	if _, ok := stopKeywords[keyword]; !ok {
+		logger.Debug("Inbound message is not an opt-out keyword")
+		return nil
+	}
+
+	reason := "Replied " + keyword
+	_, err := su.AddSuppression(c, entity.Suppression{
+		PhoneNumber: inbound.From,
+		Channel:     inbound.Channel,
+		TenantID:    inbound.TenantID,
+		Reason:      &reason,
+		Source:      entity.SuppressionSourceKeyword,
+	})
+	return err
+}
@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleInboundMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantReason string
+	}{
+		{name: "Keyword", content: "STOP", wantReason: "Replied STOP"},
+		{name: "LowerCase", content: "stop", wantReason: "Replied STOP"},
+		{name: "Spaces", content: "  Unsubscribe  ", wantReason: "Replied UNSUBSCRIBE"},
+		{name: "Period", content: "Stop.", wantReason: "Replied STOP"},
+		{name: "Exclamation", content: "STOP!", wantReason: "Replied STOP"},
+		{name: "Comma", content: "stop, please", wantReason: "Replied STOP"},
+		{name: "Quoted", content: `"quit"`, wantReason: "Replied QUIT"},
+		{name: "Inverted", content: "¡Stop!", wantReason: "Replied STOP"},
+		{name: "Ellipsis", content: "end…", wantReason: "Replied END"},
+		{name: "FollowedByText", content: "Cancel my subscription", wantReason: "Replied CANCEL"},
+		{name: "NotFirstWord", content: "Please stop"},
+		{name: "Prefix", content: "Stopping by later"},
+		{name: "InsideWord", content: "S.T.O.P"},
+		{name: "OnlyPunctuation", content: "!!!"},
+		{name: "Empty", content: " "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &suppressionStore{}
+			suppressions := NewSuppressionUsecase(store, &auditRecorder{}, entity.IngestConfig{})
+
+			err := suppressions.HandleInboundMessage(context.Background(), entity.InboundMessage{
+				From:     "+905551111111",
+				Content:  tt.content,
+				TenantID: "acme",
+			})
+			require.NoError(t, err)
+
+			if tt.wantReason == "" {
+				assert.Empty(t, store.suppressions)
+				return
+			}
+
+			require.Len(t, store.suppressions, 1)
+			suppression := store.suppressions[0]
+			assert.Equal(t, "+905551111111", suppression.PhoneNumber)
+			assert.Equal(t, "acme", suppression.TenantID)
+			assert.Equal(t, entity.SuppressionSourceKeyword, suppression.Source)
+			require.NotNil(t, suppression.Reason)
+			assert.Equal(t, tt.wantReason, *suppression.Reason)
+		})
+	}
+}
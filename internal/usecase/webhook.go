@@ -0,0 +1,330 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/dto"
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/shared/log"
+	"github.com/craftaholic/insider/internal/utils"
+)
+
+type WebhookUsecase struct {
+	webhookRepository interfaces.WebhookRepository
+	webhookSender     interfaces.WebhookSender
+	auditUsecase      interfaces.AuditUsecase
+
+	config entity.WebhookConfig
+}
+
+func NewWebhookUsecase(
+	webhookRepository interfaces.WebhookRepository,
+	webhookSender interfaces.WebhookSender,
+	auditUsecase interfaces.AuditUsecase,
+	config entity.WebhookConfig,
+) interfaces.WebhookUsecase {
+	return &WebhookUsecase{
+		webhookRepository: webhookRepository,
+		webhookSender:     webhookSender,
+		auditUsecase:      auditUsecase,
+		config:            config,
+	}
+}
+
+// CreateSubscription registers an endpoint for events of its tenant. A secret
+// is generated when none is given, it is only returned by this call.
+func (wu *WebhookUsecase) CreateSubscription(
+	c context.Context,
+	subscription entity.WebhookSubscription,
+) (entity.WebhookSubscription, error) {
+	logger := log.FromCtx(c).WithFields("action", "Create webhook subscription", "tenant_id", subscription.TenantID)
+
+	if err := validateWebhookURL(c, subscription.URL); err != nil {
+		return entity.WebhookSubscription{}, err
+	}
+
+	if len(subscription.EventTypes) == 0 {
+		subscription.EventTypes = entity.EventTypes
+	}
+	if subscription.TenantID == "" {
+		subscription.TenantID = entity.DefaultTenantID
+	}
+	if subscription.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return entity.WebhookSubscription{}, err
+		}
+		subscription.Secret = secret
+	}
+
+	if err := wu.webhookRepository.CreateSubscription(c, &subscription); err != nil {
+		return entity.WebhookSubscription{}, err
+	}
+
+	// Never the secret
+	wu.auditUsecase.Record(c, entity.AuditWebhookCreate, map[string]any{
+		"subscription_id": subscription.ID,
+		"url":             subscription.URL,
+		"tenant_id":       subscription.TenantID,
+		"event_types":     subscription.EventTypes,
+	})
+
+	logger.Info("Webhook subscription created", "subscription_id", subscription.ID, "event_types", subscription.EventTypes)
+	return subscription, nil
+}
+
+func (wu *WebhookUsecase) GetSubscription(c context.Context, id uint64) (entity.WebhookSubscription, error) {
+	return wu.webhookRepository.GetSubscription(c, id)
+}
+
+func (wu *WebhookUsecase) ListSubscriptions(c context.Context, tenantID string) ([]entity.WebhookSubscription, error) {
+	return wu.webhookRepository.ListSubscriptions(c, tenantID)
+}
+
+func (wu *WebhookUsecase) DeleteSubscription(c context.Context, id uint64) error {
+	logger := log.FromCtx(c).WithFields("action", "Delete webhook subscription", "subscription_id", id)
+
+	if err := wu.webhookRepository.DeleteSubscription(c, id); err != nil {
+		return err
+	}
+
+	wu.auditUsecase.Record(c, entity.AuditWebhookDelete, map[string]any{"subscription_id": id})
+
+	logger.Info("Webhook subscription deleted")
+	return nil
+}
+
+func (wu *WebhookUsecase) ListDeliveries(
+	c context.Context,
+	subscriptionID uint64,
+	page int,
+) ([]entity.WebhookDelivery, error) {
+	if _, err := wu.webhookRepository.GetSubscription(c, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	return wu.webhookRepository.ListDeliveries(c, subscriptionID, page)
+}
+
+// Publish queues one delivery of the event per subscription of the message
+// tenant receiving it, plus one to the callback url of the message when it
+// has one and the event is its final one. The dispatcher sends them in the
+// background.
+func (wu *WebhookUsecase) Publish(c context.Context, event entity.Event) error {
+	if !event.Type.Subscribable() {
+		return nil
+	}
+
+	subscriptions, err := wu.webhookRepository.ListSubscribers(c, event.Message.TenantID, event.Type)
+	if err != nil {
+		return fmt.Errorf("failed to list webhook subscribers: %w", err)
+	}
+
+	deliveries := make([]entity.WebhookDelivery, 0, len(subscriptions)+1)
+	if len(subscriptions) > 0 {
+		payload, err := json.Marshal(dto.ConvertEventToDTO(event))
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+
+		for _, subscription := range subscriptions {
+			deliveries = append(deliveries, entity.WebhookDelivery{
+				SubscriptionID: &subscription.ID,
+				URL:            subscription.URL,
+				EventID:        event.ID,
+				EventType:      event.Type,
+				Payload:        string(payload),
+				Status:         entity.DeliveryPending,
+				NextAttemptAt:  event.OccurredAt,
+			})
+		}
+	}
+
+	// A callback receives the message alone, once, it only ever concerns
+	// that message and its outcome
+	if event.Final && event.Message.CallbackURL != nil && *event.Message.CallbackURL != "" {
+		payload, err := json.Marshal(dto.ConvertMessageToDTO(event.Message))
+		if err != nil {
+			return fmt.Errorf("failed to marshal message: %w", err)
+		}
+
+		deliveries = append(deliveries, entity.WebhookDelivery{
+			URL:           *event.Message.CallbackURL,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			Status:        entity.DeliveryPending,
+			NextAttemptAt: event.OccurredAt,
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return wu.webhookRepository.CreateDeliveries(c, deliveries)
+}
+
+// StartDispatcher sends the queued deliveries in the background until c is done.
+func (wu *WebhookUsecase) StartDispatcher(c context.Context) {
+	if wu.config.DispatchInterval <= 0 || wu.config.DispatchBatch <= 0 {
+		log.FromCtx(c).Warn("Webhook delivery is disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(wu.config.DispatchInterval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.Done():
+				return
+			case <-ticker.C:
+				wu.dispatch(c)
+			}
+		}
+	}()
+}
+
+func (wu *WebhookUsecase) dispatch(c context.Context) {
+	logger := log.FromCtx(c).WithFields("action", "Dispatch webhook deliveries")
+
+	deliveries, err := wu.webhookRepository.ClaimDeliveries(c, wu.config.DispatchBatch, wu.config.Lease)
+	if err != nil {
+		logger.Error("Failed to claim webhook deliveries", "error", err)
+		return
+	}
+
+	secrets := map[uint64]string{}
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		secret, err := wu.secretFor(c, delivery, secrets)
+		if err != nil {
+			// The subscription is gone, so is its delivery log, or the
+			// callback secret has been unset since the message was created
+			logger.Warn("Dropping webhook delivery", "delivery_id", delivery.ID, "error", err)
+			updates := map[string]any{
+				"status":     entity.DeliveryFailed,
+				"last_error": err.Error(),
+				"updated_at": time.Now(),
+			}
+			if err = wu.webhookRepository.UpdateDelivery(c, delivery.ID, updates); err != nil {
+				logger.Error("Failed to drop webhook delivery", "delivery_id", delivery.ID, "error", err)
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wu.deliver(c, delivery, secret)
+		}()
+	}
+	wg.Wait()
+}
+
+// secretFor returns the secret the delivery is signed with, secrets keeps
+// the ones already loaded during this dispatch. Message callbacks have no
+// subscription and are signed with the callback secret.
+func (wu *WebhookUsecase) secretFor(
+	c context.Context,
+	delivery entity.WebhookDelivery,
+	secrets map[uint64]string,
+) (string, error) {
+	if delivery.SubscriptionID == nil {
+		if wu.config.CallbackSecret == "" {
+			return "", fmt.Errorf("delivery %d is a callback but no callback secret is set", delivery.ID)
+		}
+		return wu.config.CallbackSecret, nil
+	}
+
+	if secret, ok := secrets[*delivery.SubscriptionID]; ok {
+		return secret, nil
+	}
+
+	subscription, err := wu.webhookRepository.GetSubscription(c, *delivery.SubscriptionID)
+	if err != nil {
+		return "", err
+	}
+
+	secrets[subscription.ID] = subscription.Secret
+	return subscription.Secret, nil
+}
+
+// deliver sends one delivery and records the outcome, a failed attempt is
+// retried with exponential backoff until MaxAttempts is reached.
+func (wu *WebhookUsecase) deliver(c context.Context, delivery entity.WebhookDelivery, secret string) {
+	logger := log.FromCtx(c).WithFields("delivery_id", delivery.ID, "event_id", delivery.EventID,
+		"attempt", delivery.Attempts)
+
+	statusCode, err := wu.webhookSender.Send(c, delivery, secret)
+
+	now := time.Now()
+	updates := map[string]any{
+		"updated_at": now,
+	}
+	if statusCode > 0 {
+		updates["last_status_code"] = statusCode
+	}
+
+	switch {
+	case err == nil:
+		updates["status"] = entity.DeliverySucceeded
+		updates["delivered_at"] = now
+		updates["last_error"] = nil
+		logger.Info("Webhook delivered")
+	case delivery.Attempts >= wu.config.MaxAttempts:
+		updates["status"] = entity.DeliveryFailed
+		updates["last_error"] = err.Error()
+		logger.Error("Webhook delivery given up", "error", err)
+	default:
+		updates["status"] = entity.DeliveryPending
+		updates["next_attempt_at"] = now.Add(wu.config.RetryDelay(delivery.Attempts))
+		updates["last_error"] = err.Error()
+		logger.Warn("Webhook delivery failed, retrying later", "error", err, "next_attempt_at", updates["next_attempt_at"])
+	}
+
+	// Record the outcome even if the service is stopping
+	if err = wu.webhookRepository.UpdateDelivery(context.WithoutCancel(c), delivery.ID, updates); err != nil {
+		logger.Error("Failed to record webhook delivery outcome", "error", err)
+	}
+}
+
+// validateWebhookURL only accepts absolute http(s) urls of hosts resolving to
+// public addresses, so webhooks can't reach into the internal network. The
+// sender checks the address again when connecting, DNS answers may change.
+func validateWebhookURL(c context.Context, raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: webhook url %q must be an absolute http(s) url", entity.ErrValidation, raw)
+	}
+
+	addresses, err := net.DefaultResolver.LookupIPAddr(c, parsed.Hostname())
+	if err != nil {
+		return fmt.Errorf("%w: webhook url host %q can't be resolved", entity.ErrValidation, parsed.Hostname())
+	}
+	for _, address := range addresses {
+		if !utils.IsPublicIP(address.IP) {
+			return fmt.Errorf("%w: webhook url %q doesn't point to a public address", entity.ErrValidation, raw)
+		}
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
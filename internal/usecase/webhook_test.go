@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/domain/interfaces"
+	"github.com/craftaholic/insider/internal/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deliveryRecorder is a webhook repository without subscriptions keeping
+// the deliveries queued to it.
+type deliveryRecorder struct {
+	interfaces.WebhookRepository
+
+	mu         sync.Mutex
+	deliveries []entity.WebhookDelivery
+}
+
+func (r *deliveryRecorder) ListSubscribers(context.Context, string, entity.EventType) ([]entity.WebhookSubscription, error) {
+	return nil, nil
+}
+
+func (r *deliveryRecorder) CreateDeliveries(_ context.Context, deliveries []entity.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deliveries = append(r.deliveries, deliveries...)
+	return nil
+}
+
+func (r *deliveryRecorder) queued() []entity.WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]entity.WebhookDelivery(nil), r.deliveries...)
+}
+
+func TestCallbackPostedOnce(t *testing.T) {
+	tests := []struct {
+		name      string
+		send      notificationFunc
+		report    *entity.DeliveryReport
+		wantEvent entity.EventType
+		wantState entity.MessageStatus
+	}{
+		{
+			name:      "Sent",
+			send:      func(context.Context, entity.Message) (string, error) { return "provider-1", nil },
+			wantEvent: entity.EventMessageSent,
+			wantState: entity.StatusSent,
+		},
+		{
+			name:      "SentThenDelivered",
+			send:      func(context.Context, entity.Message) (string, error) { return "provider-1", nil },
+			report:    &entity.DeliveryReport{MessageID: "provider-1", Delivered: true},
+			wantEvent: entity.EventMessageSent,
+			wantState: entity.StatusSent,
+		},
+		{
+			name:      "SentThenUndelivered",
+			send:      func(context.Context, entity.Message) (string, error) { return "provider-1", nil },
+			report:    &entity.DeliveryReport{MessageID: "provider-1", Delivered: false},
+			wantEvent: entity.EventMessageSent,
+			wantState: entity.StatusSent,
+		},
+		{
+			name: "Rejected",
+			send: func(context.Context, entity.Message) (string, error) {
+				return "", &entity.NotificationError{Class: entity.ErrorClassRejected, StatusCode: 400, Detail: "rejected"}
+			},
+			wantEvent: entity.EventMessageFailed,
+			wantState: entity.StatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			webhooks := &deliveryRecorder{}
+			messages := memory.NewMessageRepository(nil, nil)
+
+			messageUsecase := newTestMessageUsecase(messages, tt.send, testServiceConfig())
+			messageUsecase.eventPublisher = NewWebhookUsecase(webhooks, nil, &auditRecorder{}, entity.WebhookConfig{CallbackSecret: "secret"})
+
+			callbackURL := "https://booking.example.com/sms"
+			message := entity.Message{PhoneNumber: "+905551111113", Content: "Your code is 1234", CallbackURL: &callbackURL}
+			require.NoError(t, messages.Create(ctx, &message))
+
+			require.NoError(t, messageUsecase.StartAutomatedSending(ctx))
+			require.Eventually(t, func() bool {
+				return len(messagesIn(t, messages, tt.wantState)) == 1
+			}, 5*time.Second, 10*time.Millisecond)
+			require.NoError(t, messageUsecase.StopAutomatedSending(ctx))
+
+			if tt.report != nil {
+				require.NoError(t, messageUsecase.HandleDeliveryReport(ctx, *tt.report))
+			}
+
+			queued := webhooks.queued()
+			require.Len(t, queued, 1)
+			assert.Nil(t, queued[0].SubscriptionID)
+			assert.Equal(t, callbackURL, queued[0].URL)
+			assert.Equal(t, tt.wantEvent, queued[0].EventType)
+
+			var posted map[string]any
+			require.NoError(t, json.Unmarshal([]byte(queued[0].Payload), &posted))
+			assert.Equal(t, string(tt.wantState), posted["status"])
+		})
+	}
+}
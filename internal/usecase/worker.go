@@ -2,78 +2,292 @@ package usecase
 
 import (
 	"context"
+	"hash/fnv"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/craftaholic/insider/internal/domain/entity"
 	"github.com/craftaholic/insider/internal/shared/log"
 )
 
 type WorkerPool struct {
-	ctx         context.Context
-	cancel      context.CancelFunc
-	jobChan     chan entity.Message
-	workerCount int
-	wg          sync.WaitGroup
+	ctx          context.Context
+	cancel       context.CancelFunc
+	jobChan      chan entity.Message
+	workerCount  int
+	jobTimeout   time.Duration
+	processor    func(context.Context, entity.Message) error
+	panicHandler func(context.Context, entity.Message, any)
+	workers      []*worker
+	nextWorkerID int
+	inFlight     atomic.Int64
+	mu           sync.Mutex
+	wg           sync.WaitGroup
+
+	// In ordered mode every worker owns a shard of shardBuffer
+	// messages instead of sharing jobChan
+	ordered     bool
+	shardBuffer int
+}
+
+type worker struct {
+	id   int
+	jobs chan entity.Message
+	quit chan struct{}
 }
 
-func newWorkerPool(ctx context.Context, workerCount int, buffer int) *WorkerPool {
+// newWorkerPool creates a pool where each message gets at most jobTimeout
+// to be processed, zero means no deadline other than the pool being stopped.
+func newWorkerPool(ctx context.Context, workerCount int, buffer int, jobTimeout time.Duration) *WorkerPool {
 	ctx, cancel := context.WithCancel(ctx)
 	return &WorkerPool{
 		ctx:         ctx,
 		cancel:      cancel,
 		jobChan:     make(chan entity.Message, buffer),
 		workerCount: workerCount,
+		jobTimeout:  jobTimeout,
 		wg:          sync.WaitGroup{},
 	}
 }
 
+// newOrderedWorkerPool creates a pool where messages sharing the same
+// ordering key always go to the same worker, so they are sent one after
+// the other in the order they were added. The buffer is split between
+// the worker shards, whose number is fixed: Resize leaves the pool as is.
+func newOrderedWorkerPool(ctx context.Context, workerCount int, buffer int, jobTimeout time.Duration) *WorkerPool {
+	wp := newWorkerPool(ctx, workerCount, 0, jobTimeout)
+	wp.ordered = true
+	wp.shardBuffer = max(1, (buffer+workerCount-1)/max(1, workerCount))
+	return wp
+}
+
 // Start will create multiple workers each runs in
 // 1 go routines. Each of these workers will handle
 // 1 message from the db (every 2 mins there will
-// be new messages sent into the channel).
-func (wp *WorkerPool) Start(processor func(context.Context, entity.Message) error) {
-	for i := range wp.workerCount {
-		wp.wg.Add(1)
-		go func(workerID int) {
-			defer wp.wg.Done()
-
-			for {
-				select {
-				case message := <-wp.jobChan:
-					// This will always be handled first if there are still
-					// messages in the channel this will execute all of it first
-					// before checking the condition of the context
-					if err := processor(wp.ctx, message); err != nil {
-						// Log error
-						log.FromCtx(wp.ctx).Error("Worker failed to process message",
-							"workerID", workerID, "messageID", message.ID, "error", err)
-					}
-				case <-wp.ctx.Done():
-					// If all messages in the channel is handled then it will check
-					// the ctx.Done condition to make sure no messages droped while
-					// there is a stop signal
-					return
-				}
+// be new messages sent into the channel). A panic
+// while processing a message is handed to panicHandler
+// and the worker moves on to the next message.
+func (wp *WorkerPool) Start(
+	processor func(context.Context, entity.Message) error,
+	panicHandler func(context.Context, entity.Message, any),
+) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.processor = processor
+	wp.panicHandler = panicHandler
+	for range wp.workerCount {
+		wp.spawnWorker()
+	}
+}
+
+// Resize grows or shrinks the pool to workerCount workers while it
+// is running. New workers start consuming right away, retired workers
+// finish the message they are currently handling before they exit. An
+// ordered pool isn't resized, keys would hash to other workers while
+// their messages are still queued in their shard.
+func (wp *WorkerPool) Resize(workerCount int) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if wp.ctx.Err() != nil || wp.ordered {
+		return
+	}
+
+	for len(wp.workers) < workerCount {
+		wp.spawnWorker()
+	}
+
+	for len(wp.workers) > workerCount {
+		last := len(wp.workers) - 1
+		close(wp.workers[last].quit)
+		wp.workers = wp.workers[:last]
+	}
+
+	wp.workerCount = workerCount
+}
+
+// Size returns the number of workers currently running.
+func (wp *WorkerPool) Size() int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	return len(wp.workers)
+}
+
+// Queued returns the number of messages waiting in the job channel.
+func (wp *WorkerPool) Queued() int {
+	queued, _ := wp.usage()
+	return queued
+}
+
+// InFlight returns the number of messages workers are handling right now.
+func (wp *WorkerPool) InFlight() int {
+	return int(wp.inFlight.Load())
+}
+
+// FreeSlots returns how many messages can be added without AddJob failing.
+// Only the fetcher adds jobs so the value can't shrink behind its back. In
+// ordered mode it is the sum over all shards, a batch hashing unevenly may
+// still find its shard full.
+func (wp *WorkerPool) FreeSlots() int {
+	queued, capacity := wp.usage()
+	return capacity - queued
+}
+
+// Stats returns a snapshot of the pool usage.
+func (wp *WorkerPool) Stats() entity.WorkerPoolStats {
+	queued, capacity := wp.usage()
+	return entity.WorkerPoolStats{
+		Workers:   wp.Size(),
+		InFlight:  wp.InFlight(),
+		Queued:    queued,
+		FreeSlots: capacity - queued,
+		Capacity:  capacity,
+		Ordered:   wp.ordered,
+	}
+}
+
+// Occupancy returns how full the job channel is, between 0 and 1.
+func (wp *WorkerPool) Occupancy() float64 {
+	queued, capacity := wp.usage()
+	if capacity == 0 {
+		return 0
+	}
+
+	return float64(queued) / float64(capacity)
+}
+
+// usage returns the number of queued messages and the buffer capacity,
+// summed over the shards in ordered mode.
+func (wp *WorkerPool) usage() (int, int) {
+	if !wp.ordered {
+		return len(wp.jobChan), cap(wp.jobChan)
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	queued, capacity := 0, 0
+	for _, w := range wp.workers {
+		queued += len(w.jobs)
+		capacity += cap(w.jobs)
+	}
+	return queued, capacity
+}
+
+// spawnWorker must be called with wp.mu held.
+func (wp *WorkerPool) spawnWorker() {
+	w := &worker{
+		id:   wp.nextWorkerID,
+		jobs: wp.jobChan,
+		quit: make(chan struct{}),
+	}
+	if wp.ordered {
+		w.jobs = make(chan entity.Message, wp.shardBuffer)
+	}
+	wp.nextWorkerID++
+	wp.workers = append(wp.workers, w)
+
+	wp.wg.Add(1)
+	go wp.runWorker(w)
+}
+
+func (wp *WorkerPool) runWorker(w *worker) {
+	defer wp.wg.Done()
+
+	for {
+		select {
+		case message := <-w.jobs:
+			// This will always be handled first if there are still
+			// messages in the channel this will execute all of it first
+			// before checking the condition of the context
+			wp.runJob(w.id, message)
+		case <-w.quit:
+			// The worker has been retired by Resize, the message it was
+			// handling (if any) is already done at this point
+			return
+		case <-wp.ctx.Done():
+			// If all messages in the channel is handled then it will check
+			// the ctx.Done condition to make sure no messages droped while
+			// there is a stop signal
+			return
+		}
+	}
+}
+
+// runJob processes one message under its own deadline. Stopping the pool
+// cancels the deadline context so a hung send is interrupted.
+func (wp *WorkerPool) runJob(workerID int, message entity.Message) {
+	wp.inFlight.Add(1)
+	defer wp.inFlight.Add(-1)
+
+	ctx, cancel := wp.ctx, context.CancelFunc(func() {})
+	if wp.jobTimeout > 0 {
+		ctx, cancel = context.WithTimeout(wp.ctx, wp.jobTimeout)
+	}
+	defer cancel()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.FromCtx(ctx).Error("Worker recovered from panic while processing message",
+				"workerID", workerID, "messageID", message.ID, "panic", recovered, "stack", string(debug.Stack()))
+
+			if wp.panicHandler != nil {
+				wp.panicHandler(ctx, message, recovered)
 			}
-		}(i)
+		}
+	}()
+
+	if err := wp.processor(ctx, message); err != nil {
+		// Log error
+		log.FromCtx(ctx).Error("Worker failed to process message",
+			"workerID", workerID, "messageID", message.ID, "error", err)
 	}
 }
 
 // AddJob will continue add job to the jobChan buffer
 // if there is a cancel signal event -> stop receiving
-// new message.
+// new message. In ordered mode the message goes to
+// the shard its ordering key hashes to.
 func (wp *WorkerPool) AddJob(message entity.Message) bool {
-	select {
-	// Always check the context first to
-	case <-wp.ctx.Done():
+	if !wp.ordered {
+		return wp.enqueue(wp.jobChan, message)
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if len(wp.workers) == 0 {
 		return false
-	case wp.jobChan <- message:
+	}
+
+	return wp.enqueue(wp.workers[shardOf(message.SequenceKey(), len(wp.workers))].jobs, message)
+}
+
+func (wp *WorkerPool) enqueue(jobs chan<- entity.Message, message entity.Message) bool {
+	// Always check the context first, a select between it and a free
+	// slot would pick either
+	if wp.ctx.Err() != nil {
+		return false
+	}
+
+	select {
+	case jobs <- message:
 		return true
 	default:
 		return false
 	}
 }
 
+func shardOf(key string, shards int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(shards))
+}
+
 // Stop function will send a signal event to the context
 // that's being used to stop receiving all new messages.
 func (wp *WorkerPool) Stop() {
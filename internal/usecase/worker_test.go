@@ -0,0 +1,328 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noopProcessor processes every message successfully without doing anything.
+func noopProcessor(context.Context, entity.Message) error {
+	return nil
+}
+
+func TestWorkerPoolResize(t *testing.T) {
+	tests := []struct {
+		name    string
+		ordered bool
+		workers int
+		stopped bool
+		resizes []int
+		want    int
+	}{
+		{name: "Grow", workers: 2, resizes: []int{5}, want: 5},
+		{name: "Shrink", workers: 4, resizes: []int{1}, want: 1},
+		{name: "GrowThenShrink", workers: 1, resizes: []int{3, 2}, want: 2},
+		{name: "ShrinkToZero", workers: 3, resizes: []int{0}, want: 0},
+		{name: "Stopped", workers: 2, stopped: true, resizes: []int{4}, want: 2},
+		{name: "Ordered", ordered: true, workers: 3, resizes: []int{6}, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newWorkerPool(context.Background(), tt.workers, 4, 0)
+			if tt.ordered {
+				pool = newOrderedWorkerPool(context.Background(), tt.workers, 4, 0)
+			}
+			pool.Start(noopProcessor, nil)
+			defer pool.Stop()
+
+			if tt.stopped {
+				pool.Stop()
+			}
+			for _, size := range tt.resizes {
+				pool.Resize(size)
+			}
+
+			assert.Equal(t, tt.want, pool.Size())
+			assert.Equal(t, tt.want, pool.Stats().Workers)
+		})
+	}
+}
+
+func TestWorkerPoolResizeFinishesMessages(t *testing.T) {
+	pool := newWorkerPool(context.Background(), 2, 4, 0)
+
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	var processed atomic.Int32
+	pool.Start(func(context.Context, entity.Message) error {
+		started <- struct{}{}
+		<-release
+		processed.Add(1)
+		return nil
+	}, nil)
+
+	require.True(t, pool.AddJob(entity.Message{ID: 1}))
+	require.True(t, pool.AddJob(entity.Message{ID: 2}))
+	for range 2 {
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("messages weren't picked up")
+		}
+	}
+
+	// Retired workers are gone from the pool but still finish their message
+	pool.Resize(0)
+	assert.Equal(t, 0, pool.Size())
+	assert.Equal(t, 2, pool.InFlight())
+
+	close(release)
+	pool.Stop()
+	assert.EqualValues(t, 2, processed.Load())
+	assert.Equal(t, 0, pool.InFlight())
+}
+
+func TestWorkerPoolFreeSlots(t *testing.T) {
+	tests := []struct {
+		name      string
+		added     int
+		wantAdded int
+		wantFree  int
+	}{
+		{name: "Empty", added: 0, wantAdded: 0, wantFree: 3},
+		{name: "Partial", added: 2, wantAdded: 2, wantFree: 1},
+		{name: "Full", added: 3, wantAdded: 3, wantFree: 0},
+		{name: "Overflow", added: 5, wantAdded: 3, wantFree: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Not started, nothing takes the jobs out of the buffer
+			pool := newWorkerPool(context.Background(), 1, 3, 0)
+
+			added := 0
+			for i := range tt.added {
+				if pool.AddJob(entity.Message{ID: uint64(i + 1)}) {
+					added++
+				}
+			}
+
+			assert.Equal(t, tt.wantAdded, added)
+			assert.Equal(t, tt.wantFree, pool.FreeSlots())
+			assert.Equal(t, tt.wantAdded, pool.Queued())
+
+			stats := pool.Stats()
+			assert.Equal(t, 3, stats.Capacity)
+			assert.Equal(t, tt.wantFree, stats.FreeSlots)
+		})
+	}
+}
+
+func TestWorkerPoolStoppedRefusesJobs(t *testing.T) {
+	pool := newWorkerPool(context.Background(), 1, 3, 0)
+	pool.Start(noopProcessor, nil)
+	pool.Stop()
+
+	assert.False(t, pool.AddJob(entity.Message{ID: 1}))
+	assert.Equal(t, 0, pool.Queued())
+}
+
+func TestOrderedWorkerPoolCapacity(t *testing.T) {
+	// The buffer is split between the shards, rounded up
+	pool := newOrderedWorkerPool(context.Background(), 3, 7, 0)
+	pool.Start(noopProcessor, nil)
+	defer pool.Stop()
+
+	stats := pool.Stats()
+	assert.True(t, stats.Ordered)
+	assert.Equal(t, 9, stats.Capacity)
+	assert.Equal(t, 9, pool.FreeSlots())
+}
+
+func TestWorkerPoolJobDeadline(t *testing.T) {
+	tests := []struct {
+		name        string
+		jobTimeout  time.Duration
+		stop        bool
+		wantErr     error
+		wantExpires bool
+	}{
+		{name: "Timeout", jobTimeout: 20 * time.Millisecond, wantErr: context.DeadlineExceeded, wantExpires: true},
+		{name: "StopWithoutTimeout", stop: true, wantErr: context.Canceled},
+		{name: "StopBeforeTimeout", jobTimeout: time.Hour, stop: true, wantErr: context.Canceled, wantExpires: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newWorkerPool(context.Background(), 1, 1, tt.jobTimeout)
+
+			started := make(chan struct{})
+			done := make(chan error, 1)
+			var expires atomic.Bool
+			pool.Start(func(ctx context.Context, _ entity.Message) error {
+				_, ok := ctx.Deadline()
+				expires.Store(ok)
+				close(started)
+
+				// A hung send, only the context gets it out
+				<-ctx.Done()
+				done <- ctx.Err()
+				return ctx.Err()
+			}, nil)
+			defer pool.Stop()
+
+			require.True(t, pool.AddJob(entity.Message{ID: 1}))
+			<-started
+			if tt.stop {
+				pool.Stop()
+			}
+
+			select {
+			case err := <-done:
+				require.ErrorIs(t, err, tt.wantErr)
+			case <-time.After(5 * time.Second):
+				t.Fatal("the message wasn't interrupted")
+			}
+			assert.Equal(t, tt.wantExpires, expires.Load())
+		})
+	}
+}
+
+func TestWorkerPoolRecoversPanics(t *testing.T) {
+	pool := newWorkerPool(context.Background(), 1, 4, 0)
+
+	type recovery struct {
+		messageID uint64
+		recovered any
+	}
+	recovered := make(chan recovery, 4)
+	processed := make(chan uint64, 4)
+	pool.Start(func(_ context.Context, message entity.Message) error {
+		if message.ID%2 == 1 {
+			panic("provider client bug")
+		}
+		processed <- message.ID
+		return nil
+	}, func(_ context.Context, message entity.Message, value any) {
+		recovered <- recovery{messageID: message.ID, recovered: value}
+	})
+
+	for id := range uint64(4) {
+		require.True(t, pool.AddJob(entity.Message{ID: id + 1}))
+	}
+
+	// The single worker survives every panic and goes on with the next message
+	for _, want := range []uint64{2, 4} {
+		select {
+		case id := <-processed:
+			assert.Equal(t, want, id)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the worker stopped after a panic")
+		}
+	}
+	pool.Stop()
+
+	close(recovered)
+	var recoveries []recovery
+	for r := range recovered {
+		recoveries = append(recoveries, r)
+	}
+	assert.Equal(t, []recovery{{1, "provider client bug"}, {3, "provider client bug"}}, recoveries)
+	assert.Equal(t, 1, pool.Size())
+	assert.Equal(t, 0, pool.InFlight())
+}
+
+func TestShardOfSequenceKey(t *testing.T) {
+	orderingKey := func(key string) *string { return &key }
+
+	tests := []struct {
+		name   string
+		first  entity.Message
+		second entity.Message
+	}{
+		{
+			name:   "SameOrderingKey",
+			first:  entity.Message{PhoneNumber: "+905551111111", OrderingKey: orderingKey("booking-42")},
+			second: entity.Message{PhoneNumber: "+905552222222", OrderingKey: orderingKey("booking-42")},
+		},
+		{
+			name:   "SamePhoneNumber",
+			first:  entity.Message{PhoneNumber: "+905551111111"},
+			second: entity.Message{PhoneNumber: "+905551111111"},
+		},
+		{
+			name:   "EmptyOrderingKey",
+			first:  entity.Message{PhoneNumber: "+905551111111", OrderingKey: orderingKey("")},
+			second: entity.Message{PhoneNumber: "+905551111111"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for shards := 1; shards <= 16; shards++ {
+				first := shardOf(tt.first.SequenceKey(), shards)
+				assert.Equal(t, first, shardOf(tt.second.SequenceKey(), shards), "%d shards", shards)
+				assert.GreaterOrEqual(t, first, 0)
+				assert.Less(t, first, shards)
+			}
+		})
+	}
+}
+
+func TestShardOfSpreadsKeys(t *testing.T) {
+	used := map[int]int{}
+	for i := range 100 {
+		used[shardOf(fmt.Sprintf("+9055511%05d", i), 4)]++
+	}
+
+	// Every shard gets a share of the recipients
+	assert.Len(t, used, 4)
+	for shard, count := range used {
+		assert.Greater(t, count, 5, "shard %d", shard)
+	}
+}
+
+func TestOrderedWorkerPoolKeepsKeyOrder(t *testing.T) {
+	pool := newOrderedWorkerPool(context.Background(), 3, 64, 0)
+
+	var mu sync.Mutex
+	sent := map[string][]uint64{}
+	pool.Start(func(_ context.Context, message entity.Message) error {
+		// Uneven send times would reorder messages sharing workers
+		time.Sleep(time.Duration(message.ID%3) * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		sent[message.SequenceKey()] = append(sent[message.SequenceKey()], message.ID)
+		return nil
+	}, nil)
+
+	want := map[string][]uint64{}
+	for id := range uint64(30) {
+		key := fmt.Sprintf("+90555111111%d", id%5)
+		want[key] = append(want[key], id+1)
+		require.True(t, pool.AddJob(entity.Message{ID: id + 1, PhoneNumber: key}))
+	}
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		count := 0
+		for _, ids := range sent {
+			count += len(ids)
+		}
+		return count == 30
+	}, 5*time.Second, 10*time.Millisecond)
+	pool.Stop()
+
+	assert.Equal(t, want, sent)
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// reservedNetworks are the ranges not covered by the net.IP predicates that
+// still never reach the internet.
+var reservedNetworks = parseCIDRs(
+	"0.0.0.0/8",     // "this" network
+	"100.64.0.0/10", // carrier-grade NAT, some cloud metadata services live there
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved, broadcast included
+	"64:ff9b::/96",  // NAT64, maps to any IPv4 address
+)
+
+// IsPublicIP reports whether ip is routable on the internet. Loopback,
+// private, link-local (cloud metadata endpoints included), multicast,
+// unspecified and reserved addresses aren't.
+func IsPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// PublicDialControl is a net.Dialer Control refusing connections to
+// addresses that aren't public. It runs once the host has been resolved, so
+// neither a DNS change nor a redirect gets around it.
+func PublicDialControl(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("connecting to non-public address %s is not allowed", host)
+	}
+	return nil
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
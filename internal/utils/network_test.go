@@ -0,0 +1,49 @@
+package utils_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/craftaholic/insider/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.215.14", true},
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"fd00:ec2::254", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"100.100.100.200", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			assert.Equal(t, tt.want, utils.IsPublicIP(net.ParseIP(tt.ip)))
+		})
+	}
+}
+
+func TestPublicDialControl(t *testing.T) {
+	assert.NoError(t, utils.PublicDialControl("tcp4", "93.184.215.14:443", nil))
+	assert.Error(t, utils.PublicDialControl("tcp4", "169.254.169.254:80", nil))
+	assert.Error(t, utils.PublicDialControl("tcp6", "[::1]:8080", nil))
+	assert.Error(t, utils.PublicDialControl("tcp4", "localhost:80", nil))
+}
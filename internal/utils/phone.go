@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/nyaruka/phonenumbers"
+)
+
+// smsRoutableTypes are the number types a text message can be delivered to.
+var smsRoutableTypes = map[phonenumbers.PhoneNumberType]struct{}{
+	phonenumbers.MOBILE:               {},
+	phonenumbers.FIXED_LINE_OR_MOBILE: {},
+	phonenumbers.PERSONAL_NUMBER:      {},
+	phonenumbers.VOIP:                 {},
+}
+
+// NormalizePhoneNumber parses raw, either in international format or in the
+// local format of defaultRegion, and returns it in E.164 along with the ISO
+// 3166 code of the country it belongs to. Numbers that are invalid or that
+// can't receive text messages are rejected with an entity.ErrValidation.
+func NormalizePhoneNumber(raw string, defaultRegion string) (string, string, error) {
+	number, err := phonenumbers.Parse(raw, defaultRegion)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: phone number %q can't be parsed: %w", entity.ErrValidation, raw, err)
+	}
+
+	if !phonenumbers.IsValidNumber(number) {
+		return "", "", fmt.Errorf("%w: phone number %q is not a valid number", entity.ErrValidation, raw)
+	}
+
+	if _, ok := smsRoutableTypes[phonenumbers.GetNumberType(number)]; !ok {
+		return "", "", fmt.Errorf("%w: phone number %q can't receive text messages", entity.ErrValidation, raw)
+	}
+
+	return phonenumbers.Format(number, phonenumbers.E164), phonenumbers.GetRegionCodeForNumber(number), nil
+}
+
+// PhoneTimezone returns the IANA timezone of an E.164 phone number, the first
+// one for numbers of countries spanning several. It is empty when unknown.
+func PhoneTimezone(e164 string) string {
+	number, err := phonenumbers.Parse(e164, "")
+	if err != nil {
+		return ""
+	}
+
+	timezones, err := phonenumbers.GetTimezonesForNumber(number)
+	if err != nil || len(timezones) == 0 || timezones[0] == phonenumbers.UNKNOWN_TIMEZONE {
+		return ""
+	}
+
+	return timezones[0]
+}
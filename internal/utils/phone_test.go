@@ -0,0 +1,79 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	tests := []struct {
+		name          string
+		raw           string
+		defaultRegion string
+		wantNumber    string
+		wantCountry   string
+	}{
+		{"E164", "+905321234567", "TR", "+905321234567", "TR"},
+		{"International", "+90 (532) 123-45-67", "", "+905321234567", "TR"},
+		{"InternationalOtherRegion", "+447400123456", "TR", "+447400123456", "GB"},
+		{"Local", "0532 123 45 67", "TR", "+905321234567", "TR"},
+		{"LocalWithoutTrunkPrefix", "5321234567", "TR", "+905321234567", "TR"},
+		{"LocalOtherRegion", "07400 123456", "GB", "+447400123456", "GB"},
+		{"FixedLineOrMobile", "(650) 253-0000", "US", "+16502530000", "US"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			number, country, err := utils.NormalizePhoneNumber(tt.raw, tt.defaultRegion)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantNumber, number)
+			assert.Equal(t, tt.wantCountry, country)
+		})
+	}
+}
+
+func TestNormalizePhoneNumberInvalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		raw           string
+		defaultRegion string
+	}{
+		{"Empty", "", "TR"},
+		{"NotANumber", "call me", "TR"},
+		{"TooShort", "+90532123", "TR"},
+		{"LocalWithoutRegion", "05321234567", ""},
+		{"UnknownCountryCode", "+9991234567", "TR"},
+		{"FixedLine", "+902121234567", "TR"},
+		{"TollFree", "08001234567", "TR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := utils.NormalizePhoneNumber(tt.raw, tt.defaultRegion)
+			require.ErrorIs(t, err, entity.ErrValidation)
+		})
+	}
+}
+
+func TestPhoneTimezone(t *testing.T) {
+	tests := []struct {
+		name string
+		e164 string
+		want string
+	}{
+		{"Turkey", "+905321234567", "Europe/Istanbul"},
+		{"UnitedStates", "+16502530000", "America/Los_Angeles"},
+		{"NotANumber", "call me", ""},
+		{"NotE164", "05321234567", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, utils.PhoneTimezone(tt.e164))
+		})
+	}
+}
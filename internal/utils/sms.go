@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	gsm7SingleSegment = 160 // septets
+	gsm7MultiSegment  = 153 // septets left once the concatenation header is added
+	ucs2SingleSegment = 70  // UTF-16 code units
+	ucs2MultiSegment  = 67
+)
+
+// gsm7Basic is the GSM 03.38 default alphabet, each character takes one septet.
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension characters are sent as an escape plus the character, two septets.
+const gsm7Extension = "\f^{}\\[~]|€"
+
+// transliterations replace common characters that are missing from GSM-7,
+// anything else is decomposed and stripped of its accents.
+var transliterations = map[rune]string{
+	'‘': "'", '’': "'", '‚': "'", '′': "'",
+	'“': "\"", '”': "\"", '„': "\"", '″': "\"",
+	'–': "-", '—': "-", '‐': "-", '−': "-",
+	'…': "...", '•': "*", '\t': " ", ' ': " ",
+	'ı': "i", 'İ': "I", 'ł': "l", 'Ł': "L", 'đ': "d", 'Đ': "D",
+	'œ': "oe", 'Œ': "OE", '«': "\"", '»': "\"", '`': "'", '´': "'",
+}
+
+// gsm7Septets returns how many septets r takes in GSM-7, 0 when it isn't part of it.
+func gsm7Septets(r rune) int {
+	switch {
+	case strings.ContainsRune(gsm7Basic, r):
+		return 1
+	case strings.ContainsRune(gsm7Extension, r):
+		return 2
+	default:
+		return 0
+	}
+}
+
+// DetectEncoding returns GSM-7 when every character of content is part of
+// the GSM alphabet and UCS-2 otherwise.
+func DetectEncoding(content string) entity.SMSEncoding {
+	for _, r := range content {
+		if gsm7Septets(r) == 0 {
+			return entity.EncodingUCS2
+		}
+	}
+	return entity.EncodingGSM7
+}
+
+// CountSegments returns the encoding of content and the number of SMS it is
+// split into. Above one segment every part carries a concatenation header, so
+// fewer characters fit, and an escaped GSM-7 character or a UTF-16 surrogate
+// pair is never split across two parts.
+func CountSegments(content string) (entity.SMSEncoding, int) {
+	encoding := DetectEncoding(content)
+
+	units := make([]int, 0, len(content))
+	single, multi := gsm7SingleSegment, gsm7MultiSegment
+	if encoding == entity.EncodingGSM7 {
+		for _, r := range content {
+			units = append(units, gsm7Septets(r))
+		}
+	} else {
+		single, multi = ucs2SingleSegment, ucs2MultiSegment
+		for _, r := range content {
+			units = append(units, utf16.RuneLen(r))
+		}
+	}
+
+	total := 0
+	for _, u := range units {
+		total += u
+	}
+	if total <= single {
+		return encoding, 1
+	}
+
+	segments, used := 1, 0
+	for _, u := range units {
+		if used+u > multi {
+			segments++
+			used = 0
+		}
+		used += u
+	}
+	return encoding, segments
+}
+
+// Transliterate replaces the characters of content that are missing from
+// GSM-7 with the closest GSM-7 equivalent, so the message isn't sent as
+// UCS-2. Characters without any equivalent become '?'.
+func Transliterate(content string) string {
+	var b strings.Builder
+	b.Grow(len(content))
+
+	for _, r := range content {
+		if gsm7Septets(r) > 0 {
+			b.WriteRune(r)
+			continue
+		}
+
+		if replacement, ok := transliterations[r]; ok {
+			b.WriteString(replacement)
+			continue
+		}
+
+		b.WriteString(stripAccents(r))
+	}
+
+	return b.String()
+}
+
+// stripAccents decomposes r and keeps its base characters, '?' when
+// they still aren't GSM-7.
+func stripAccents(r rune) string {
+	var b strings.Builder
+	for _, d := range norm.NFD.String(string(r)) {
+		if unicode.Is(unicode.Mn, d) {
+			continue
+		}
+		if gsm7Septets(d) == 0 {
+			return "?"
+		}
+		b.WriteRune(d)
+	}
+
+	if b.Len() == 0 {
+		return "?"
+	}
+	return b.String()
+}
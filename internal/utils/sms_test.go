@@ -0,0 +1,103 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/craftaholic/insider/internal/domain/entity"
+	"github.com/craftaholic/insider/internal/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    entity.SMSEncoding
+	}{
+		{"Empty", "", entity.EncodingGSM7},
+		{"Basic", "Hello @home, 100% ready? Ça va, Øyvind!", entity.EncodingGSM7},
+		{"Extension", "Total: 10€ {paid} [ok] ~ ^ | \\", entity.EncodingGSM7},
+		{"Turkish", "Şişli'de buluşalım", entity.EncodingUCS2},
+		{"LowercaseCedilla", "ça va", entity.EncodingUCS2},
+		{"CurlyQuotes", "“quoted”", entity.EncodingUCS2},
+		{"Emoji", "Thanks 👍", entity.EncodingUCS2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, utils.DetectEncoding(tt.content))
+		})
+	}
+}
+
+func TestCountSegments(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantEncoding entity.SMSEncoding
+		wantSegments int
+	}{
+		{"Empty", "", entity.EncodingGSM7, 1},
+
+		// 160 septets fit one SMS, 153 per part once split
+		{"GSM7Single", strings.Repeat("a", 160), entity.EncodingGSM7, 1},
+		{"GSM7JustOver", strings.Repeat("a", 161), entity.EncodingGSM7, 2},
+		{"GSM7TwoParts", strings.Repeat("a", 306), entity.EncodingGSM7, 2},
+		{"GSM7ThreeParts", strings.Repeat("a", 307), entity.EncodingGSM7, 3},
+
+		// Extension characters take two septets
+		{"ExtensionSingle", strings.Repeat("€", 80), entity.EncodingGSM7, 1},
+		{"ExtensionOver", strings.Repeat("€", 81), entity.EncodingGSM7, 2},
+		{"ExtensionAfterBasic", strings.Repeat("a", 159) + "€", entity.EncodingGSM7, 2},
+		// The escape and its character stay in the same part: the € moves to
+		// the second part, which the last character then overflows
+		{"ExtensionNotSplit", strings.Repeat("a", 152) + "€" + strings.Repeat("a", 152), entity.EncodingGSM7, 3},
+
+		// 70 UTF-16 code units fit one SMS, 67 per part once split
+		{"UCS2Single", strings.Repeat("ş", 70), entity.EncodingUCS2, 1},
+		{"UCS2JustOver", strings.Repeat("ş", 71), entity.EncodingUCS2, 2},
+		{"UCS2TwoParts", strings.Repeat("ş", 134), entity.EncodingUCS2, 2},
+		{"UCS2ThreeParts", strings.Repeat("ş", 135), entity.EncodingUCS2, 3},
+
+		// A single non GSM-7 character turns the whole message into UCS-2
+		{"UCS2FromOneCharacter", strings.Repeat("a", 100) + "ş", entity.EncodingUCS2, 2},
+
+		// Emojis are surrogate pairs, two code units that are never split
+		{"EmojiSingle", strings.Repeat("😀", 35), entity.EncodingUCS2, 1},
+		{"EmojiOver", strings.Repeat("😀", 36), entity.EncodingUCS2, 2},
+		{"SurrogatePairNotSplit", strings.Repeat("ş", 66) + "😀" + strings.Repeat("ş", 66), entity.EncodingUCS2, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoding, segments := utils.CountSegments(tt.content)
+			assert.Equal(t, tt.wantEncoding, encoding)
+			assert.Equal(t, tt.wantSegments, segments)
+		})
+	}
+}
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"GSM7Unchanged", "Café @ 10€, Ça va?", "Café @ 10€, Ça va?"},
+		{"Punctuation", "“Quoted” – it’s done…", "\"Quoted\" - it's done..."},
+		{"Turkish", "Şişli'de ığdır Ğ İ", "Sisli'de igdir G I"},
+		{"Accents", "ç ł ő ŕ", "c l o r"},
+		{"Ligature", "œuvre", "oeuvre"},
+		{"Whitespace", "a\tb\u00a0c", "a b c"},
+		{"NoEquivalent", "Thanks 👍 中", "Thanks ? ?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transliterated := utils.Transliterate(tt.content)
+			assert.Equal(t, tt.want, transliterated)
+			assert.Equal(t, entity.EncodingGSM7, utils.DetectEncoding(transliterated))
+		})
+	}
+}